@@ -0,0 +1,247 @@
+// Package bytecode decodes and disassembles JVM bytecode (JVMS 6.5).
+package bytecode
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// array types of newarray
+const (
+	TBoolean = 4
+	TChar    = 5
+	TFloat   = 6
+	TDouble  = 7
+	TByte    = 8
+	TShort   = 9
+	TInt     = 10
+	TLong    = 11
+)
+
+var (
+	_atm = map[int32]string{
+		TBoolean: "boolean",
+		TChar:    "char",
+		TFloat:   "float",
+		TDouble:  "double",
+		TByte:    "byte",
+		TShort:   "short",
+		TInt:     "int",
+		TLong:    "long",
+	}
+)
+
+// Error error occurred while decoding bytecode.
+type Error struct {
+	Offset int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bytecode: offset %d: %s", e.Offset, e.Msg)
+}
+
+// Instruction decoded instruction.
+type Instruction struct {
+	// Offset offset of the opcode, of the wide prefix for a wide instruction.
+	Offset int
+	// Length length in bytes, including the wide prefix and switch padding.
+	Length int
+	Opcode Opcode
+	// Wide whether the instruction is modified by the wide prefix.
+	Wide bool
+	// Operands operands in the order of the instruction format: a local
+	// variable index, a constant, a constant pool index, an array type or a
+	// branch offset relative to Offset. iinc holds index and constant,
+	// invokeinterface index, count and the zero byte, invokedynamic index and
+	// the two zero bytes, multianewarray index and dimensions.
+	Operands []int32
+	// Switch jump table of tableswitch and lookupswitch.
+	Switch *Switch
+}
+
+// Switch jump table of a tableswitch or lookupswitch, offsets are relative to
+// the offset of the instruction.
+type Switch struct {
+	Default int32
+	// Low and High key range of a tableswitch.
+	Low  int32
+	High int32
+	// Keys match keys, for a tableswitch Low to High.
+	Keys    []int32
+	Offsets []int32
+}
+
+// Branch returns the absolute target of a branch instruction.
+func (m *Instruction) Branch() int {
+	return m.Offset + int(m.Operands[0])
+}
+
+// IsBranch reports whether the instruction has a single branch offset
+// operand.
+func (m *Instruction) IsBranch() bool {
+	f := _opcodes[m.Opcode].format
+	return f == _branch2 || f == _branch4
+}
+
+// HasConstant reports whether the first operand is a constant pool index.
+func (m *Instruction) HasConstant() bool {
+	switch _opcodes[m.Opcode].format {
+	case _cp1, _cp2, _invokeinterface, _invokedynamic, _multianewarray:
+		return true
+	}
+	return false
+}
+
+// Decode decodes bytecode into instructions.
+func Decode(code []byte) (res []*Instruction, err error) {
+	res = make([]*Instruction, 0, len(code)/2)
+	var in *Instruction
+	for off := 0; off < len(code); off += in.Length {
+		if in, err = DecodeAt(code, off); err != nil {
+			return nil, err
+		}
+		res = append(res, in)
+	}
+	return
+}
+
+// DecodeAt decodes the instruction at offset off.
+func DecodeAt(code []byte, off int) (res *Instruction, err error) {
+	d := &decoder{code: code, pos: off}
+	res = &Instruction{Offset: off, Opcode: Opcode(d.u1())}
+	if !res.Opcode.Valid() {
+		return nil, &Error{Offset: off, Msg: fmt.Sprintf("illegal opcode 0x%02x", uint8(res.Opcode))}
+	}
+	f := _opcodes[res.Opcode].format
+	if f == _wide {
+		res.Wide = true
+		res.Opcode = Opcode(d.u1())
+		switch res.Opcode {
+		case Iload, Fload, Aload, Lload, Dload, Istore, Fstore, Astore, Lstore, Dstore, Ret:
+			res.Operands = []int32{int32(d.u2())}
+		case Iinc:
+			res.Operands = []int32{int32(d.u2()), int32(d.s2())}
+		default:
+			if d.err == nil {
+				return nil, &Error{Offset: off, Msg: fmt.Sprintf("wide can not modify %s", res.Opcode)}
+			}
+		}
+	} else {
+		switch f {
+		case _s1:
+			res.Operands = []int32{int32(d.s1())}
+		case _s2, _branch2:
+			res.Operands = []int32{int32(d.s2())}
+		case _cp1, _local, _atype:
+			res.Operands = []int32{int32(d.u1())}
+		case _cp2:
+			res.Operands = []int32{int32(d.u2())}
+		case _iinc:
+			res.Operands = []int32{int32(d.u1()), int32(d.s1())}
+		case _branch4:
+			res.Operands = []int32{d.s4()}
+		case _invokeinterface:
+			res.Operands = []int32{int32(d.u2()), int32(d.u1()), int32(d.u1())}
+		case _invokedynamic:
+			res.Operands = []int32{int32(d.u2()), int32(d.u1()), int32(d.u1())}
+		case _multianewarray:
+			res.Operands = []int32{int32(d.u2()), int32(d.u1())}
+		case _tableswitch, _lookupswitch:
+			// 0 to 3 bytes padding, so that default starts at a multiple of
+			// 4 from the start of the code
+			for d.pos%4 != 0 {
+				d.u1()
+			}
+			res.Switch = d.switchTable(f == _tableswitch)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	res.Length = d.pos - off
+	return
+}
+
+type decoder struct {
+	code []byte
+	pos  int
+	err  error
+}
+
+func (d *decoder) next(n int) (res []byte) {
+	if d.err != nil {
+		return
+	}
+	if len(d.code)-d.pos < n {
+		d.err = &Error{Offset: d.pos, Msg: "unexpected end of code"}
+		return
+	}
+	res = d.code[d.pos : d.pos+n]
+	d.pos += n
+	return
+}
+
+func (d *decoder) u1() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) s1() int8 {
+	return int8(d.u1())
+}
+
+func (d *decoder) u2() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) s2() int16 {
+	return int16(d.u2())
+}
+
+func (d *decoder) s4() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) switchTable(table bool) (res *Switch) {
+	res = &Switch{Default: d.s4()}
+	var n int64
+	if table {
+		res.Low, res.High = d.s4(), d.s4()
+		n = int64(res.High) - int64(res.Low) + 1
+		if d.err == nil && n <= 0 {
+			d.err = &Error{Offset: d.pos, Msg: fmt.Sprintf("tableswitch low %d greater than high %d", res.Low, res.High)}
+		}
+	} else {
+		n = int64(d.s4())
+		if d.err == nil && n < 0 {
+			d.err = &Error{Offset: d.pos, Msg: fmt.Sprintf("negative lookupswitch npairs %d", n)}
+		}
+	}
+	// every entry takes at least 4 bytes, don't trust n beyond the code left
+	if d.err != nil || n > int64(len(d.code)-d.pos)/4 {
+		if d.err == nil {
+			d.err = &Error{Offset: d.pos, Msg: "unexpected end of code"}
+		}
+		return
+	}
+	res.Keys = make([]int32, n)
+	res.Offsets = make([]int32, n)
+	for i := int64(0); i < n; i++ {
+		if table {
+			res.Keys[i] = res.Low + int32(i)
+		} else {
+			res.Keys[i] = d.s4()
+		}
+		res.Offsets[i] = d.s4()
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package bytecode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+func TestDecode(t *testing.T) {
+	code := []byte{
+		0x10, 0xfe, // 0: bipush -2
+		0xc4, 0x84, 0x01, 0x00, 0xff, 0x00, // 2: wide iinc 256, -256
+		0xaa, 0x00, 0x00, 0x00, // 8: tableswitch, 3 bytes padding
+		0x00, 0x00, 0x00, 0x20, // default 40
+		0x00, 0x00, 0x00, 0x01, // low 1
+		0x00, 0x00, 0x00, 0x02, // high 2
+		0x00, 0x00, 0x00, 0x1c, // 1: 36
+		0x00, 0x00, 0x00, 0x1d, // 2: 37
+		0xb9, 0x00, 0x01, 0x02, 0x00, // 32: invokeinterface #1, 2
+		0xb1,             // 37: return
+		0xa7, 0xff, 0xfa, // 38: goto 32
+	}
+	insts, err := Decode(code)
+	if err != nil {
+		t.Errorf("failed to decode, error(%v)", err)
+		t.FailNow()
+	}
+	if len(insts) != 6 {
+		t.Errorf("expected 6 instructions, got %d", len(insts))
+		t.FailNow()
+	}
+	if in := insts[0]; in.Opcode != Bipush || in.Operands[0] != -2 {
+		t.Errorf("unexpected instruction %+v", in)
+	}
+	if in := insts[1]; in.Opcode != Iinc || !in.Wide || in.Operands[0] != 256 || in.Operands[1] != -256 || in.Length != 6 {
+		t.Errorf("unexpected instruction %+v", in)
+	}
+	if in := insts[2]; in.Opcode != Tableswitch || in.Length != 24 || in.Switch.Default != 32 || len(in.Switch.Keys) != 2 || in.Switch.Keys[1] != 2 || in.Switch.Offsets[1] != 29 {
+		t.Errorf("unexpected instruction %+v %+v", in, in.Switch)
+	}
+	if in := insts[3]; in.Opcode != Invokeinterface || in.Offset != 32 || in.Operands[1] != 2 {
+		t.Errorf("unexpected instruction %+v", in)
+	}
+	if in := insts[5]; !in.IsBranch() || in.Branch() != 32 {
+		t.Errorf("unexpected instruction %+v", in)
+	}
+	for _, c := range [][]byte{{0x10}, {0xc4, 0x60}, {0xcb}, {0xaa, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 1}} {
+		if _, err = Decode(c); err == nil {
+			t.Errorf("expected error for %x", c)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	for _, m := range cf.Methods {
+		if m.Code == nil {
+			continue
+		}
+		s, err := Disassemble(m.Code.Code, cf.CpInfo)
+		if err != nil {
+			t.Errorf("failed to disassemble, error(%v)", err)
+			continue
+		}
+		if strings.Contains(s, "invokevirtual") && !strings.Contains(s, "// Method java/io/PrintStream.println:(Ljava/lang/String;)V") {
+			t.Errorf("unexpected listing:\n%s", s)
+		}
+		t.Logf("code:\n%s", s)
+	}
+}
+
+func TestPrintCode(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	s, err := cf.FormatCode(PrintCode)
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	exp := "         5: invokevirtual #15                 // Method java/io/PrintStream.println:(Ljava/lang/String;)V\n"
+	if !strings.Contains(s, exp) {
+		t.Errorf("missing code listing in:\n%s", s)
+	}
+}
@@ -0,0 +1,477 @@
+package bytecode
+
+import (
+	"fmt"
+)
+
+// Opcode instruction opcode.
+type Opcode uint8
+
+const (
+	Nop             Opcode = 0x00
+	AconstNull      Opcode = 0x01
+	IconstM1        Opcode = 0x02
+	Iconst0         Opcode = 0x03
+	Iconst1         Opcode = 0x04
+	Iconst2         Opcode = 0x05
+	Iconst3         Opcode = 0x06
+	Iconst4         Opcode = 0x07
+	Iconst5         Opcode = 0x08
+	Lconst0         Opcode = 0x09
+	Lconst1         Opcode = 0x0a
+	Fconst0         Opcode = 0x0b
+	Fconst1         Opcode = 0x0c
+	Fconst2         Opcode = 0x0d
+	Dconst0         Opcode = 0x0e
+	Dconst1         Opcode = 0x0f
+	Bipush          Opcode = 0x10
+	Sipush          Opcode = 0x11
+	Ldc             Opcode = 0x12
+	LdcW            Opcode = 0x13
+	Ldc2W           Opcode = 0x14
+	Iload           Opcode = 0x15
+	Lload           Opcode = 0x16
+	Fload           Opcode = 0x17
+	Dload           Opcode = 0x18
+	Aload           Opcode = 0x19
+	Iload0          Opcode = 0x1a
+	Iload1          Opcode = 0x1b
+	Iload2          Opcode = 0x1c
+	Iload3          Opcode = 0x1d
+	Lload0          Opcode = 0x1e
+	Lload1          Opcode = 0x1f
+	Lload2          Opcode = 0x20
+	Lload3          Opcode = 0x21
+	Fload0          Opcode = 0x22
+	Fload1          Opcode = 0x23
+	Fload2          Opcode = 0x24
+	Fload3          Opcode = 0x25
+	Dload0          Opcode = 0x26
+	Dload1          Opcode = 0x27
+	Dload2          Opcode = 0x28
+	Dload3          Opcode = 0x29
+	Aload0          Opcode = 0x2a
+	Aload1          Opcode = 0x2b
+	Aload2          Opcode = 0x2c
+	Aload3          Opcode = 0x2d
+	Iaload          Opcode = 0x2e
+	Laload          Opcode = 0x2f
+	Faload          Opcode = 0x30
+	Daload          Opcode = 0x31
+	Aaload          Opcode = 0x32
+	Baload          Opcode = 0x33
+	Caload          Opcode = 0x34
+	Saload          Opcode = 0x35
+	Istore          Opcode = 0x36
+	Lstore          Opcode = 0x37
+	Fstore          Opcode = 0x38
+	Dstore          Opcode = 0x39
+	Astore          Opcode = 0x3a
+	Istore0         Opcode = 0x3b
+	Istore1         Opcode = 0x3c
+	Istore2         Opcode = 0x3d
+	Istore3         Opcode = 0x3e
+	Lstore0         Opcode = 0x3f
+	Lstore1         Opcode = 0x40
+	Lstore2         Opcode = 0x41
+	Lstore3         Opcode = 0x42
+	Fstore0         Opcode = 0x43
+	Fstore1         Opcode = 0x44
+	Fstore2         Opcode = 0x45
+	Fstore3         Opcode = 0x46
+	Dstore0         Opcode = 0x47
+	Dstore1         Opcode = 0x48
+	Dstore2         Opcode = 0x49
+	Dstore3         Opcode = 0x4a
+	Astore0         Opcode = 0x4b
+	Astore1         Opcode = 0x4c
+	Astore2         Opcode = 0x4d
+	Astore3         Opcode = 0x4e
+	Iastore         Opcode = 0x4f
+	Lastore         Opcode = 0x50
+	Fastore         Opcode = 0x51
+	Dastore         Opcode = 0x52
+	Aastore         Opcode = 0x53
+	Bastore         Opcode = 0x54
+	Castore         Opcode = 0x55
+	Sastore         Opcode = 0x56
+	Pop             Opcode = 0x57
+	Pop2            Opcode = 0x58
+	Dup             Opcode = 0x59
+	DupX1           Opcode = 0x5a
+	DupX2           Opcode = 0x5b
+	Dup2            Opcode = 0x5c
+	Dup2X1          Opcode = 0x5d
+	Dup2X2          Opcode = 0x5e
+	Swap            Opcode = 0x5f
+	Iadd            Opcode = 0x60
+	Ladd            Opcode = 0x61
+	Fadd            Opcode = 0x62
+	Dadd            Opcode = 0x63
+	Isub            Opcode = 0x64
+	Lsub            Opcode = 0x65
+	Fsub            Opcode = 0x66
+	Dsub            Opcode = 0x67
+	Imul            Opcode = 0x68
+	Lmul            Opcode = 0x69
+	Fmul            Opcode = 0x6a
+	Dmul            Opcode = 0x6b
+	Idiv            Opcode = 0x6c
+	Ldiv            Opcode = 0x6d
+	Fdiv            Opcode = 0x6e
+	Ddiv            Opcode = 0x6f
+	Irem            Opcode = 0x70
+	Lrem            Opcode = 0x71
+	Frem            Opcode = 0x72
+	Drem            Opcode = 0x73
+	Ineg            Opcode = 0x74
+	Lneg            Opcode = 0x75
+	Fneg            Opcode = 0x76
+	Dneg            Opcode = 0x77
+	Ishl            Opcode = 0x78
+	Lshl            Opcode = 0x79
+	Ishr            Opcode = 0x7a
+	Lshr            Opcode = 0x7b
+	Iushr           Opcode = 0x7c
+	Lushr           Opcode = 0x7d
+	Iand            Opcode = 0x7e
+	Land            Opcode = 0x7f
+	Ior             Opcode = 0x80
+	Lor             Opcode = 0x81
+	Ixor            Opcode = 0x82
+	Lxor            Opcode = 0x83
+	Iinc            Opcode = 0x84
+	I2l             Opcode = 0x85
+	I2f             Opcode = 0x86
+	I2d             Opcode = 0x87
+	L2i             Opcode = 0x88
+	L2f             Opcode = 0x89
+	L2d             Opcode = 0x8a
+	F2i             Opcode = 0x8b
+	F2l             Opcode = 0x8c
+	F2d             Opcode = 0x8d
+	D2i             Opcode = 0x8e
+	D2l             Opcode = 0x8f
+	D2f             Opcode = 0x90
+	I2b             Opcode = 0x91
+	I2c             Opcode = 0x92
+	I2s             Opcode = 0x93
+	Lcmp            Opcode = 0x94
+	Fcmpl           Opcode = 0x95
+	Fcmpg           Opcode = 0x96
+	Dcmpl           Opcode = 0x97
+	Dcmpg           Opcode = 0x98
+	Ifeq            Opcode = 0x99
+	Ifne            Opcode = 0x9a
+	Iflt            Opcode = 0x9b
+	Ifge            Opcode = 0x9c
+	Ifgt            Opcode = 0x9d
+	Ifle            Opcode = 0x9e
+	IfIcmpeq        Opcode = 0x9f
+	IfIcmpne        Opcode = 0xa0
+	IfIcmplt        Opcode = 0xa1
+	IfIcmpge        Opcode = 0xa2
+	IfIcmpgt        Opcode = 0xa3
+	IfIcmple        Opcode = 0xa4
+	IfAcmpeq        Opcode = 0xa5
+	IfAcmpne        Opcode = 0xa6
+	Goto            Opcode = 0xa7
+	Jsr             Opcode = 0xa8
+	Ret             Opcode = 0xa9
+	Tableswitch     Opcode = 0xaa
+	Lookupswitch    Opcode = 0xab
+	Ireturn         Opcode = 0xac
+	Lreturn         Opcode = 0xad
+	Freturn         Opcode = 0xae
+	Dreturn         Opcode = 0xaf
+	Areturn         Opcode = 0xb0
+	Return          Opcode = 0xb1
+	Getstatic       Opcode = 0xb2
+	Putstatic       Opcode = 0xb3
+	Getfield        Opcode = 0xb4
+	Putfield        Opcode = 0xb5
+	Invokevirtual   Opcode = 0xb6
+	Invokespecial   Opcode = 0xb7
+	Invokestatic    Opcode = 0xb8
+	Invokeinterface Opcode = 0xb9
+	Invokedynamic   Opcode = 0xba
+	New             Opcode = 0xbb
+	Newarray        Opcode = 0xbc
+	Anewarray       Opcode = 0xbd
+	Arraylength     Opcode = 0xbe
+	Athrow          Opcode = 0xbf
+	Checkcast       Opcode = 0xc0
+	Instanceof      Opcode = 0xc1
+	Monitorenter    Opcode = 0xc2
+	Monitorexit     Opcode = 0xc3
+	Wide            Opcode = 0xc4
+	Multianewarray  Opcode = 0xc5
+	Ifnull          Opcode = 0xc6
+	Ifnonnull       Opcode = 0xc7
+	GotoW           Opcode = 0xc8
+	JsrW            Opcode = 0xc9
+	Breakpoint      Opcode = 0xca
+	Impdep1         Opcode = 0xfe
+	Impdep2         Opcode = 0xff
+)
+
+// operand formats
+const (
+	_none = iota
+	// _s1 signed byte
+	_s1
+	// _s2 signed short
+	_s2
+	// _cp1 unsigned byte constant pool index
+	_cp1
+	// _cp2 unsigned short constant pool index
+	_cp2
+	// _local unsigned byte local variable index, unsigned short if wide
+	_local
+	// _iinc local variable index and signed byte constant, unsigned short and
+	// signed short if wide
+	_iinc
+	// _branch2 signed short branch offset
+	_branch2
+	// _branch4 signed int branch offset
+	_branch4
+	_tableswitch
+	_lookupswitch
+	// _invokeinterface constant pool index, count and a zero byte
+	_invokeinterface
+	// _invokedynamic constant pool index and two zero bytes
+	_invokedynamic
+	// _atype unsigned byte array type
+	_atype
+	_wide
+	// _multianewarray constant pool index and dimensions
+	_multianewarray
+)
+
+type opcodeInfo struct {
+	name   string
+	format int
+}
+
+var (
+	_opcodes = [256]opcodeInfo{
+		Nop:             {"nop", _none},
+		AconstNull:      {"aconst_null", _none},
+		IconstM1:        {"iconst_m1", _none},
+		Iconst0:         {"iconst_0", _none},
+		Iconst1:         {"iconst_1", _none},
+		Iconst2:         {"iconst_2", _none},
+		Iconst3:         {"iconst_3", _none},
+		Iconst4:         {"iconst_4", _none},
+		Iconst5:         {"iconst_5", _none},
+		Lconst0:         {"lconst_0", _none},
+		Lconst1:         {"lconst_1", _none},
+		Fconst0:         {"fconst_0", _none},
+		Fconst1:         {"fconst_1", _none},
+		Fconst2:         {"fconst_2", _none},
+		Dconst0:         {"dconst_0", _none},
+		Dconst1:         {"dconst_1", _none},
+		Bipush:          {"bipush", _s1},
+		Sipush:          {"sipush", _s2},
+		Ldc:             {"ldc", _cp1},
+		LdcW:            {"ldc_w", _cp2},
+		Ldc2W:           {"ldc2_w", _cp2},
+		Iload:           {"iload", _local},
+		Lload:           {"lload", _local},
+		Fload:           {"fload", _local},
+		Dload:           {"dload", _local},
+		Aload:           {"aload", _local},
+		Iload0:          {"iload_0", _none},
+		Iload1:          {"iload_1", _none},
+		Iload2:          {"iload_2", _none},
+		Iload3:          {"iload_3", _none},
+		Lload0:          {"lload_0", _none},
+		Lload1:          {"lload_1", _none},
+		Lload2:          {"lload_2", _none},
+		Lload3:          {"lload_3", _none},
+		Fload0:          {"fload_0", _none},
+		Fload1:          {"fload_1", _none},
+		Fload2:          {"fload_2", _none},
+		Fload3:          {"fload_3", _none},
+		Dload0:          {"dload_0", _none},
+		Dload1:          {"dload_1", _none},
+		Dload2:          {"dload_2", _none},
+		Dload3:          {"dload_3", _none},
+		Aload0:          {"aload_0", _none},
+		Aload1:          {"aload_1", _none},
+		Aload2:          {"aload_2", _none},
+		Aload3:          {"aload_3", _none},
+		Iaload:          {"iaload", _none},
+		Laload:          {"laload", _none},
+		Faload:          {"faload", _none},
+		Daload:          {"daload", _none},
+		Aaload:          {"aaload", _none},
+		Baload:          {"baload", _none},
+		Caload:          {"caload", _none},
+		Saload:          {"saload", _none},
+		Istore:          {"istore", _local},
+		Lstore:          {"lstore", _local},
+		Fstore:          {"fstore", _local},
+		Dstore:          {"dstore", _local},
+		Astore:          {"astore", _local},
+		Istore0:         {"istore_0", _none},
+		Istore1:         {"istore_1", _none},
+		Istore2:         {"istore_2", _none},
+		Istore3:         {"istore_3", _none},
+		Lstore0:         {"lstore_0", _none},
+		Lstore1:         {"lstore_1", _none},
+		Lstore2:         {"lstore_2", _none},
+		Lstore3:         {"lstore_3", _none},
+		Fstore0:         {"fstore_0", _none},
+		Fstore1:         {"fstore_1", _none},
+		Fstore2:         {"fstore_2", _none},
+		Fstore3:         {"fstore_3", _none},
+		Dstore0:         {"dstore_0", _none},
+		Dstore1:         {"dstore_1", _none},
+		Dstore2:         {"dstore_2", _none},
+		Dstore3:         {"dstore_3", _none},
+		Astore0:         {"astore_0", _none},
+		Astore1:         {"astore_1", _none},
+		Astore2:         {"astore_2", _none},
+		Astore3:         {"astore_3", _none},
+		Iastore:         {"iastore", _none},
+		Lastore:         {"lastore", _none},
+		Fastore:         {"fastore", _none},
+		Dastore:         {"dastore", _none},
+		Aastore:         {"aastore", _none},
+		Bastore:         {"bastore", _none},
+		Castore:         {"castore", _none},
+		Sastore:         {"sastore", _none},
+		Pop:             {"pop", _none},
+		Pop2:            {"pop2", _none},
+		Dup:             {"dup", _none},
+		DupX1:           {"dup_x1", _none},
+		DupX2:           {"dup_x2", _none},
+		Dup2:            {"dup2", _none},
+		Dup2X1:          {"dup2_x1", _none},
+		Dup2X2:          {"dup2_x2", _none},
+		Swap:            {"swap", _none},
+		Iadd:            {"iadd", _none},
+		Ladd:            {"ladd", _none},
+		Fadd:            {"fadd", _none},
+		Dadd:            {"dadd", _none},
+		Isub:            {"isub", _none},
+		Lsub:            {"lsub", _none},
+		Fsub:            {"fsub", _none},
+		Dsub:            {"dsub", _none},
+		Imul:            {"imul", _none},
+		Lmul:            {"lmul", _none},
+		Fmul:            {"fmul", _none},
+		Dmul:            {"dmul", _none},
+		Idiv:            {"idiv", _none},
+		Ldiv:            {"ldiv", _none},
+		Fdiv:            {"fdiv", _none},
+		Ddiv:            {"ddiv", _none},
+		Irem:            {"irem", _none},
+		Lrem:            {"lrem", _none},
+		Frem:            {"frem", _none},
+		Drem:            {"drem", _none},
+		Ineg:            {"ineg", _none},
+		Lneg:            {"lneg", _none},
+		Fneg:            {"fneg", _none},
+		Dneg:            {"dneg", _none},
+		Ishl:            {"ishl", _none},
+		Lshl:            {"lshl", _none},
+		Ishr:            {"ishr", _none},
+		Lshr:            {"lshr", _none},
+		Iushr:           {"iushr", _none},
+		Lushr:           {"lushr", _none},
+		Iand:            {"iand", _none},
+		Land:            {"land", _none},
+		Ior:             {"ior", _none},
+		Lor:             {"lor", _none},
+		Ixor:            {"ixor", _none},
+		Lxor:            {"lxor", _none},
+		Iinc:            {"iinc", _iinc},
+		I2l:             {"i2l", _none},
+		I2f:             {"i2f", _none},
+		I2d:             {"i2d", _none},
+		L2i:             {"l2i", _none},
+		L2f:             {"l2f", _none},
+		L2d:             {"l2d", _none},
+		F2i:             {"f2i", _none},
+		F2l:             {"f2l", _none},
+		F2d:             {"f2d", _none},
+		D2i:             {"d2i", _none},
+		D2l:             {"d2l", _none},
+		D2f:             {"d2f", _none},
+		I2b:             {"i2b", _none},
+		I2c:             {"i2c", _none},
+		I2s:             {"i2s", _none},
+		Lcmp:            {"lcmp", _none},
+		Fcmpl:           {"fcmpl", _none},
+		Fcmpg:           {"fcmpg", _none},
+		Dcmpl:           {"dcmpl", _none},
+		Dcmpg:           {"dcmpg", _none},
+		Ifeq:            {"ifeq", _branch2},
+		Ifne:            {"ifne", _branch2},
+		Iflt:            {"iflt", _branch2},
+		Ifge:            {"ifge", _branch2},
+		Ifgt:            {"ifgt", _branch2},
+		Ifle:            {"ifle", _branch2},
+		IfIcmpeq:        {"if_icmpeq", _branch2},
+		IfIcmpne:        {"if_icmpne", _branch2},
+		IfIcmplt:        {"if_icmplt", _branch2},
+		IfIcmpge:        {"if_icmpge", _branch2},
+		IfIcmpgt:        {"if_icmpgt", _branch2},
+		IfIcmple:        {"if_icmple", _branch2},
+		IfAcmpeq:        {"if_acmpeq", _branch2},
+		IfAcmpne:        {"if_acmpne", _branch2},
+		Goto:            {"goto", _branch2},
+		Jsr:             {"jsr", _branch2},
+		Ret:             {"ret", _local},
+		Tableswitch:     {"tableswitch", _tableswitch},
+		Lookupswitch:    {"lookupswitch", _lookupswitch},
+		Ireturn:         {"ireturn", _none},
+		Lreturn:         {"lreturn", _none},
+		Freturn:         {"freturn", _none},
+		Dreturn:         {"dreturn", _none},
+		Areturn:         {"areturn", _none},
+		Return:          {"return", _none},
+		Getstatic:       {"getstatic", _cp2},
+		Putstatic:       {"putstatic", _cp2},
+		Getfield:        {"getfield", _cp2},
+		Putfield:        {"putfield", _cp2},
+		Invokevirtual:   {"invokevirtual", _cp2},
+		Invokespecial:   {"invokespecial", _cp2},
+		Invokestatic:    {"invokestatic", _cp2},
+		Invokeinterface: {"invokeinterface", _invokeinterface},
+		Invokedynamic:   {"invokedynamic", _invokedynamic},
+		New:             {"new", _cp2},
+		Newarray:        {"newarray", _atype},
+		Anewarray:       {"anewarray", _cp2},
+		Arraylength:     {"arraylength", _none},
+		Athrow:          {"athrow", _none},
+		Checkcast:       {"checkcast", _cp2},
+		Instanceof:      {"instanceof", _cp2},
+		Monitorenter:    {"monitorenter", _none},
+		Monitorexit:     {"monitorexit", _none},
+		Wide:            {"wide", _wide},
+		Multianewarray:  {"multianewarray", _multianewarray},
+		Ifnull:          {"ifnull", _branch2},
+		Ifnonnull:       {"ifnonnull", _branch2},
+		GotoW:           {"goto_w", _branch4},
+		JsrW:            {"jsr_w", _branch4},
+		Breakpoint:      {"breakpoint", _none},
+		Impdep1:         {"impdep1", _none},
+		Impdep2:         {"impdep2", _none},
+	}
+)
+
+// String returns the mnemonic of the opcode.
+func (o Opcode) String() string {
+	if o.Valid() {
+		return _opcodes[o].name
+	}
+	return fmt.Sprintf("<illegal opcode 0x%02x>", uint8(o))
+}
+
+// Valid reports whether o is a defined opcode, including the reserved ones.
+func (o Opcode) Valid() bool {
+	return _opcodes[o].name != ""
+}
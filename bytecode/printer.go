@@ -0,0 +1,209 @@
+package bytecode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+// Printer prints instructions in javap -c style, constant pool operands are
+// resolved through CpInfo.
+type Printer struct {
+	CpInfo []class.ConstantInfo
+	// ThisClass internal name of the class the code belongs to, members of it
+	// are printed without the class name like javap does.
+	ThisClass string
+	// Indent prefix of every line.
+	Indent string
+}
+
+// PrintCode prints code in javap -c style, it is the class.CodePrinter to
+// pass to ClassFile.FormatCode for the javap -v listing of a class file.
+func PrintCode(w io.Writer, code []byte, cp []class.ConstantInfo, thisClass, indent string) (err error) {
+	var insts []*Instruction
+	if insts, err = Decode(code); err != nil {
+		return
+	}
+	p := &Printer{CpInfo: cp, ThisClass: thisClass, Indent: indent}
+	return p.Fprint(w, insts)
+}
+
+// Disassemble decodes code and returns its javap -c style listing.
+func Disassemble(code []byte, cp []class.ConstantInfo) (res string, err error) {
+	var insts []*Instruction
+	if insts, err = Decode(code); err != nil {
+		return
+	}
+	b := new(bytes.Buffer)
+	p := &Printer{CpInfo: cp}
+	if err = p.Fprint(b, insts); err != nil {
+		return
+	}
+	return b.String(), nil
+}
+
+// Fprint prints instructions to w, one per line.
+func (p *Printer) Fprint(w io.Writer, insts []*Instruction) (err error) {
+	b := new(bytes.Buffer)
+	for _, in := range insts {
+		if err = p.print(b, in); err != nil {
+			return
+		}
+	}
+	_, err = w.Write(b.Bytes())
+	return
+}
+
+func (p *Printer) print(b *bytes.Buffer, in *Instruction) (err error) {
+	name := in.Opcode.String()
+	if in.Wide {
+		name += "_w"
+	}
+	b.WriteString(fmt.Sprintf("%s%4d: ", p.Indent, in.Offset))
+	var op, cm string
+	switch f := _opcodes[in.Opcode].format; {
+	case in.Switch != nil:
+		p.printSwitch(b, in, f == _tableswitch)
+		return
+	case f == _cp1 || f == _cp2:
+		op = fmt.Sprintf("#%d", in.Operands[0])
+		cm, err = p.constant(uint16(in.Operands[0]))
+	case f == _invokeinterface || f == _invokedynamic || f == _multianewarray:
+		op = fmt.Sprintf("#%d,  %d", in.Operands[0], in.Operands[1])
+		cm, err = p.constant(uint16(in.Operands[0]))
+	case f == _branch2 || f == _branch4:
+		op = fmt.Sprintf("%d", in.Branch())
+	case f == _iinc:
+		op = fmt.Sprintf("%d, %d", in.Operands[0], in.Operands[1])
+	case f == _atype:
+		if op = _atm[in.Operands[0]]; op == "" {
+			op = fmt.Sprintf("%d", in.Operands[0])
+		}
+	case len(in.Operands) > 0:
+		op = fmt.Sprintf("%d", in.Operands[0])
+	}
+	if err != nil {
+		return
+	}
+	switch {
+	case cm != "":
+		b.WriteString(class.PadColumn(name, 14) + class.PadColumn(op, 20) + "// " + cm)
+	case op != "":
+		b.WriteString(class.PadColumn(name, 14) + op)
+	default:
+		b.WriteString(name)
+	}
+	b.WriteString("\n")
+	return
+}
+
+func (p *Printer) printSwitch(b *bytes.Buffer, in *Instruction, table bool) {
+	b.WriteString(class.PadColumn(in.Opcode.String(), 14))
+	if table {
+		b.WriteString(fmt.Sprintf("{ // %d to %d\n", in.Switch.Low, in.Switch.High))
+	} else {
+		b.WriteString(fmt.Sprintf("{ // %d\n", len(in.Switch.Keys)))
+	}
+	for i, k := range in.Switch.Keys {
+		b.WriteString(fmt.Sprintf("%s%18d: %d\n", p.Indent, k, in.Offset+int(in.Switch.Offsets[i])))
+	}
+	b.WriteString(fmt.Sprintf("%s%18s: %d\n", p.Indent, "default", in.Offset+int(in.Switch.Default)))
+	b.WriteString(p.Indent + "    }\n")
+}
+
+// constant returns the javap comment of constant pool entry i.
+func (p *Printer) constant(i uint16) (res string, err error) {
+	if int(i) >= len(p.CpInfo) || p.CpInfo[i] == nil {
+		return "", fmt.Errorf("invalid constant pool index %d", i)
+	}
+	switch c := p.CpInfo[i].(type) {
+	case *class.ClassInfo:
+		var n string
+		if n, err = c.ParseNameFromPool(p.CpInfo); err != nil {
+			return
+		}
+		if strings.HasPrefix(n, "[") {
+			n = "\"" + n + "\""
+		}
+		res = "class " + n
+	case *class.FieldRefInfo:
+		res, err = p.memberRef("Field", c)
+	case *class.MethodRefInfo:
+		res, err = p.memberRef("Method", &c.FieldRefInfo)
+	case *class.InterfaceMethodRefInfo:
+		res, err = p.memberRef("InterfaceMethod", &c.FieldRefInfo)
+	case *class.StringInfo:
+		var s string
+		if s, err = c.ParseStringFromPool(p.CpInfo); err != nil {
+			return
+		}
+		res = "String " + class.Escape(s)
+	case *class.IntegerInfo:
+		res = "int " + c.Literal()
+	case *class.FloatInfo:
+		res = "float " + c.Literal()
+	case *class.LongInfo:
+		res = "long " + c.Literal()
+	case *class.DoubleInfo:
+		res = "double " + c.Literal()
+	case *class.MethodHandle:
+		var r string
+		if r, err = p.constant(c.ReferenceIndex); err != nil {
+			return
+		}
+		// drop the kind of the referenced member, e.g. Method
+		if i := strings.IndexByte(r, ' '); i >= 0 {
+			r = r[i+1:]
+		}
+		res = fmt.Sprintf("MethodHandle %s %s", class.RefKindName(c.ReferenceKind), r)
+	case *class.MethodTypeInfo:
+		var d string
+		if d, err = c.ParseDescriptorFromPool(p.CpInfo); err != nil {
+			return
+		}
+		res = "MethodType " + d
+	case *class.DynamicInfo:
+		var n, d string
+		if n, d, err = c.ParseNameAndTypeFromPool(p.CpInfo); err != nil {
+			return
+		}
+		res = fmt.Sprintf("Dynamic #%d:%s:%s", c.BootstrapMethodAttrIndex, memberName(n), d)
+	case *class.InvokeDynamicInfo:
+		var n, d string
+		if n, d, err = c.ParseNameAndTypeFromPool(p.CpInfo); err != nil {
+			return
+		}
+		res = fmt.Sprintf("InvokeDynamic #%d:%s:%s", c.BootstrapMethodAttrIndex, memberName(n), d)
+	default:
+		res = c.TN()
+	}
+	return
+}
+
+func (p *Printer) memberRef(kind string, c *class.FieldRefInfo) (res string, err error) {
+	var cn, n, d string
+	if cn, err = c.ParseClassFromPool(p.CpInfo); err != nil {
+		return
+	}
+	if n, d, err = c.ParseNameAndTypeFromPool(p.CpInfo); err != nil {
+		return
+	}
+	if cn == p.ThisClass {
+		return fmt.Sprintf("%s %s:%s", kind, memberName(n), d), nil
+	}
+	if strings.HasPrefix(cn, "[") {
+		cn = "\"" + cn + "\""
+	}
+	return fmt.Sprintf("%s %s.%s:%s", kind, cn, memberName(n), d), nil
+}
+
+// memberName quotes special method names like javap does.
+func memberName(n string) string {
+	if strings.HasPrefix(n, "<") {
+		return "\"" + n + "\""
+	}
+	return n
+}
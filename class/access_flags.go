@@ -1,13 +1,21 @@
 package class
 
+import (
+	"fmt"
+	"strings"
+)
+
 const (
 	// class
-	_cAccPublic    = 0x0001 // 0000000000000001
-	_cAccFinal     = 0x0010 // 0000000000010000
-	_cAccSuper     = 0x0020 // 0000000000100000
-	_cAccInterface = 0x0200 // 0000001000000000
-	_cAccAbstract  = 0x0400 // 0000010000000000
-	_cAccSynthetic = 0x1000 // 0001000000000000
+	_cAccPublic     = 0x0001 // 0000000000000001
+	_cAccFinal      = 0x0010 // 0000000000010000
+	_cAccSuper      = 0x0020 // 0000000000100000
+	_cAccInterface  = 0x0200 // 0000001000000000
+	_cAccAbstract   = 0x0400 // 0000010000000000
+	_cAccSynthetic  = 0x1000 // 0001000000000000
+	_cAccAnnotation = 0x2000 // 0010000000000000
+	_cAccEnum       = 0x4000 // 0100000000000000
+	_cAccModule     = 0x8000 // 1000000000000000
 
 	// field
 	_fAccPublic    = 0x0001 // 0000000000000001
@@ -33,22 +41,77 @@ const (
 	_mAccAbstract     = 0x0400 // 0000010000000000
 	_mAccStrict       = 0x0800 // 0000100000000000
 	_mAccSynthetic    = 0x1000 // 0001000000000000
+
+	// inner class
+	_iAccPublic     = 0x0001 // 0000000000000001
+	_iAccPrivate    = 0x0002 // 0000000000000010
+	_iAccProtected  = 0x0004 // 0000000000000100
+	_iAccStatic     = 0x0008 // 0000000000001000
+	_iAccFinal      = 0x0010 // 0000000000010000
+	_iAccInterface  = 0x0200 // 0000001000000000
+	_iAccAbstract   = 0x0400 // 0000010000000000
+	_iAccSynthetic  = 0x1000 // 0001000000000000
+	_iAccAnnotation = 0x2000 // 0010000000000000
+	_iAccEnum       = 0x4000 // 0100000000000000
+
+	// module, requires, exports and opens
+	_moAccOpen        = 0x0020 // 0000000000100000
+	_moAccTransitive  = 0x0020 // 0000000000100000
+	_moAccStaticPhase = 0x0040 // 0000000001000000
+	_moAccSynthetic   = 0x1000 // 0001000000000000
+	_moAccMandated    = 0x8000 // 1000000000000000
+
+	// parameter
+	_pAccFinal     = 0x0010 // 0000000000010000
+	_pAccSynthetic = 0x1000 // 0001000000000000
+	_pAccMandated  = 0x8000 // 1000000000000000
+)
+
+// access flags, the same bit has a different meaning per context.
+const (
+	AccPublic       = 0x0001
+	AccPrivate      = 0x0002
+	AccProtected    = 0x0004
+	AccStatic       = 0x0008
+	AccFinal        = 0x0010
+	AccSuper        = 0x0020
+	AccSynchronized = 0x0020
+	AccOpen         = 0x0020
+	AccTransitive   = 0x0020
+	AccVolatile     = 0x0040
+	AccBridge       = 0x0040
+	AccStaticPhase  = 0x0040
+	AccTransient    = 0x0080
+	AccVarargs      = 0x0080
+	AccNative       = 0x0100
+	AccInterface    = 0x0200
+	AccAbstract     = 0x0400
+	AccStrict       = 0x0800
+	AccSynthetic    = 0x1000
+	AccAnnotation   = 0x2000
+	AccEnum         = 0x4000
+	AccModule       = 0x8000
+	AccMandated     = 0x8000
 )
 
 var (
 	// class
 	_cAccFm = map[uint16]string{
-		_cAccPublic:    "ACC_PUBLIC",
-		_cAccFinal:     "ACC_FINAL",
-		_cAccSuper:     "ACC_SUPER",
-		_cAccInterface: "ACC_INTERFACE",
-		_cAccAbstract:  "ACC_ABSTRACT",
-		_cAccSynthetic: "ACC_SYNTHETIC",
+		_cAccPublic:     "ACC_PUBLIC",
+		_cAccFinal:      "ACC_FINAL",
+		_cAccSuper:      "ACC_SUPER",
+		_cAccInterface:  "ACC_INTERFACE",
+		_cAccAbstract:   "ACC_ABSTRACT",
+		_cAccSynthetic:  "ACC_SYNTHETIC",
+		_cAccAnnotation: "ACC_ANNOTATION",
+		_cAccEnum:       "ACC_ENUM",
+		_cAccModule:     "ACC_MODULE",
 	}
 
 	// field
 	_fAccFm = map[uint16]string{
 		_fAccPublic:    "ACC_PUBLIC",
+		_fAccPrivate:   "ACC_PRIVATE",
 		_fAccProtected: "ACC_PROTECTED",
 		_fAccStatic:    "ACC_STATIC",
 		_fAccFinal:     "ACC_FINAL",
@@ -61,6 +124,7 @@ var (
 	// method
 	_mAccFm = map[uint16]string{
 		_mAccPublic:       "ACC_PUBLIC",
+		_mAccPrivate:      "ACC_PRIVATE",
 		_mAccProtected:    "ACC_PROTECTED",
 		_mAccStatic:       "ACC_STATIC",
 		_mAccFinal:        "ACC_FINAL",
@@ -69,8 +133,51 @@ var (
 		_mAccVarargs:      "ACC_VARARGS",
 		_mAccNative:       "ACC_NATIVE",
 		_mAccAbstract:     "ACC_ABSTRACT",
+		_mAccStrict:       "ACC_STRICT",
 		_mAccSynthetic:    "ACC_SYNTHETIC",
 	}
+
+	// inner class
+	_iAccFm = map[uint16]string{
+		_iAccPublic:     "ACC_PUBLIC",
+		_iAccPrivate:    "ACC_PRIVATE",
+		_iAccProtected:  "ACC_PROTECTED",
+		_iAccStatic:     "ACC_STATIC",
+		_iAccFinal:      "ACC_FINAL",
+		_iAccInterface:  "ACC_INTERFACE",
+		_iAccAbstract:   "ACC_ABSTRACT",
+		_iAccSynthetic:  "ACC_SYNTHETIC",
+		_iAccAnnotation: "ACC_ANNOTATION",
+		_iAccEnum:       "ACC_ENUM",
+	}
+
+	// module
+	_moAccFm = map[uint16]string{
+		_moAccOpen:      "ACC_OPEN",
+		_moAccSynthetic: "ACC_SYNTHETIC",
+		_moAccMandated:  "ACC_MANDATED",
+	}
+
+	// requires
+	_rAccFm = map[uint16]string{
+		_moAccTransitive:  "ACC_TRANSITIVE",
+		_moAccStaticPhase: "ACC_STATIC_PHASE",
+		_moAccSynthetic:   "ACC_SYNTHETIC",
+		_moAccMandated:    "ACC_MANDATED",
+	}
+
+	// exports and opens
+	_eAccFm = map[uint16]string{
+		_moAccSynthetic: "ACC_SYNTHETIC",
+		_moAccMandated:  "ACC_MANDATED",
+	}
+
+	// parameter
+	_pAccFm = map[uint16]string{
+		_pAccFinal:     "ACC_FINAL",
+		_pAccSynthetic: "ACC_SYNTHETIC",
+		_pAccMandated:  "ACC_MANDATED",
+	}
 )
 
 func ParseClassAccessFlags(f uint16) (fs []uint16) {
@@ -98,6 +205,18 @@ func ParseClassAccessFlags(f uint16) (fs []uint16) {
 	if f&_cAccSynthetic != 0 {
 		fs = append(fs, _cAccSynthetic)
 	}
+
+	if f&_cAccAnnotation != 0 {
+		fs = append(fs, _cAccAnnotation)
+	}
+
+	if f&_cAccEnum != 0 {
+		fs = append(fs, _cAccEnum)
+	}
+
+	if f&_cAccModule != 0 {
+		fs = append(fs, _cAccModule)
+	}
 	return
 }
 
@@ -192,3 +311,291 @@ func ParseMethodAccessFlags(f uint16) (fs []uint16) {
 	}
 	return
 }
+
+// ClassAccessFlagNames returns the names of the class access flags set in f.
+func ClassAccessFlagNames(f uint16) []string {
+	return ClassFlags(f).Names()
+}
+
+// FieldAccessFlagNames returns the names of the field access flags set in f.
+func FieldAccessFlagNames(f uint16) []string {
+	return FieldFlags(f).Names()
+}
+
+// MethodAccessFlagNames returns the names of the method access flags set in f.
+func MethodAccessFlagNames(f uint16) []string {
+	return MethodFlags(f).Names()
+}
+
+// flagNames returns the names of the flags set in f in bit order, unknown
+// bits are ignored.
+func flagNames(f uint16, names map[uint16]string) []string {
+	ns := make([]string, 0)
+	for b := uint16(1); b != 0; b <<= 1 {
+		if f&b == 0 {
+			continue
+		}
+		if n, ok := names[b]; ok {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// visibility returns an error if more than one of ACC_PUBLIC, ACC_PRIVATE
+// and ACC_PROTECTED is set in f.
+func visibility(f uint16) error {
+	n := 0
+	for _, b := range []uint16{AccPublic, AccPrivate, AccProtected} {
+		if f&b != 0 {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("at most one of ACC_PUBLIC, ACC_PRIVATE and ACC_PROTECTED may be set")
+	}
+	return nil
+}
+
+// ClassFlags access_flags of a ClassFile (JVMS 4.1).
+type ClassFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f ClassFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f ClassFlags) Names() []string {
+	return flagNames(uint16(f), _cAccFm)
+}
+
+func (f ClassFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// Validate returns the illegal flag combinations for a class file of the
+// given major version.
+func (f ClassFlags) Validate(major uint16) (errs []error) {
+	if f.Has(AccModule) && major >= 53 {
+		if f != AccModule {
+			errs = append(errs, fmt.Errorf("ACC_MODULE must not be set with other flags"))
+		}
+		return
+	}
+	if f.Has(AccInterface) {
+		if !f.Has(AccAbstract) {
+			errs = append(errs, fmt.Errorf("interface must be ACC_ABSTRACT"))
+		}
+		if f.Has(AccFinal) {
+			errs = append(errs, fmt.Errorf("interface must not be ACC_FINAL"))
+		}
+		if major >= 49 && f.Has(AccSuper) {
+			errs = append(errs, fmt.Errorf("interface must not be ACC_SUPER"))
+		}
+		if major >= 49 && f.Has(AccEnum) {
+			errs = append(errs, fmt.Errorf("interface must not be ACC_ENUM"))
+		}
+		return
+	}
+	if major >= 49 && f.Has(AccAnnotation) {
+		errs = append(errs, fmt.Errorf("ACC_ANNOTATION requires ACC_INTERFACE"))
+	}
+	if f.Has(AccFinal | AccAbstract) {
+		errs = append(errs, fmt.Errorf("class must not be both ACC_FINAL and ACC_ABSTRACT"))
+	}
+	return
+}
+
+// FieldFlags access_flags of a field_info (JVMS 4.5).
+type FieldFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f FieldFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f FieldFlags) Names() []string {
+	return flagNames(uint16(f), _fAccFm)
+}
+
+func (f FieldFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// Validate returns the illegal flag combinations for a field of a class with
+// access flags owner.
+func (f FieldFlags) Validate(major uint16, owner ClassFlags) (errs []error) {
+	if owner.Has(AccInterface) {
+		if !f.Has(AccPublic | AccStatic | AccFinal) {
+			errs = append(errs, fmt.Errorf("interface field must be ACC_PUBLIC, ACC_STATIC and ACC_FINAL"))
+		}
+		if uint16(f)&^(AccPublic|AccStatic|AccFinal|AccSynthetic) != 0 {
+			errs = append(errs, fmt.Errorf("interface field may only be ACC_PUBLIC, ACC_STATIC, ACC_FINAL and ACC_SYNTHETIC"))
+		}
+		return
+	}
+	if err := visibility(uint16(f)); err != nil {
+		errs = append(errs, err)
+	}
+	if f.Has(AccFinal | AccVolatile) {
+		errs = append(errs, fmt.Errorf("field must not be both ACC_FINAL and ACC_VOLATILE"))
+	}
+	return
+}
+
+// MethodFlags access_flags of a method_info (JVMS 4.6).
+type MethodFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f MethodFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f MethodFlags) Names() []string {
+	return flagNames(uint16(f), _mAccFm)
+}
+
+func (f MethodFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// Validate returns the illegal flag combinations for a method with the given
+// name of a class with access flags owner.
+func (f MethodFlags) Validate(major uint16, owner ClassFlags, name string) (errs []error) {
+	if name == "<clinit>" {
+		// other flags of a class initialization method are ignored
+		if major >= 51 && !f.Has(AccStatic) {
+			errs = append(errs, fmt.Errorf("<clinit> must be ACC_STATIC"))
+		}
+		return
+	}
+	if err := visibility(uint16(f)); err != nil {
+		errs = append(errs, err)
+	}
+	if owner.Has(AccInterface) {
+		if major < 52 {
+			if !f.Has(AccPublic | AccAbstract) {
+				errs = append(errs, fmt.Errorf("interface method must be ACC_PUBLIC and ACC_ABSTRACT"))
+			}
+		} else if !f.Has(AccPublic) && !f.Has(AccPrivate) {
+			errs = append(errs, fmt.Errorf("interface method must be ACC_PUBLIC or ACC_PRIVATE"))
+		}
+		if uint16(f)&(AccProtected|AccFinal|AccSynchronized|AccNative) != 0 {
+			errs = append(errs, fmt.Errorf("interface method must not be ACC_PROTECTED, ACC_FINAL, ACC_SYNCHRONIZED or ACC_NATIVE"))
+		}
+	}
+	if f.Has(AccAbstract) {
+		bad := uint16(AccPrivate | AccStatic | AccFinal | AccSynchronized | AccNative)
+		if major >= 46 && major <= 60 {
+			bad |= AccStrict
+		}
+		if uint16(f)&bad != 0 {
+			errs = append(errs, fmt.Errorf("abstract method must not be ACC_PRIVATE, ACC_STATIC, ACC_FINAL, ACC_SYNCHRONIZED, ACC_NATIVE or ACC_STRICT"))
+		}
+	}
+	if name == "<init>" && uint16(f)&^(AccPublic|AccPrivate|AccProtected|AccVarargs|AccStrict|AccSynthetic) != 0 {
+		errs = append(errs, fmt.Errorf("<init> may only be ACC_PUBLIC, ACC_PRIVATE, ACC_PROTECTED, ACC_VARARGS, ACC_STRICT and ACC_SYNTHETIC"))
+	}
+	return
+}
+
+// InnerClassFlags inner_class_access_flags of an InnerClasses entry
+// (JVMS 4.7.6).
+type InnerClassFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f InnerClassFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f InnerClassFlags) Names() []string {
+	return flagNames(uint16(f), _iAccFm)
+}
+
+func (f InnerClassFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// Validate returns the illegal flag combinations of an inner class, which
+// follow the rules of a class plus visibility.
+func (f InnerClassFlags) Validate(major uint16) (errs []error) {
+	if err := visibility(uint16(f)); err != nil {
+		errs = append(errs, err)
+	}
+	// ACC_SUPER shares no bit with the inner class flags
+	errs = append(errs, ClassFlags(uint16(f)&^AccSuper).Validate(major)...)
+	return
+}
+
+// ModuleFlags module_flags of a Module attribute (JVMS 4.7.25).
+type ModuleFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f ModuleFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f ModuleFlags) Names() []string {
+	return flagNames(uint16(f), _moAccFm)
+}
+
+func (f ModuleFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// RequiresFlags requires_flags of a Module attribute.
+type RequiresFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f RequiresFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f RequiresFlags) Names() []string {
+	return flagNames(uint16(f), _rAccFm)
+}
+
+func (f RequiresFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// Validate returns the illegal flags of a requires entry for module
+// java.base, the only module whose requires flags are restricted.
+func (f RequiresFlags) Validate(major uint16, module string) (errs []error) {
+	if module == "java.base" && major >= 54 && uint16(f)&(AccTransitive|AccStaticPhase) != 0 {
+		errs = append(errs, fmt.Errorf("requires java.base must not be ACC_TRANSITIVE or ACC_STATIC_PHASE"))
+	}
+	return
+}
+
+// ExportsFlags exports_flags or opens_flags of a Module attribute.
+type ExportsFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f ExportsFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f ExportsFlags) Names() []string {
+	return flagNames(uint16(f), _eAccFm)
+}
+
+func (f ExportsFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
+
+// ParameterFlags access_flags of a MethodParameters entry (JVMS 4.7.24).
+type ParameterFlags uint16
+
+// Has reports whether all flags of fl are set.
+func (f ParameterFlags) Has(fl uint16) bool {
+	return uint16(f)&fl == fl
+}
+
+func (f ParameterFlags) Names() []string {
+	return flagNames(uint16(f), _pAccFm)
+}
+
+func (f ParameterFlags) String() string {
+	return strings.Join(f.Names(), ", ")
+}
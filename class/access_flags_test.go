@@ -0,0 +1,56 @@
+package class
+
+import (
+	"testing"
+)
+
+func TestFlagsNames(t *testing.T) {
+	if s := FieldFlags(0x001a).String(); s != "ACC_PRIVATE, ACC_STATIC, ACC_FINAL" {
+		t.Errorf("unexpected field flags %s", s)
+	}
+	if s := MethodFlags(0x0c01).String(); s != "ACC_PUBLIC, ACC_ABSTRACT, ACC_STRICT" {
+		t.Errorf("unexpected method flags %s", s)
+	}
+	if s := ClassFlags(0x6601).String(); s != "ACC_PUBLIC, ACC_INTERFACE, ACC_ABSTRACT, ACC_ANNOTATION, ACC_ENUM" {
+		t.Errorf("unexpected class flags %s", s)
+	}
+	if s := RequiresFlags(0x8020).String(); s != "ACC_TRANSITIVE, ACC_MANDATED" {
+		t.Errorf("unexpected requires flags %s", s)
+	}
+	if !ClassFlags(0x0021).Has(AccPublic | AccSuper) {
+		t.Errorf("expected ACC_PUBLIC and ACC_SUPER")
+	}
+}
+
+func TestFlagsValidate(t *testing.T) {
+	cases := []struct {
+		errs  []error
+		valid bool
+	}{
+		{ClassFlags(AccPublic | AccSuper).Validate(52), true},
+		{ClassFlags(AccPublic | AccInterface).Validate(52), false},
+		{ClassFlags(AccInterface | AccAbstract | AccSuper).Validate(52), false},
+		{ClassFlags(AccInterface | AccAbstract | AccSuper).Validate(48), true},
+		{ClassFlags(AccFinal | AccAbstract).Validate(52), false},
+		{ClassFlags(AccModule).Validate(53), true},
+		{ClassFlags(AccModule | AccPublic).Validate(53), false},
+		{FieldFlags(AccPrivate|AccStatic|AccFinal).Validate(52, AccPublic), true},
+		{FieldFlags(AccFinal|AccVolatile).Validate(52, AccPublic), false},
+		{FieldFlags(AccPublic|AccPrivate).Validate(52, AccPublic), false},
+		{FieldFlags(AccPublic|AccStatic).Validate(52, AccInterface|AccAbstract), false},
+		{MethodFlags(AccPublic|AccAbstract).Validate(52, AccPublic|AccAbstract, "f"), true},
+		{MethodFlags(AccPublic|AccAbstract|AccFinal).Validate(52, AccPublic|AccAbstract, "f"), false},
+		{MethodFlags(AccPublic|AccAbstract|AccStrict).Validate(52, AccPublic|AccAbstract, "f"), false},
+		{MethodFlags(AccPublic|AccAbstract|AccStrict).Validate(61, AccPublic|AccAbstract, "f"), true},
+		{MethodFlags(AccPrivate).Validate(52, AccInterface|AccAbstract, "f"), true},
+		{MethodFlags(AccPrivate).Validate(51, AccInterface|AccAbstract, "f"), false},
+		{MethodFlags(AccPublic|AccStatic).Validate(52, AccPublic, "<init>"), false},
+		{MethodFlags(0).Validate(51, AccPublic, "<clinit>"), false},
+		{InnerClassFlags(AccPrivate | AccStatic).Validate(52), true},
+	}
+	for i, c := range cases {
+		if (len(c.errs) == 0) != c.valid {
+			t.Errorf("case %d: expected valid %t, got %v", i, c.valid, c.errs)
+		}
+	}
+}
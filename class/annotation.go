@@ -0,0 +1,683 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	_runtimeVisibleAnnotations            = "RuntimeVisibleAnnotations"
+	_runtimeInvisibleAnnotations          = "RuntimeInvisibleAnnotations"
+	_runtimeVisibleParameterAnnotations   = "RuntimeVisibleParameterAnnotations"
+	_runtimeInvisibleParameterAnnotations = "RuntimeInvisibleParameterAnnotations"
+	_runtimeVisibleTypeAnnotations        = "RuntimeVisibleTypeAnnotations"
+	_runtimeInvisibleTypeAnnotations      = "RuntimeInvisibleTypeAnnotations"
+	_annotationDefault                    = "AnnotationDefault"
+)
+
+// element_value tags
+const (
+	ElementByte       = 'B'
+	ElementChar       = 'C'
+	ElementDouble     = 'D'
+	ElementFloat      = 'F'
+	ElementInt        = 'I'
+	ElementLong       = 'J'
+	ElementShort      = 'S'
+	ElementBoolean    = 'Z'
+	ElementString     = 's'
+	ElementEnum       = 'e'
+	ElementClass      = 'c'
+	ElementAnnotation = '@'
+	ElementArray      = '['
+)
+
+// type annotation target_type values
+const (
+	TargetClassTypeParameter                = 0x00
+	TargetMethodTypeParameter               = 0x01
+	TargetClassExtends                      = 0x10
+	TargetClassTypeParameterBound           = 0x11
+	TargetMethodTypeParameterBound          = 0x12
+	TargetField                             = 0x13
+	TargetMethodReturn                      = 0x14
+	TargetMethodReceiver                    = 0x15
+	TargetMethodFormalParameter             = 0x16
+	TargetThrows                            = 0x17
+	TargetLocalVariable                     = 0x40
+	TargetResourceVariable                  = 0x41
+	TargetExceptionParameter                = 0x42
+	TargetInstanceof                        = 0x43
+	TargetNew                               = 0x44
+	TargetConstructorReference              = 0x45
+	TargetMethodReference                   = 0x46
+	TargetCast                              = 0x47
+	TargetConstructorInvocationTypeArgument = 0x48
+	TargetMethodInvocationTypeArgument      = 0x49
+	TargetConstructorReferenceTypeArgument  = 0x4a
+	TargetMethodReferenceTypeArgument       = 0x4b
+)
+
+// type_path_kind values
+const (
+	PathArray         = 0
+	PathNested        = 1
+	PathWildcardBound = 2
+	PathTypeArgument  = 3
+)
+
+var (
+	_ttm = map[uint8]string{
+		TargetClassTypeParameter:                "CLASS_TYPE_PARAMETER",
+		TargetMethodTypeParameter:               "METHOD_TYPE_PARAMETER",
+		TargetClassExtends:                      "CLASS_EXTENDS",
+		TargetClassTypeParameterBound:           "CLASS_TYPE_PARAMETER_BOUND",
+		TargetMethodTypeParameterBound:          "METHOD_TYPE_PARAMETER_BOUND",
+		TargetField:                             "FIELD",
+		TargetMethodReturn:                      "METHOD_RETURN",
+		TargetMethodReceiver:                    "METHOD_RECEIVER",
+		TargetMethodFormalParameter:             "METHOD_FORMAL_PARAMETER",
+		TargetThrows:                            "THROWS",
+		TargetLocalVariable:                     "LOCAL_VARIABLE",
+		TargetResourceVariable:                  "RESOURCE_VARIABLE",
+		TargetExceptionParameter:                "EXCEPTION_PARAMETER",
+		TargetInstanceof:                        "INSTANCEOF",
+		TargetNew:                               "NEW",
+		TargetConstructorReference:              "CONSTRUCTOR_REFERENCE",
+		TargetMethodReference:                   "METHOD_REFERENCE",
+		TargetCast:                              "CAST",
+		TargetConstructorInvocationTypeArgument: "CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT",
+		TargetMethodInvocationTypeArgument:      "METHOD_INVOCATION_TYPE_ARGUMENT",
+		TargetConstructorReferenceTypeArgument:  "CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT",
+		TargetMethodReferenceTypeArgument:       "METHOD_REFERENCE_TYPE_ARGUMENT",
+	}
+
+	_ptm = map[uint8]string{
+		PathArray:         "ARRAY",
+		PathNested:        "INNER_TYPE",
+		PathWildcardBound: "WILDCARD",
+		PathTypeArgument:  "TYPE_ARGUMENT",
+	}
+)
+
+// TargetTypeName returns the javap name of a type annotation target_type,
+// e.g. FIELD.
+func TargetTypeName(t uint8) string {
+	if n, ok := _ttm[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("UNKNOWN(0x%02x)", t)
+}
+
+// Annotation annotation.
+type Annotation struct {
+	TypeIndex            uint16
+	NumElementValuePairs uint16
+	ElementValuePairs    []*ElementValuePair
+}
+
+func (m *Annotation) Read(r *reader) {
+	m.TypeIndex = r.u16()
+	m.NumElementValuePairs = r.u16()
+	m.ElementValuePairs = make([]*ElementValuePair, m.NumElementValuePairs)
+	for i := 0; i < int(m.NumElementValuePairs) && r.err == nil; i++ {
+		p := &ElementValuePair{ElementNameIndex: r.u16(), Value: new(ElementValue)}
+		p.Value.Read(r)
+		m.ElementValuePairs[i] = p
+	}
+}
+
+func (m *Annotation) Write(w *writer) {
+	w.u16(m.TypeIndex)
+	w.u16(uint16(len(m.ElementValuePairs)))
+	for _, p := range m.ElementValuePairs {
+		w.u16(p.ElementNameIndex)
+		p.Value.Write(w)
+	}
+}
+
+// ParseTypeFromPool returns the field descriptor of the annotation type, e.g.
+// Lorg/junit/Test;.
+func (m *Annotation) ParseTypeFromPool(cp []ConstantInfo) (string, error) {
+	return ui2string(cp, m.TypeIndex)
+}
+
+// Element returns the value of the element with the given name, nil if the
+// annotation doesn't set it.
+func (m *Annotation) Element(cp []ConstantInfo, name string) (res *ElementValue, err error) {
+	for _, p := range m.ElementValuePairs {
+		var n string
+		if n, err = ui2string(cp, p.ElementNameIndex); err != nil {
+			return
+		}
+		if n == name {
+			return p.Value, nil
+		}
+	}
+	return
+}
+
+// ElementValuePair entry of the element_value_pairs in annotation.
+type ElementValuePair struct {
+	ElementNameIndex uint16
+	Value            *ElementValue
+}
+
+// ElementValue element_value, the fields set depend on Tag: ConstValueIndex
+// for the primitive and string tags, TypeNameIndex and ConstNameIndex for
+// enums, ClassInfoIndex for classes, AnnotationValue for annotations and
+// Values for arrays.
+type ElementValue struct {
+	Tag             uint8
+	ConstValueIndex uint16
+	TypeNameIndex   uint16
+	ConstNameIndex  uint16
+	ClassInfoIndex  uint16
+	AnnotationValue *Annotation
+	NumValues       uint16
+	Values          []*ElementValue
+}
+
+func (m *ElementValue) Read(r *reader) {
+	m.Tag = r.u8()
+	switch m.Tag {
+	case ElementByte, ElementChar, ElementDouble, ElementFloat, ElementInt, ElementLong, ElementShort, ElementBoolean, ElementString:
+		m.ConstValueIndex = r.u16()
+	case ElementEnum:
+		m.TypeNameIndex = r.u16()
+		m.ConstNameIndex = r.u16()
+	case ElementClass:
+		m.ClassInfoIndex = r.u16()
+	case ElementAnnotation:
+		m.AnnotationValue = new(Annotation)
+		m.AnnotationValue.Read(r)
+	case ElementArray:
+		m.NumValues = r.u16()
+		m.Values = make([]*ElementValue, m.NumValues)
+		for i := 0; i < int(m.NumValues) && r.err == nil; i++ {
+			m.Values[i] = new(ElementValue)
+			m.Values[i].Read(r)
+		}
+	default:
+		if r.err == nil {
+			r.off--
+			r.fail(fmt.Errorf("invalid element value tag %q", m.Tag))
+		}
+	}
+}
+
+func (m *ElementValue) Write(w *writer) {
+	w.u8(m.Tag)
+	switch m.Tag {
+	case ElementEnum:
+		w.u16(m.TypeNameIndex)
+		w.u16(m.ConstNameIndex)
+	case ElementClass:
+		w.u16(m.ClassInfoIndex)
+	case ElementAnnotation:
+		m.AnnotationValue.Write(w)
+	case ElementArray:
+		w.u16(uint16(len(m.Values)))
+		for _, v := range m.Values {
+			v.Write(w)
+		}
+	default:
+		w.u16(m.ConstValueIndex)
+	}
+}
+
+func readAnnotations(r *reader) (count uint16, as []*Annotation) {
+	count = r.u16()
+	as = make([]*Annotation, count)
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		r.enter("annotation", i)
+		as[i] = new(Annotation)
+		as[i].Read(r)
+		r.leave()
+	}
+	return
+}
+
+func writeAnnotations(w *writer, as []*Annotation) {
+	w.u16(uint16(len(as)))
+	for _, a := range as {
+		a.Write(w)
+	}
+}
+
+// RuntimeVisibleAnnotationsAttribute RuntimeVisibleAnnotations_attribute.
+type RuntimeVisibleAnnotationsAttribute struct {
+	NumAnnotations uint16
+	Annotations    []*Annotation
+}
+
+func (m *RuntimeVisibleAnnotationsAttribute) AttributeName() string {
+	return _runtimeVisibleAnnotations
+}
+
+func (m *RuntimeVisibleAnnotationsAttribute) Read(r *reader) {
+	m.NumAnnotations, m.Annotations = readAnnotations(r)
+}
+
+func (m *RuntimeVisibleAnnotationsAttribute) Write(w *writer) {
+	writeAnnotations(w, m.Annotations)
+}
+
+// RuntimeInvisibleAnnotationsAttribute RuntimeInvisibleAnnotations_attribute.
+type RuntimeInvisibleAnnotationsAttribute struct {
+	RuntimeVisibleAnnotationsAttribute
+}
+
+func (m *RuntimeInvisibleAnnotationsAttribute) AttributeName() string {
+	return _runtimeInvisibleAnnotations
+}
+
+// ParameterAnnotations entry of the parameter_annotations in
+// RuntimeVisibleParameterAnnotations_attribute.
+type ParameterAnnotations struct {
+	NumAnnotations uint16
+	Annotations    []*Annotation
+}
+
+// RuntimeVisibleParameterAnnotationsAttribute
+// RuntimeVisibleParameterAnnotations_attribute.
+type RuntimeVisibleParameterAnnotationsAttribute struct {
+	NumParameters        uint8
+	ParameterAnnotations []*ParameterAnnotations
+}
+
+func (m *RuntimeVisibleParameterAnnotationsAttribute) AttributeName() string {
+	return _runtimeVisibleParameterAnnotations
+}
+
+func (m *RuntimeVisibleParameterAnnotationsAttribute) Read(r *reader) {
+	m.NumParameters = r.u8()
+	m.ParameterAnnotations = make([]*ParameterAnnotations, m.NumParameters)
+	for i := 0; i < int(m.NumParameters) && r.err == nil; i++ {
+		r.enter("parameter", i)
+		p := new(ParameterAnnotations)
+		p.NumAnnotations, p.Annotations = readAnnotations(r)
+		m.ParameterAnnotations[i] = p
+		r.leave()
+	}
+}
+
+func (m *RuntimeVisibleParameterAnnotationsAttribute) Write(w *writer) {
+	w.u8(uint8(len(m.ParameterAnnotations)))
+	for _, p := range m.ParameterAnnotations {
+		writeAnnotations(w, p.Annotations)
+	}
+}
+
+// RuntimeInvisibleParameterAnnotationsAttribute
+// RuntimeInvisibleParameterAnnotations_attribute.
+type RuntimeInvisibleParameterAnnotationsAttribute struct {
+	RuntimeVisibleParameterAnnotationsAttribute
+}
+
+func (m *RuntimeInvisibleParameterAnnotationsAttribute) AttributeName() string {
+	return _runtimeInvisibleParameterAnnotations
+}
+
+// TypeAnnotation type_annotation, the TargetInfo fields set depend on
+// TargetType.
+type TypeAnnotation struct {
+	TargetType uint8
+	TargetInfo
+	TargetPath *TypePath
+	Annotation
+}
+
+func (m *TypeAnnotation) Read(r *reader) {
+	m.TargetType = r.u8()
+	m.TargetInfo.Read(r, m.TargetType)
+	m.TargetPath = new(TypePath)
+	m.TargetPath.Read(r)
+	m.Annotation.Read(r)
+}
+
+func (m *TypeAnnotation) Write(w *writer) {
+	w.u8(m.TargetType)
+	m.TargetInfo.Write(w, m.TargetType)
+	m.TargetPath.Write(w)
+	m.Annotation.Write(w)
+}
+
+// TargetString returns the target of the type annotation like javap, e.g.
+// METHOD_FORMAL_PARAMETER, param_index=0, location=[TYPE_ARGUMENT(0)].
+func (m *TypeAnnotation) TargetString() string {
+	ss := []string{TargetTypeName(m.TargetType)}
+	t := &m.TargetInfo
+	switch m.TargetType {
+	case TargetClassTypeParameter, TargetMethodTypeParameter:
+		ss = append(ss, fmt.Sprintf("param_index=%d", t.TypeParameterIndex))
+	case TargetClassExtends:
+		ss = append(ss, fmt.Sprintf("type_index=%d", int16(t.SupertypeIndex)))
+	case TargetClassTypeParameterBound, TargetMethodTypeParameterBound:
+		ss = append(ss, fmt.Sprintf("param_index=%d", t.TypeParameterIndex), fmt.Sprintf("bound_index=%d", t.BoundIndex))
+	case TargetMethodFormalParameter:
+		ss = append(ss, fmt.Sprintf("param_index=%d", t.FormalParameterIndex))
+	case TargetThrows:
+		ss = append(ss, fmt.Sprintf("throws_index=%d", t.ThrowsTypeIndex))
+	case TargetLocalVariable, TargetResourceVariable:
+		ls := make([]string, len(t.Table))
+		for i, l := range t.Table {
+			ls[i] = fmt.Sprintf("start_pc=%d, length=%d, index=%d", l.StartPc, l.Length, l.Index)
+		}
+		ss = append(ss, "{"+strings.Join(ls, "; ")+"}")
+	case TargetExceptionParameter:
+		ss = append(ss, fmt.Sprintf("exception_index=%d", t.ExceptionTableIndex))
+	case TargetInstanceof, TargetNew, TargetConstructorReference, TargetMethodReference:
+		ss = append(ss, fmt.Sprintf("offset=%d", t.Offset))
+	case TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument,
+		TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument:
+		ss = append(ss, fmt.Sprintf("offset=%d", t.Offset), fmt.Sprintf("type_index=%d", t.TypeArgumentIndex))
+	}
+	if m.TargetPath != nil && len(m.TargetPath.Path) > 0 {
+		ps := make([]string, len(m.TargetPath.Path))
+		for i, p := range m.TargetPath.Path {
+			ps[i] = _ptm[p.TypePathKind]
+			if p.TypePathKind == PathTypeArgument {
+				ps[i] += fmt.Sprintf("(%d)", p.TypeArgumentIndex)
+			}
+		}
+		ss = append(ss, "location=["+strings.Join(ps, ", ")+"]")
+	}
+	return strings.Join(ss, ", ")
+}
+
+// TargetInfo target_info of a type annotation.
+type TargetInfo struct {
+	// TypeParameterIndex of type_parameter_target and
+	// type_parameter_bound_target.
+	TypeParameterIndex uint8
+	// SupertypeIndex of supertype_target, 65535 for the superclass.
+	SupertypeIndex uint16
+	// BoundIndex of type_parameter_bound_target.
+	BoundIndex uint8
+	// FormalParameterIndex of formal_parameter_target.
+	FormalParameterIndex uint8
+	// ThrowsTypeIndex of throws_target.
+	ThrowsTypeIndex uint16
+	// TableLength and Table of localvar_target.
+	TableLength uint16
+	Table       []*LocalVarTarget
+	// ExceptionTableIndex of catch_target.
+	ExceptionTableIndex uint16
+	// Offset of offset_target and type_argument_target.
+	Offset uint16
+	// TypeArgumentIndex of type_argument_target.
+	TypeArgumentIndex uint8
+}
+
+func (m *TargetInfo) Read(r *reader, t uint8) {
+	switch t {
+	case TargetClassTypeParameter, TargetMethodTypeParameter:
+		m.TypeParameterIndex = r.u8()
+	case TargetClassExtends:
+		m.SupertypeIndex = r.u16()
+	case TargetClassTypeParameterBound, TargetMethodTypeParameterBound:
+		m.TypeParameterIndex = r.u8()
+		m.BoundIndex = r.u8()
+	case TargetField, TargetMethodReturn, TargetMethodReceiver:
+	case TargetMethodFormalParameter:
+		m.FormalParameterIndex = r.u8()
+	case TargetThrows:
+		m.ThrowsTypeIndex = r.u16()
+	case TargetLocalVariable, TargetResourceVariable:
+		m.TableLength = r.u16()
+		m.Table = make([]*LocalVarTarget, m.TableLength)
+		for i := 0; i < int(m.TableLength) && r.err == nil; i++ {
+			m.Table[i] = &LocalVarTarget{StartPc: r.u16(), Length: r.u16(), Index: r.u16()}
+		}
+	case TargetExceptionParameter:
+		m.ExceptionTableIndex = r.u16()
+	case TargetInstanceof, TargetNew, TargetConstructorReference, TargetMethodReference:
+		m.Offset = r.u16()
+	case TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument,
+		TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument:
+		m.Offset = r.u16()
+		m.TypeArgumentIndex = r.u8()
+	default:
+		if r.err == nil {
+			r.off--
+			r.fail(fmt.Errorf("invalid target type 0x%02x", t))
+		}
+	}
+}
+
+func (m *TargetInfo) Write(w *writer, t uint8) {
+	switch t {
+	case TargetClassTypeParameter, TargetMethodTypeParameter:
+		w.u8(m.TypeParameterIndex)
+	case TargetClassExtends:
+		w.u16(m.SupertypeIndex)
+	case TargetClassTypeParameterBound, TargetMethodTypeParameterBound:
+		w.u8(m.TypeParameterIndex)
+		w.u8(m.BoundIndex)
+	case TargetMethodFormalParameter:
+		w.u8(m.FormalParameterIndex)
+	case TargetThrows:
+		w.u16(m.ThrowsTypeIndex)
+	case TargetLocalVariable, TargetResourceVariable:
+		w.u16(uint16(len(m.Table)))
+		for _, l := range m.Table {
+			w.u16(l.StartPc)
+			w.u16(l.Length)
+			w.u16(l.Index)
+		}
+	case TargetExceptionParameter:
+		w.u16(m.ExceptionTableIndex)
+	case TargetInstanceof, TargetNew, TargetConstructorReference, TargetMethodReference:
+		w.u16(m.Offset)
+	case TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument,
+		TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument:
+		w.u16(m.Offset)
+		w.u8(m.TypeArgumentIndex)
+	}
+}
+
+// LocalVarTarget entry of the table in localvar_target.
+type LocalVarTarget struct {
+	StartPc uint16
+	Length  uint16
+	Index   uint16
+}
+
+// TypePath type_path.
+type TypePath struct {
+	PathLength uint8
+	Path       []*PathEntry
+}
+
+func (m *TypePath) Read(r *reader) {
+	m.PathLength = r.u8()
+	m.Path = make([]*PathEntry, m.PathLength)
+	for i := 0; i < int(m.PathLength) && r.err == nil; i++ {
+		m.Path[i] = &PathEntry{TypePathKind: r.u8(), TypeArgumentIndex: r.u8()}
+	}
+}
+
+func (m *TypePath) Write(w *writer) {
+	if m == nil {
+		w.u8(0)
+		return
+	}
+	w.u8(uint8(len(m.Path)))
+	for _, p := range m.Path {
+		w.u8(p.TypePathKind)
+		w.u8(p.TypeArgumentIndex)
+	}
+}
+
+// PathEntry entry of the path in type_path.
+type PathEntry struct {
+	TypePathKind      uint8
+	TypeArgumentIndex uint8
+}
+
+// RuntimeVisibleTypeAnnotationsAttribute
+// RuntimeVisibleTypeAnnotations_attribute.
+type RuntimeVisibleTypeAnnotationsAttribute struct {
+	NumAnnotations uint16
+	Annotations    []*TypeAnnotation
+}
+
+func (m *RuntimeVisibleTypeAnnotationsAttribute) AttributeName() string {
+	return _runtimeVisibleTypeAnnotations
+}
+
+func (m *RuntimeVisibleTypeAnnotationsAttribute) Read(r *reader) {
+	m.NumAnnotations = r.u16()
+	m.Annotations = make([]*TypeAnnotation, m.NumAnnotations)
+	for i := 0; i < int(m.NumAnnotations) && r.err == nil; i++ {
+		r.enter("type annotation", i)
+		m.Annotations[i] = new(TypeAnnotation)
+		m.Annotations[i].Read(r)
+		r.leave()
+	}
+}
+
+func (m *RuntimeVisibleTypeAnnotationsAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.Annotations)))
+	for _, a := range m.Annotations {
+		a.Write(w)
+	}
+}
+
+// RuntimeInvisibleTypeAnnotationsAttribute
+// RuntimeInvisibleTypeAnnotations_attribute.
+type RuntimeInvisibleTypeAnnotationsAttribute struct {
+	RuntimeVisibleTypeAnnotationsAttribute
+}
+
+func (m *RuntimeInvisibleTypeAnnotationsAttribute) AttributeName() string {
+	return _runtimeInvisibleTypeAnnotations
+}
+
+// AnnotationDefaultAttribute AnnotationDefault_attribute.
+type AnnotationDefaultAttribute struct {
+	DefaultValue *ElementValue
+}
+
+func (m *AnnotationDefaultAttribute) AttributeName() string {
+	return _annotationDefault
+}
+
+func (m *AnnotationDefaultAttribute) Read(r *reader) {
+	m.DefaultValue = new(ElementValue)
+	m.DefaultValue.Read(r)
+}
+
+func (m *AnnotationDefaultAttribute) Write(w *writer) {
+	m.DefaultValue.Write(w)
+}
+
+// annotations returns the runtime visible annotations of as followed by the
+// runtime invisible ones.
+func annotations(as []*AttributeInfo) (res []*Annotation) {
+	for _, a := range as {
+		switch u := a.Value.(type) {
+		case *RuntimeVisibleAnnotationsAttribute:
+			res = append(res, u.Annotations...)
+		case *RuntimeInvisibleAnnotationsAttribute:
+			res = append(res, u.Annotations...)
+		}
+	}
+	return
+}
+
+// typeAnnotations returns the runtime visible type annotations of as
+// followed by the runtime invisible ones.
+func typeAnnotations(as []*AttributeInfo) (res []*TypeAnnotation) {
+	for _, a := range as {
+		switch u := a.Value.(type) {
+		case *RuntimeVisibleTypeAnnotationsAttribute:
+			res = append(res, u.Annotations...)
+		case *RuntimeInvisibleTypeAnnotationsAttribute:
+			res = append(res, u.Annotations...)
+		}
+	}
+	return
+}
+
+// findAnnotation returns the annotation of type desc among as, nil if absent.
+func findAnnotation(cp []ConstantInfo, as []*Annotation, desc string) (res *Annotation, err error) {
+	for _, a := range as {
+		var t string
+		if t, err = a.ParseTypeFromPool(cp); err != nil {
+			return
+		}
+		if t == desc {
+			return a, nil
+		}
+	}
+	return
+}
+
+// Annotations returns the visible and invisible annotations of the class.
+func (m *ClassFile) Annotations() []*Annotation {
+	return annotations(m.Attributes)
+}
+
+// TypeAnnotations returns the visible and invisible type annotations of the
+// class.
+func (m *ClassFile) TypeAnnotations() []*TypeAnnotation {
+	return typeAnnotations(m.Attributes)
+}
+
+// Annotation returns the annotation of the class with the given type
+// descriptor, e.g. Ljava/lang/Deprecated;, nil if absent.
+func (m *ClassFile) Annotation(desc string) (*Annotation, error) {
+	return findAnnotation(m.CpInfo, m.Annotations(), desc)
+}
+
+// Annotations returns the visible and invisible annotations of the member.
+func (m *FieldInfo) Annotations() []*Annotation {
+	return annotations(m.Attributes)
+}
+
+// TypeAnnotations returns the visible and invisible type annotations of the
+// member, those of the method code excluded.
+func (m *FieldInfo) TypeAnnotations() []*TypeAnnotation {
+	return typeAnnotations(m.Attributes)
+}
+
+// Annotation returns the annotation of the member with the given type
+// descriptor, e.g. Lorg/junit/Test;, nil if absent.
+func (m *FieldInfo) Annotation(cp []ConstantInfo, desc string) (*Annotation, error) {
+	return findAnnotation(cp, m.Annotations(), desc)
+}
+
+// ParameterAnnotations returns the visible and invisible annotations of each
+// parameter of the method, nil if there are none. Parameters are counted as
+// in the attributes, which may omit synthetic ones.
+func (m *MethodInfo) ParameterAnnotations() (res [][]*Annotation) {
+	for _, a := range m.Attributes {
+		var ps []*ParameterAnnotations
+		switch u := a.Value.(type) {
+		case *RuntimeVisibleParameterAnnotationsAttribute:
+			ps = u.ParameterAnnotations
+		case *RuntimeInvisibleParameterAnnotationsAttribute:
+			ps = u.ParameterAnnotations
+		default:
+			continue
+		}
+		for i, p := range ps {
+			if i == len(res) {
+				res = append(res, nil)
+			}
+			res[i] = append(res[i], p.Annotations...)
+		}
+	}
+	return
+}
+
+// AnnotationDefault returns the default value of an annotation interface
+// element, nil if the method has none.
+func (m *MethodInfo) AnnotationDefault() *ElementValue {
+	if a, ok := m.Attribute(_annotationDefault).(*AnnotationDefaultAttribute); ok {
+		return a.DefaultValue
+	}
+	return nil
+}
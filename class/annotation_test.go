@@ -0,0 +1,76 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	n := len(cf.CpInfo)
+	for _, s := range []string{"RuntimeVisibleAnnotations", "Lorg/junit/Test;", "timeout", "RuntimeVisibleTypeAnnotations"} {
+		cf.CpInfo = append(cf.CpInfo, &Utf8Info{Tag: Tag{_utf8}, Length: uint16(len(s)), Bytes: []byte(s)})
+	}
+	m := cf.Methods[1]
+	// @Test(timeout=100000) with the Integer constant #34
+	m.Attributes = append(m.Attributes, &AttributeInfo{
+		AttributeNameIndex: uint16(n),
+		Info:               []byte{0, 1, 0, byte(n + 1), 0, 1, 0, byte(n + 2), 'I', 0, 34},
+	})
+	// @Test on the type argument of local variable 0
+	m.Code.Attributes = append(m.Code.Attributes, &AttributeInfo{
+		AttributeNameIndex: uint16(n + 3),
+		Info:               []byte{0, 1, TargetLocalVariable, 0, 1, 0, 0, 0, 5, 0, 0, 1, PathTypeArgument, 0, 0, byte(n + 1), 0, 0},
+	})
+	b, err := cf.Bytes()
+	if err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	if cf, err = ParseBytes(b); err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	m = cf.Methods[1]
+	a, err := m.Annotation(cf.CpInfo, "Lorg/junit/Test;")
+	if err != nil || a == nil {
+		t.Errorf("expected @Test annotation, error(%v)", err)
+		t.FailNow()
+	}
+	v, err := a.Element(cf.CpInfo, "timeout")
+	if err != nil || v == nil || v.Tag != ElementInt || v.ConstValueIndex != 34 {
+		t.Errorf("unexpected timeout element %+v, error(%v)", v, err)
+	}
+	if a, _ = cf.Methods[0].Annotation(cf.CpInfo, "Lorg/junit/Test;"); a != nil {
+		t.Errorf("expected no annotation of the constructor")
+	}
+	tas := typeAnnotations(m.Code.Attributes)
+	if len(tas) != 1 || tas[0].TargetType != TargetLocalVariable || len(tas[0].TargetInfo.Table) != 1 || tas[0].TargetInfo.Table[0].Length != 5 {
+		t.Errorf("unexpected type annotations %+v", tas)
+	}
+
+	s, err := cf.Format()
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	for _, l := range []string{
+		"    RuntimeVisibleAnnotations:\n" +
+			fmt.Sprintf("      0: #%d(#%d=I#34)\n", n+1, n+2) +
+			"        org.junit.Test(\n" +
+			"          timeout=100000\n" +
+			"        )\n",
+		"      RuntimeVisibleTypeAnnotations:\n" +
+			fmt.Sprintf("        0: #%d(): LOCAL_VARIABLE", n+1) + ", {start_pc=0, length=5, index=0}, location=[TYPE_ARGUMENT(0)]\n" +
+			"          org.junit.Test\n",
+	} {
+		if !strings.Contains(s, l) {
+			t.Errorf("expected\n%s\nin\n%s", l, s)
+		}
+	}
+}
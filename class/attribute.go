@@ -0,0 +1,724 @@
+package class
+
+import (
+	"fmt"
+)
+
+const (
+	_code                   = "Code"
+	_constantValue          = "ConstantValue"
+	_exceptions             = "Exceptions"
+	_innerClasses           = "InnerClasses"
+	_enclosingMethod        = "EnclosingMethod"
+	_synthetic              = "Synthetic"
+	_signature              = "Signature"
+	_sourceFile             = "SourceFile"
+	_sourceDebugExtension   = "SourceDebugExtension"
+	_lineNumberTable        = "LineNumberTable"
+	_localVariableTable     = "LocalVariableTable"
+	_localVariableTypeTable = "LocalVariableTypeTable"
+	_deprecated             = "Deprecated"
+	_bootstrapMethods       = "BootstrapMethods"
+	_methodParameters       = "MethodParameters"
+	_nestHost               = "NestHost"
+	_nestMembers            = "NestMembers"
+	_record                 = "Record"
+	_permittedSubclasses    = "PermittedSubclasses"
+)
+
+var (
+	// _attributes constructors of the attributes decoded by ParseBytes.
+	_attributes = map[string]func() attribute{
+		_code:                                 func() attribute { return new(CodeAttribute) },
+		_constantValue:                        func() attribute { return new(ConstantValueAttribute) },
+		_exceptions:                           func() attribute { return new(ExceptionsAttribute) },
+		_innerClasses:                         func() attribute { return new(InnerClassesAttribute) },
+		_enclosingMethod:                      func() attribute { return new(EnclosingMethodAttribute) },
+		_synthetic:                            func() attribute { return new(SyntheticAttribute) },
+		_signature:                            func() attribute { return new(SignatureAttribute) },
+		_sourceFile:                           func() attribute { return new(SourceFileAttribute) },
+		_sourceDebugExtension:                 func() attribute { return new(SourceDebugExtensionAttribute) },
+		_lineNumberTable:                      func() attribute { return new(LineNumberTableAttribute) },
+		_localVariableTable:                   func() attribute { return new(LocalVariableTableAttribute) },
+		_localVariableTypeTable:               func() attribute { return new(LocalVariableTypeTableAttribute) },
+		_deprecated:                           func() attribute { return new(DeprecatedAttribute) },
+		_bootstrapMethods:                     func() attribute { return new(BootstrapMethodsAttribute) },
+		_methodParameters:                     func() attribute { return new(MethodParametersAttribute) },
+		_nestHost:                             func() attribute { return new(NestHostAttribute) },
+		_nestMembers:                          func() attribute { return new(NestMembersAttribute) },
+		_record:                               func() attribute { return new(RecordAttribute) },
+		_permittedSubclasses:                  func() attribute { return new(PermittedSubclassesAttribute) },
+		_runtimeVisibleAnnotations:            func() attribute { return new(RuntimeVisibleAnnotationsAttribute) },
+		_runtimeInvisibleAnnotations:          func() attribute { return new(RuntimeInvisibleAnnotationsAttribute) },
+		_runtimeVisibleParameterAnnotations:   func() attribute { return new(RuntimeVisibleParameterAnnotationsAttribute) },
+		_runtimeInvisibleParameterAnnotations: func() attribute { return new(RuntimeInvisibleParameterAnnotationsAttribute) },
+		_runtimeVisibleTypeAnnotations:        func() attribute { return new(RuntimeVisibleTypeAnnotationsAttribute) },
+		_runtimeInvisibleTypeAnnotations:      func() attribute { return new(RuntimeInvisibleTypeAnnotationsAttribute) },
+		_stackMapTable:                        func() attribute { return new(StackMapTableAttribute) },
+		_moduleAttribute:                      func() attribute { return new(ModuleAttribute) },
+		_modulePackages:                       func() attribute { return new(ModulePackagesAttribute) },
+		_moduleMainClass:                      func() attribute { return new(ModuleMainClassAttribute) },
+		_annotationDefault:                    func() attribute { return new(AnnotationDefaultAttribute) },
+	}
+)
+
+// Attribute decoded attribute.
+type Attribute interface {
+	// AttributeName returns the name of the attribute, e.g. Code.
+	AttributeName() string
+}
+
+// attribute attribute decoded and encoded by this package.
+type attribute interface {
+	Attribute
+	Read(r *reader)
+	Write(w *writer)
+}
+
+// decodeAttributes resolves the names of as and decodes the standard and
+// registered ones into AttributeInfo.Value, unknown attributes are kept as raw
+// info only. frames is the structure as belong to, for errors.
+func decodeAttributes(cp *ConstantPool, as []*AttributeInfo, frames []frame) (err error) {
+	for i, a := range as {
+		if a.Name, err = cp.Utf8(a.AttributeNameIndex); err != nil {
+			return &ParseError{Offset: a.off - 6, Structure: structure(append(frames, frame{"attribute", i})), Err: err}
+		}
+		fs := append(append([]frame{}, frames...), frame{fmt.Sprintf("attribute %s", a.Name), i})
+		n, ok := _attributes[a.Name]
+		if !ok {
+			if a.Value, err = decodeCustom(cp, a, fs); err != nil {
+				return
+			}
+			continue
+		}
+		r := &reader{b: a.Info, base: a.off, frames: fs}
+		v := n()
+		v.Read(r)
+		if r.err == nil && r.off != len(a.Info) {
+			r.fail(fmt.Errorf("attribute length %d mismatch, %d bytes read", len(a.Info), r.off))
+		}
+		if r.err != nil {
+			return r.err
+		}
+		switch u := v.(type) {
+		case *CodeAttribute:
+			err = decodeAttributes(cp, u.Attributes, r.frames)
+		case *RecordAttribute:
+			for j, c := range u.Components {
+				if err = decodeAttributes(cp, c.Attributes, append(r.frames, frame{"component", j})); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+		a.Value = v
+	}
+	return
+}
+
+// findAttribute returns the decoded value of the first attribute of as with
+// the given name, nil if absent or not decoded.
+func findAttribute(as []*AttributeInfo, name string) Attribute {
+	for _, a := range as {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+func writeAttributes(w *writer, as []*AttributeInfo) {
+	w.u16(uint16(len(as)))
+	for _, a := range as {
+		a.Write(w)
+	}
+}
+
+func readAttributes(r *reader) (count uint16, as []*AttributeInfo) {
+	count = r.u16()
+	as = make([]*AttributeInfo, count)
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		r.enter("attribute", i)
+		as[i] = new(AttributeInfo)
+		as[i].Read(r)
+		r.leave()
+	}
+	return
+}
+
+func readU16s(r *reader, n int) (res []uint16) {
+	res = make([]uint16, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		res[i] = r.u16()
+	}
+	return
+}
+
+func writeU16s(w *writer, vs []uint16) {
+	w.u16(uint16(len(vs)))
+	for _, v := range vs {
+		w.u16(v)
+	}
+}
+
+// CodeAttribute Code_attribute.
+type CodeAttribute struct {
+	MaxStack             uint16
+	MaxLocals            uint16
+	CodeLength           uint32
+	Code                 []byte
+	ExceptionTableLength uint16
+	ExceptionTable       []*ExceptionTableEntry
+	AttributesCount      uint16
+	Attributes           []*AttributeInfo
+}
+
+func (m *CodeAttribute) AttributeName() string {
+	return _code
+}
+
+func (m *CodeAttribute) Read(r *reader) {
+	m.MaxStack = r.u16()
+	m.MaxLocals = r.u16()
+	m.CodeLength = r.u32()
+	m.Code = r.bytes(int(m.CodeLength))
+	m.ExceptionTableLength = r.u16()
+	m.ExceptionTable = make([]*ExceptionTableEntry, m.ExceptionTableLength)
+	for i := 0; i < int(m.ExceptionTableLength) && r.err == nil; i++ {
+		r.enter("exception table entry", i)
+		m.ExceptionTable[i] = new(ExceptionTableEntry)
+		m.ExceptionTable[i].Read(r)
+		r.leave()
+	}
+	m.AttributesCount, m.Attributes = readAttributes(r)
+}
+
+func (m *CodeAttribute) Write(w *writer) {
+	w.u16(m.MaxStack)
+	w.u16(m.MaxLocals)
+	w.u32(uint32(len(m.Code)))
+	w.bytes(m.Code)
+	w.u16(uint16(len(m.ExceptionTable)))
+	for _, e := range m.ExceptionTable {
+		e.Write(w)
+	}
+	writeAttributes(w, m.Attributes)
+}
+
+// Attribute returns the decoded attribute of the code with the given name,
+// e.g. LineNumberTable, nil if absent.
+func (m *CodeAttribute) Attribute(name string) Attribute {
+	return findAttribute(m.Attributes, name)
+}
+
+// ExceptionTableEntry entry of the exception_table in Code_attribute.
+type ExceptionTableEntry struct {
+	StartPc   uint16
+	EndPc     uint16
+	HandlerPc uint16
+	CatchType uint16
+}
+
+func (m *ExceptionTableEntry) Read(r *reader) {
+	m.StartPc = r.u16()
+	m.EndPc = r.u16()
+	m.HandlerPc = r.u16()
+	m.CatchType = r.u16()
+}
+
+func (m *ExceptionTableEntry) Write(w *writer) {
+	w.u16(m.StartPc)
+	w.u16(m.EndPc)
+	w.u16(m.HandlerPc)
+	w.u16(m.CatchType)
+}
+
+// ConstantValueAttribute ConstantValue_attribute.
+type ConstantValueAttribute struct {
+	ConstantValueIndex uint16
+}
+
+func (m *ConstantValueAttribute) AttributeName() string {
+	return _constantValue
+}
+
+func (m *ConstantValueAttribute) Read(r *reader) {
+	m.ConstantValueIndex = r.u16()
+}
+
+func (m *ConstantValueAttribute) Write(w *writer) {
+	w.u16(m.ConstantValueIndex)
+}
+
+// ExceptionsAttribute Exceptions_attribute.
+type ExceptionsAttribute struct {
+	NumberOfExceptions  uint16
+	ExceptionIndexTable []uint16
+}
+
+func (m *ExceptionsAttribute) AttributeName() string {
+	return _exceptions
+}
+
+func (m *ExceptionsAttribute) Read(r *reader) {
+	m.NumberOfExceptions = r.u16()
+	m.ExceptionIndexTable = readU16s(r, int(m.NumberOfExceptions))
+}
+
+func (m *ExceptionsAttribute) Write(w *writer) {
+	writeU16s(w, m.ExceptionIndexTable)
+}
+
+// InnerClassesAttribute InnerClasses_attribute.
+type InnerClassesAttribute struct {
+	NumberOfClasses uint16
+	Classes         []*InnerClass
+}
+
+func (m *InnerClassesAttribute) AttributeName() string {
+	return _innerClasses
+}
+
+func (m *InnerClassesAttribute) Read(r *reader) {
+	m.NumberOfClasses = r.u16()
+	m.Classes = make([]*InnerClass, m.NumberOfClasses)
+	for i := 0; i < int(m.NumberOfClasses) && r.err == nil; i++ {
+		m.Classes[i] = &InnerClass{
+			InnerClassInfoIndex:   r.u16(),
+			OuterClassInfoIndex:   r.u16(),
+			InnerNameIndex:        r.u16(),
+			InnerClassAccessFlags: r.u16(),
+		}
+	}
+}
+
+func (m *InnerClassesAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.Classes)))
+	for _, c := range m.Classes {
+		w.u16(c.InnerClassInfoIndex)
+		w.u16(c.OuterClassInfoIndex)
+		w.u16(c.InnerNameIndex)
+		w.u16(c.InnerClassAccessFlags)
+	}
+}
+
+// InnerClass entry of the classes in InnerClasses_attribute, OuterClassInfoIndex
+// and InnerNameIndex are 0 for a local or anonymous class.
+type InnerClass struct {
+	InnerClassInfoIndex   uint16
+	OuterClassInfoIndex   uint16
+	InnerNameIndex        uint16
+	InnerClassAccessFlags uint16
+}
+
+// EnclosingMethodAttribute EnclosingMethod_attribute, MethodIndex is 0 if
+// the class is not enclosed by a method.
+type EnclosingMethodAttribute struct {
+	ClassIndex  uint16
+	MethodIndex uint16
+}
+
+func (m *EnclosingMethodAttribute) AttributeName() string {
+	return _enclosingMethod
+}
+
+func (m *EnclosingMethodAttribute) Read(r *reader) {
+	m.ClassIndex = r.u16()
+	m.MethodIndex = r.u16()
+}
+
+func (m *EnclosingMethodAttribute) Write(w *writer) {
+	w.u16(m.ClassIndex)
+	w.u16(m.MethodIndex)
+}
+
+// SyntheticAttribute Synthetic_attribute.
+type SyntheticAttribute struct{}
+
+func (m *SyntheticAttribute) AttributeName() string {
+	return _synthetic
+}
+
+func (m *SyntheticAttribute) Read(r *reader) {}
+
+func (m *SyntheticAttribute) Write(w *writer) {}
+
+// DeprecatedAttribute Deprecated_attribute.
+type DeprecatedAttribute struct{}
+
+func (m *DeprecatedAttribute) AttributeName() string {
+	return _deprecated
+}
+
+func (m *DeprecatedAttribute) Read(r *reader) {}
+
+func (m *DeprecatedAttribute) Write(w *writer) {}
+
+// SignatureAttribute Signature_attribute.
+type SignatureAttribute struct {
+	SignatureIndex uint16
+}
+
+func (m *SignatureAttribute) AttributeName() string {
+	return _signature
+}
+
+func (m *SignatureAttribute) Read(r *reader) {
+	m.SignatureIndex = r.u16()
+}
+
+func (m *SignatureAttribute) Write(w *writer) {
+	w.u16(m.SignatureIndex)
+}
+
+// ParseSignatureFromPool returns the signature string.
+func (m *SignatureAttribute) ParseSignatureFromPool(cp []ConstantInfo) (string, error) {
+	return ui2string(cp, m.SignatureIndex)
+}
+
+// SourceFileAttribute SourceFile_attribute.
+type SourceFileAttribute struct {
+	SourcefileIndex uint16
+}
+
+func (m *SourceFileAttribute) AttributeName() string {
+	return _sourceFile
+}
+
+func (m *SourceFileAttribute) Read(r *reader) {
+	m.SourcefileIndex = r.u16()
+}
+
+func (m *SourceFileAttribute) Write(w *writer) {
+	w.u16(m.SourcefileIndex)
+}
+
+// ParseSourceFileFromPool returns the source file name.
+func (m *SourceFileAttribute) ParseSourceFileFromPool(cp []ConstantInfo) (string, error) {
+	return ui2string(cp, m.SourcefileIndex)
+}
+
+// SourceDebugExtensionAttribute SourceDebugExtension_attribute, the debug
+// extension is modified UTF-8 without a length prefix.
+type SourceDebugExtensionAttribute struct {
+	DebugExtension []byte
+}
+
+func (m *SourceDebugExtensionAttribute) AttributeName() string {
+	return _sourceDebugExtension
+}
+
+func (m *SourceDebugExtensionAttribute) Read(r *reader) {
+	m.DebugExtension = r.bytes(len(r.b) - r.off)
+}
+
+func (m *SourceDebugExtensionAttribute) Write(w *writer) {
+	w.bytes(m.DebugExtension)
+}
+
+// LineNumberTableAttribute LineNumberTable_attribute.
+type LineNumberTableAttribute struct {
+	LineNumberTableLength uint16
+	LineNumberTable       []*LineNumber
+}
+
+func (m *LineNumberTableAttribute) AttributeName() string {
+	return _lineNumberTable
+}
+
+func (m *LineNumberTableAttribute) Read(r *reader) {
+	m.LineNumberTableLength = r.u16()
+	m.LineNumberTable = make([]*LineNumber, m.LineNumberTableLength)
+	for i := 0; i < int(m.LineNumberTableLength) && r.err == nil; i++ {
+		m.LineNumberTable[i] = &LineNumber{StartPc: r.u16(), LineNumber: r.u16()}
+	}
+}
+
+func (m *LineNumberTableAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.LineNumberTable)))
+	for _, l := range m.LineNumberTable {
+		w.u16(l.StartPc)
+		w.u16(l.LineNumber)
+	}
+}
+
+// LineNumber entry of the line_number_table in LineNumberTable_attribute.
+type LineNumber struct {
+	StartPc    uint16
+	LineNumber uint16
+}
+
+// LocalVariableTableAttribute LocalVariableTable_attribute.
+type LocalVariableTableAttribute struct {
+	LocalVariableTableLength uint16
+	LocalVariableTable       []*LocalVariable
+}
+
+func (m *LocalVariableTableAttribute) AttributeName() string {
+	return _localVariableTable
+}
+
+func (m *LocalVariableTableAttribute) Read(r *reader) {
+	m.LocalVariableTableLength = r.u16()
+	m.LocalVariableTable = make([]*LocalVariable, m.LocalVariableTableLength)
+	for i := 0; i < int(m.LocalVariableTableLength) && r.err == nil; i++ {
+		m.LocalVariableTable[i] = new(LocalVariable)
+		m.LocalVariableTable[i].Read(r)
+	}
+}
+
+func (m *LocalVariableTableAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.LocalVariableTable)))
+	for _, l := range m.LocalVariableTable {
+		l.Write(w)
+	}
+}
+
+// LocalVariable entry of the local_variable_table in
+// LocalVariableTable_attribute.
+type LocalVariable struct {
+	StartPc         uint16
+	Length          uint16
+	NameIndex       uint16
+	DescriptorIndex uint16
+	Index           uint16
+}
+
+func (m *LocalVariable) Read(r *reader) {
+	m.StartPc = r.u16()
+	m.Length = r.u16()
+	m.NameIndex = r.u16()
+	m.DescriptorIndex = r.u16()
+	m.Index = r.u16()
+}
+
+func (m *LocalVariable) Write(w *writer) {
+	w.u16(m.StartPc)
+	w.u16(m.Length)
+	w.u16(m.NameIndex)
+	w.u16(m.DescriptorIndex)
+	w.u16(m.Index)
+}
+
+// LocalVariableTypeTableAttribute LocalVariableTypeTable_attribute.
+type LocalVariableTypeTableAttribute struct {
+	LocalVariableTypeTableLength uint16
+	LocalVariableTypeTable       []*LocalVariableType
+}
+
+func (m *LocalVariableTypeTableAttribute) AttributeName() string {
+	return _localVariableTypeTable
+}
+
+func (m *LocalVariableTypeTableAttribute) Read(r *reader) {
+	m.LocalVariableTypeTableLength = r.u16()
+	m.LocalVariableTypeTable = make([]*LocalVariableType, m.LocalVariableTypeTableLength)
+	for i := 0; i < int(m.LocalVariableTypeTableLength) && r.err == nil; i++ {
+		m.LocalVariableTypeTable[i] = new(LocalVariableType)
+		m.LocalVariableTypeTable[i].Read(r)
+	}
+}
+
+func (m *LocalVariableTypeTableAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.LocalVariableTypeTable)))
+	for _, l := range m.LocalVariableTypeTable {
+		l.Write(w)
+	}
+}
+
+// LocalVariableType entry of the local_variable_type_table in
+// LocalVariableTypeTable_attribute.
+type LocalVariableType struct {
+	StartPc        uint16
+	Length         uint16
+	NameIndex      uint16
+	SignatureIndex uint16
+	Index          uint16
+}
+
+func (m *LocalVariableType) Read(r *reader) {
+	m.StartPc = r.u16()
+	m.Length = r.u16()
+	m.NameIndex = r.u16()
+	m.SignatureIndex = r.u16()
+	m.Index = r.u16()
+}
+
+func (m *LocalVariableType) Write(w *writer) {
+	w.u16(m.StartPc)
+	w.u16(m.Length)
+	w.u16(m.NameIndex)
+	w.u16(m.SignatureIndex)
+	w.u16(m.Index)
+}
+
+// BootstrapMethodsAttribute BootstrapMethods_attribute.
+type BootstrapMethodsAttribute struct {
+	NumBootstrapMethods uint16
+	BootstrapMethods    []*BootstrapMethod
+}
+
+func (m *BootstrapMethodsAttribute) AttributeName() string {
+	return _bootstrapMethods
+}
+
+func (m *BootstrapMethodsAttribute) Read(r *reader) {
+	m.NumBootstrapMethods = r.u16()
+	m.BootstrapMethods = make([]*BootstrapMethod, m.NumBootstrapMethods)
+	for i := 0; i < int(m.NumBootstrapMethods) && r.err == nil; i++ {
+		b := &BootstrapMethod{BootstrapMethodRef: r.u16(), NumBootstrapArguments: r.u16()}
+		b.BootstrapArguments = readU16s(r, int(b.NumBootstrapArguments))
+		m.BootstrapMethods[i] = b
+	}
+}
+
+func (m *BootstrapMethodsAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.BootstrapMethods)))
+	for _, b := range m.BootstrapMethods {
+		w.u16(b.BootstrapMethodRef)
+		writeU16s(w, b.BootstrapArguments)
+	}
+}
+
+// BootstrapMethod entry of the bootstrap_methods in
+// BootstrapMethods_attribute.
+type BootstrapMethod struct {
+	BootstrapMethodRef    uint16
+	NumBootstrapArguments uint16
+	BootstrapArguments    []uint16
+}
+
+// MethodParametersAttribute MethodParameters_attribute.
+type MethodParametersAttribute struct {
+	ParametersCount uint8
+	Parameters      []*MethodParameter
+}
+
+func (m *MethodParametersAttribute) AttributeName() string {
+	return _methodParameters
+}
+
+func (m *MethodParametersAttribute) Read(r *reader) {
+	m.ParametersCount = r.u8()
+	m.Parameters = make([]*MethodParameter, m.ParametersCount)
+	for i := 0; i < int(m.ParametersCount) && r.err == nil; i++ {
+		m.Parameters[i] = &MethodParameter{NameIndex: r.u16(), AccessFlags: r.u16()}
+	}
+}
+
+func (m *MethodParametersAttribute) Write(w *writer) {
+	w.u8(uint8(len(m.Parameters)))
+	for _, p := range m.Parameters {
+		w.u16(p.NameIndex)
+		w.u16(p.AccessFlags)
+	}
+}
+
+// MethodParameter entry of the parameters in MethodParameters_attribute,
+// NameIndex is 0 for a parameter without name.
+type MethodParameter struct {
+	NameIndex   uint16
+	AccessFlags uint16
+}
+
+// NestHostAttribute NestHost_attribute.
+type NestHostAttribute struct {
+	HostClassIndex uint16
+}
+
+func (m *NestHostAttribute) AttributeName() string {
+	return _nestHost
+}
+
+func (m *NestHostAttribute) Read(r *reader) {
+	m.HostClassIndex = r.u16()
+}
+
+func (m *NestHostAttribute) Write(w *writer) {
+	w.u16(m.HostClassIndex)
+}
+
+// NestMembersAttribute NestMembers_attribute.
+type NestMembersAttribute struct {
+	NumberOfClasses uint16
+	Classes         []uint16
+}
+
+func (m *NestMembersAttribute) AttributeName() string {
+	return _nestMembers
+}
+
+func (m *NestMembersAttribute) Read(r *reader) {
+	m.NumberOfClasses = r.u16()
+	m.Classes = readU16s(r, int(m.NumberOfClasses))
+}
+
+func (m *NestMembersAttribute) Write(w *writer) {
+	writeU16s(w, m.Classes)
+}
+
+// PermittedSubclassesAttribute PermittedSubclasses_attribute.
+type PermittedSubclassesAttribute struct {
+	NumberOfClasses uint16
+	Classes         []uint16
+}
+
+func (m *PermittedSubclassesAttribute) AttributeName() string {
+	return _permittedSubclasses
+}
+
+func (m *PermittedSubclassesAttribute) Read(r *reader) {
+	m.NumberOfClasses = r.u16()
+	m.Classes = readU16s(r, int(m.NumberOfClasses))
+}
+
+func (m *PermittedSubclassesAttribute) Write(w *writer) {
+	writeU16s(w, m.Classes)
+}
+
+// RecordAttribute Record_attribute.
+type RecordAttribute struct {
+	ComponentsCount uint16
+	Components      []*RecordComponent
+}
+
+func (m *RecordAttribute) AttributeName() string {
+	return _record
+}
+
+func (m *RecordAttribute) Read(r *reader) {
+	m.ComponentsCount = r.u16()
+	m.Components = make([]*RecordComponent, m.ComponentsCount)
+	for i := 0; i < int(m.ComponentsCount) && r.err == nil; i++ {
+		r.enter("component", i)
+		c := &RecordComponent{NameIndex: r.u16(), DescriptorIndex: r.u16()}
+		c.AttributesCount, c.Attributes = readAttributes(r)
+		m.Components[i] = c
+		r.leave()
+	}
+}
+
+func (m *RecordAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.Components)))
+	for _, c := range m.Components {
+		w.u16(c.NameIndex)
+		w.u16(c.DescriptorIndex)
+		writeAttributes(w, c.Attributes)
+	}
+}
+
+// RecordComponent record_component_info.
+type RecordComponent struct {
+	NameIndex       uint16
+	DescriptorIndex uint16
+	AttributesCount uint16
+	Attributes      []*AttributeInfo
+}
+
+// Attribute returns the decoded attribute of the component with the given
+// name, nil if absent.
+func (m *RecordComponent) Attribute(name string) Attribute {
+	return findAttribute(m.Attributes, name)
+}
@@ -0,0 +1,64 @@
+package class
+
+import (
+	"fmt"
+)
+
+var (
+	_decoders = map[string]AttributeDecoder{}
+)
+
+// AttributeDecoder decodes the info of a custom attribute, cp is the constant
+// pool of the class file the attribute belongs to.
+type AttributeDecoder func(cp *ConstantPool, info []byte) (Attribute, error)
+
+// AttributeEncoder is implemented by decoded custom attributes that can be
+// written back, the attribute is written from its original info otherwise.
+type AttributeEncoder interface {
+	Attribute
+	// EncodeAttribute returns the attribute info, without name and length.
+	EncodeAttribute() ([]byte, error)
+}
+
+// AttributeFormatter is implemented by decoded custom attributes printed by
+// Format, the attribute is printed as hex bytes otherwise.
+type AttributeFormatter interface {
+	Attribute
+	// FormatAttribute returns the lines printed below the attribute name, cp
+	// is the constant pool of the class file.
+	FormatAttribute(cp *ConstantPool) ([]string, error)
+}
+
+// RegisterAttributeDecoder registers the decoder of the attributes with the
+// given name, ParseBytes, Parse and Decoder then set AttributeInfo.Value to
+// the decoded value. Standard attributes can't be replaced, registering a
+// name twice panics. It is meant to be called from init functions, and is
+// not safe to call while class files are parsed.
+func RegisterAttributeDecoder(name string, d AttributeDecoder) {
+	if d == nil {
+		panic("class: RegisterAttributeDecoder decoder is nil")
+	}
+	if _, ok := _attributes[name]; ok {
+		panic("class: RegisterAttributeDecoder called for standard attribute " + name)
+	}
+	if _, ok := _decoders[name]; ok {
+		panic("class: RegisterAttributeDecoder called twice for attribute " + name)
+	}
+	_decoders[name] = d
+}
+
+// decodeCustom decodes a with its registered decoder, res is nil if there is
+// none.
+func decodeCustom(cp *ConstantPool, a *AttributeInfo, frames []frame) (res Attribute, err error) {
+	d, ok := _decoders[a.Name]
+	if !ok {
+		return
+	}
+	if res, err = d(cp, a.Info); err != nil {
+		return nil, &ParseError{Offset: a.off, Structure: structure(frames), Err: err}
+	}
+	if res == nil {
+		return nil, &ParseError{Offset: a.off, Structure: structure(frames), Err: fmt.Errorf("decoder of %s returned no value", a.Name)}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package class
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type buildAttribute struct {
+	Number uint16
+}
+
+func (m *buildAttribute) AttributeName() string {
+	return "Build"
+}
+
+func (m *buildAttribute) EncodeAttribute() ([]byte, error) {
+	return []byte{byte(m.Number >> 8), byte(m.Number)}, nil
+}
+
+func (m *buildAttribute) FormatAttribute(cp *ConstantPool) ([]string, error) {
+	return []string{fmt.Sprintf("number: %d", m.Number)}, nil
+}
+
+type failingAttribute struct{}
+
+func (m failingAttribute) AttributeName() string {
+	return "Failing"
+}
+
+func (m failingAttribute) EncodeAttribute() ([]byte, error) {
+	return nil, fmt.Errorf("failed")
+}
+
+// unregisterAttributeDecoder removes the decoder of name so a test can
+// register it again.
+func unregisterAttributeDecoder(name string) {
+	delete(_decoders, name)
+}
+
+func TestRegisterAttributeDecoder(t *testing.T) {
+	t.Cleanup(func() { unregisterAttributeDecoder("Build") })
+	RegisterAttributeDecoder("Build", func(cp *ConstantPool, info []byte) (Attribute, error) {
+		if len(info) != 2 {
+			return nil, fmt.Errorf("invalid length %d", len(info))
+		}
+		return &buildAttribute{Number: binary.BigEndian.Uint16(info)}, nil
+	})
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	cf.CpInfo = append(cf.CpInfo, &Utf8Info{Tag: Tag{_utf8}, Length: 5, Bytes: []byte("Build")})
+	cf.Attributes = append(cf.Attributes, &AttributeInfo{AttributeNameIndex: uint16(len(cf.CpInfo) - 1), Info: []byte{0, 42}})
+	b, err := cf.Bytes()
+	if err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	if cf, err = ParseBytes(b); err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	a, ok := cf.Attribute("Build").(*buildAttribute)
+	if !ok || a.Number != 42 {
+		t.Errorf("unexpected Build attribute %v", cf.Attribute("Build"))
+		t.FailNow()
+	}
+	s, err := cf.Format()
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	if !strings.Contains(s, "Build:\n  number: 42\n") {
+		t.Errorf("expected Build attribute in\n%s", s)
+	}
+
+	// the decoded value is written back
+	a.Number = 7
+	if b, err = cf.Bytes(); err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	if cf, err = ParseBytes(b); err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	if a = cf.Attribute("Build").(*buildAttribute); a.Number != 7 {
+		t.Errorf("expected number 7, got %d", a.Number)
+	}
+
+	// decoder errors are reported as *ParseError
+	b[len(b)-3] = 3
+	b = append(b, 0)
+	if _, err = ParseBytes(b); err == nil {
+		t.Errorf("expected error for invalid Build attribute")
+	} else if pe, ok := err.(*ParseError); !ok || !strings.HasPrefix(pe.Structure, "attribute Build") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWriteNestedEncoderError(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	c := cf.Methods[0].Code
+	if c == nil {
+		t.Errorf("expected Code of %v", cf.Methods[0])
+		t.FailNow()
+	}
+	c.Attributes = append(c.Attributes, &AttributeInfo{AttributeNameIndex: cf.ThisClass, Name: "Failing", Value: failingAttribute{}})
+	if _, err = cf.Bytes(); err == nil || !strings.HasSuffix(err.Error(), "encode attribute Failing: failed") {
+		t.Errorf("expected encode error, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package class
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAttributes(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	sf, ok := cf.Attribute(_sourceFile).(*SourceFileAttribute)
+	if !ok {
+		t.Errorf("expected SourceFile attribute, got %v", cf.Attribute(_sourceFile))
+		t.FailNow()
+	}
+	if n, err := sf.ParseSourceFileFromPool(cf.CpInfo); err != nil || n != "HelloWorld.java" {
+		t.Errorf("unexpected source file %s, error(%v)", n, err)
+	}
+	for _, m := range cf.Methods {
+		if m.Code == nil || m.Attribute(_code) != m.Code {
+			t.Errorf("expected Code attribute of method #%d", m.NameIndex)
+		}
+		if _, ok := m.Code.Attribute(_lineNumberTable).(*LineNumberTableAttribute); !ok {
+			t.Errorf("expected LineNumberTable attribute of method #%d", m.NameIndex)
+		}
+	}
+
+	// an unknown attribute is kept verbatim
+	cf.CpInfo = append(cf.CpInfo, &Utf8Info{Tag: Tag{_utf8}, Length: 6, Bytes: []byte("Custom")})
+	cf.Attributes = append(cf.Attributes, &AttributeInfo{AttributeNameIndex: uint16(len(cf.CpInfo) - 1), Info: []byte{1, 2, 3}})
+	b, err := cf.Bytes()
+	if err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	res, err := ParseBytes(b)
+	if err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	a := res.Attributes[len(res.Attributes)-1]
+	if a.Name != "Custom" || a.Value != nil || !bytes.Equal(a.Info, []byte{1, 2, 3}) {
+		t.Errorf("unexpected unknown attribute %+v", a)
+	}
+	if res.Attribute("Custom") != nil {
+		t.Errorf("expected no decoded value of an unknown attribute")
+	}
+
+	// a known attribute longer than its content, followed by Custom
+	a = cf.Attributes[0]
+	a.Value = nil
+	a.Info = append(a.Info, 0)
+	if b, err = cf.Bytes(); err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	_, err = ParseBytes(b)
+	pe, ok := err.(*ParseError)
+	if !ok || !strings.HasPrefix(pe.Structure, "attribute SourceFile") || pe.Offset != len(b)-9-len(a.Info)+2 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
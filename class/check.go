@@ -0,0 +1,645 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/descriptor"
+	"github.com/wucongyou/go-jvm/signature"
+)
+
+const (
+	_magic = 0xCAFEBABE
+	// MinMajorVersion and MaxMajorVersion range of the class file major
+	// versions accepted by Check, JDK 1.0.2 to Java 25.
+	MinMajorVersion = 45
+	MaxMajorVersion = 69
+)
+
+// structures holding attributes, for the kind of their Signature
+const (
+	_inClass = iota
+	_inField
+	_inMethod
+	_inCode
+)
+
+var (
+	// _cpMinMajor first major version each constant tag is allowed in.
+	_cpMinMajor = map[uint8]uint16{
+		_methodHandle:  51,
+		_methodType:    51,
+		_invokeDynamic: 51,
+		_module:        53,
+		_package:       53,
+		_dynamic:       55,
+	}
+)
+
+// CheckError format violation found by Check, Location is the structure
+// holding it, e.g. constant pool entry #7 or method #1 main.
+type CheckError struct {
+	Location string
+	Err      error
+}
+
+func (e *CheckError) Error() string {
+	if e.Location == "" {
+		return fmt.Sprintf("class: %v", e.Err)
+	}
+	return fmt.Sprintf("class: %s: %v", e.Location, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// Check performs the format checks of JVMS 4.8 on the class file and
+// returns every violation found as a *CheckError, nil if the class file is
+// well-formed. Bytecode is not verified.
+func (m *ClassFile) Check() (errs []error) {
+	c := &checker{cf: m, cp: m.CpInfo, flags: ClassFlags(m.AccessFlags)}
+	if a, ok := m.Attribute(_bootstrapMethods).(*BootstrapMethodsAttribute); ok {
+		c.bootstrapMethods = len(a.BootstrapMethods)
+	}
+	c.check()
+	return c.errs
+}
+
+type checker struct {
+	cf               *ClassFile
+	cp               []ConstantInfo
+	flags            ClassFlags
+	bootstrapMethods int
+	errs             []error
+}
+
+func (c *checker) errorf(loc string, format string, a ...interface{}) {
+	c.errs = append(c.errs, &CheckError{Location: loc, Err: fmt.Errorf(format, a...)})
+}
+
+func (c *checker) add(loc string, errs ...error) {
+	for _, err := range errs {
+		c.errs = append(c.errs, &CheckError{Location: loc, Err: err})
+	}
+}
+
+// entry returns the constant at index i, reporting a violation at loc if i
+// is not a usable index.
+func (c *checker) entry(loc string, i uint16) ConstantInfo {
+	if i == 0 || int(i) >= len(c.cp) {
+		c.errorf(loc, "constant pool index %d out of range", i)
+		return nil
+	}
+	if c.cp[i] == nil {
+		c.errorf(loc, "constant pool index %d is not usable", i)
+		return nil
+	}
+	return c.cp[i]
+}
+
+// utf8 returns the string at index i, ok is false if there is none.
+func (c *checker) utf8(loc string, i uint16) (res string, ok bool) {
+	e := c.entry(loc, i)
+	if e == nil {
+		return
+	}
+	u, ok := e.(*Utf8Info)
+	if !ok {
+		c.errorf(loc, "constant pool index %d is a %s, expected Utf8", i, e.TN())
+		return
+	}
+	var err error
+	if res, err = DecodeString(u.Bytes); err != nil {
+		// reported with the constant pool entry
+		return "", false
+	}
+	return res, true
+}
+
+// class returns the internal name of the class at index i.
+func (c *checker) class(loc string, i uint16) (res string, ok bool) {
+	e := c.entry(loc, i)
+	if e == nil {
+		return
+	}
+	u, ok := e.(*ClassInfo)
+	if !ok {
+		c.errorf(loc, "constant pool index %d is a %s, expected Class", i, e.TN())
+		return
+	}
+	// the name itself is checked with the constant pool entry
+	if s, err := u.ParseNameFromPool(c.cp); err == nil {
+		return s, true
+	}
+	return "", false
+}
+
+func (c *checker) kind(loc string, i uint16, tags ...uint8) (res ConstantInfo) {
+	if res = c.entry(loc, i); res == nil {
+		return
+	}
+	for _, t := range tags {
+		if res.T() == t {
+			return
+		}
+	}
+	ns := make([]string, len(tags))
+	for j, t := range tags {
+		ns[j] = _tm[t]
+	}
+	c.errorf(loc, "constant pool index %d is a %s, expected %s", i, res.TN(), strings.Join(ns, " or "))
+	return nil
+}
+
+func (c *checker) check() {
+	m := c.cf
+	if m.Magic != _magic {
+		c.errorf("", "invalid magic 0x%08X", m.Magic)
+	}
+	if m.MajorVersion < MinMajorVersion || m.MajorVersion > MaxMajorVersion {
+		c.errorf("", "unsupported class file version %d.%d", m.MajorVersion, m.MinorVersion)
+	} else if m.MajorVersion >= 56 && m.MinorVersion != 0 && m.MinorVersion != 0xFFFF {
+		c.errorf("", "invalid minor version %d for major version %d", m.MinorVersion, m.MajorVersion)
+	}
+	c.constantPool()
+	c.add("access_flags", c.flags.Validate(m.MajorVersion)...)
+
+	name, ok := c.class("this_class", m.ThisClass)
+	if ok && strings.HasPrefix(name, "[") {
+		c.errorf("this_class", "array class %s", name)
+	}
+	module := c.flags.Has(AccModule)
+	switch {
+	case m.SuperClass == 0:
+		if ok && name != "java/lang/Object" && !module {
+			c.errorf("super_class", "only java/lang/Object has no superclass")
+		}
+	case module:
+		c.errorf("super_class", "module-info must have no superclass")
+	default:
+		if sc, ok := c.class("super_class", m.SuperClass); ok {
+			if strings.HasPrefix(sc, "[") {
+				c.errorf("super_class", "array class %s", sc)
+			}
+			if c.flags.Has(AccInterface) && sc != "java/lang/Object" {
+				c.errorf("super_class", "superclass of an interface must be java/lang/Object, got %s", sc)
+			}
+		}
+	}
+	if module && (len(m.Interfaces) > 0 || len(m.Fields) > 0 || len(m.Methods) > 0) {
+		c.errorf("", "module-info must have no interfaces, fields or methods")
+	}
+	for i, in := range m.Interfaces {
+		loc := fmt.Sprintf("interface #%d", i)
+		if n, ok := c.class(loc, in.NameIndex); ok && strings.HasPrefix(n, "[") {
+			c.errorf(loc, "array class %s", n)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, f := range m.Fields {
+		c.field(i, f, seen)
+	}
+	seen = make(map[string]bool)
+	for i, f := range m.Methods {
+		c.method(i, f, seen)
+	}
+	c.attributes("", m.Attributes, _inClass)
+	if module && m.Attribute(_moduleAttribute) == nil {
+		c.errorf("", "module-info must have a Module attribute")
+	}
+}
+
+func (c *checker) constantPool() {
+	major := c.cf.MajorVersion
+	for i := 1; i < len(c.cp); i++ {
+		e := c.cp[i]
+		if e == nil {
+			continue
+		}
+		loc := fmt.Sprintf("constant pool entry #%d", i)
+		if (e.T() == _long || e.T() == _double) && i+1 < len(c.cp) && c.cp[i+1] != nil {
+			c.errorf(loc, "slot #%d after a %s must be unusable", i+1, e.TN())
+		}
+		if v, ok := _cpMinMajor[e.T()]; ok && major < v {
+			c.errorf(loc, "%s requires major version %d, got %d", e.TN(), v, major)
+		}
+		switch u := e.(type) {
+		case *Utf8Info:
+			if _, err := DecodeString(u.Bytes); err != nil {
+				c.add(loc, err)
+			}
+		case *ClassInfo:
+			if n, ok := c.utf8(loc, u.NameIndex); ok {
+				if err := checkClassName(n); err != nil {
+					c.add(loc, err)
+				}
+			}
+		case *StringInfo:
+			c.utf8(loc, u.StringIndex)
+		case *FieldRefInfo:
+			c.memberRef(loc, u, false)
+		case *MethodRefInfo:
+			c.memberRef(loc, &u.FieldRefInfo, true)
+		case *InterfaceMethodRefInfo:
+			c.memberRef(loc, &u.FieldRefInfo, true)
+		case *NameAndType:
+			c.utf8(loc, u.NameIndex)
+			c.utf8(loc, u.DescriptorIndex)
+		case *MethodHandle:
+			c.methodHandle(loc, u)
+		case *MethodTypeInfo:
+			if d, ok := c.utf8(loc, u.DescriptorIndex); ok {
+				if _, err := descriptor.ParseMethod(d); err != nil {
+					c.add(loc, err)
+				}
+			}
+		case *DynamicInfo:
+			c.dynamic(loc, &u.InvokeDynamicInfo, false)
+		case *InvokeDynamicInfo:
+			c.dynamic(loc, u, true)
+		case *PackageInfo:
+			c.moduleConstant(loc, u.NameIndex)
+		case *ModuleInfo:
+			c.moduleConstant(loc, u.NameIndex)
+		}
+	}
+}
+
+// checkClassName checks the name of a CONSTANT_Class_info, a binary name in
+// internal form or an array descriptor.
+func checkClassName(n string) (err error) {
+	if strings.HasPrefix(n, "[") {
+		_, err = descriptor.ParseField(n)
+	} else {
+		_, err = descriptor.ParseField("L" + n + ";")
+	}
+	return
+}
+
+// checkName checks an unqualified name of a field or method (JVMS 4.2.2).
+func checkName(n string, method bool) error {
+	if n == "" {
+		return fmt.Errorf("empty name")
+	}
+	if method && (n == "<init>" || n == "<clinit>") {
+		return nil
+	}
+	bad := ".;[/"
+	if method {
+		bad += "<>"
+	}
+	if i := strings.IndexAny(n, bad); i >= 0 {
+		return fmt.Errorf("illegal character %q in name %s", n[i], n)
+	}
+	return nil
+}
+
+func (c *checker) nameAndType(loc string, i uint16) (name, desc string, ok bool) {
+	nt, _ := c.kind(loc, i, _nameAndType).(*NameAndType)
+	if nt == nil {
+		return
+	}
+	if name, ok = c.utf8(loc, nt.NameIndex); !ok {
+		return
+	}
+	desc, ok = c.utf8(loc, nt.DescriptorIndex)
+	return
+}
+
+func (c *checker) memberRef(loc string, u *FieldRefInfo, method bool) {
+	c.class(loc, u.ClassIndex)
+	name, desc, ok := c.nameAndType(loc, u.NameAndTypeIndex)
+	if !ok {
+		return
+	}
+	if err := checkName(name, method); err != nil {
+		c.add(loc, err)
+	}
+	if !method {
+		if _, err := descriptor.ParseField(desc); err != nil {
+			c.add(loc, err)
+		}
+		return
+	}
+	md, err := descriptor.ParseMethod(desc)
+	if err != nil {
+		c.add(loc, err)
+		return
+	}
+	if name == "<clinit>" {
+		c.errorf(loc, "reference to <clinit>")
+	}
+	if name == "<init>" && md.Return.Kind != descriptor.Void {
+		c.errorf(loc, "<init> must return void")
+	}
+}
+
+func (c *checker) methodHandle(loc string, u *MethodHandle) {
+	var tags []uint8
+	switch u.ReferenceKind {
+	case 1, 2, 3, 4:
+		tags = []uint8{_fieldRef}
+	case 5, 8:
+		tags = []uint8{_methodRef}
+	case 6, 7:
+		tags = []uint8{_methodRef}
+		if c.cf.MajorVersion >= 52 {
+			tags = append(tags, _interfaceMethodRef)
+		}
+	case 9:
+		tags = []uint8{_interfaceMethodRef}
+	default:
+		c.errorf(loc, "invalid reference kind %d", u.ReferenceKind)
+		return
+	}
+	e := c.kind(loc, u.ReferenceIndex, tags...)
+	if e == nil || u.ReferenceKind < 5 {
+		return
+	}
+	var ref *FieldRefInfo
+	switch r := e.(type) {
+	case *MethodRefInfo:
+		ref = &r.FieldRefInfo
+	case *InterfaceMethodRefInfo:
+		ref = &r.FieldRefInfo
+	}
+	name, _, ok := c.nameAndType(loc, ref.NameAndTypeIndex)
+	switch {
+	case !ok:
+	case u.ReferenceKind == 8 && name != "<init>":
+		c.errorf(loc, "REF_newInvokeSpecial must reference <init>, got %s", name)
+	case u.ReferenceKind != 8 && (name == "<init>" || name == "<clinit>"):
+		c.errorf(loc, "%s must not reference %s", _refKinds[u.ReferenceKind], name)
+	}
+}
+
+func (c *checker) dynamic(loc string, u *InvokeDynamicInfo, method bool) {
+	if int(u.BootstrapMethodAttrIndex) >= c.bootstrapMethods {
+		c.errorf(loc, "bootstrap method index %d out of range", u.BootstrapMethodAttrIndex)
+	}
+	name, desc, ok := c.nameAndType(loc, u.NameAndTypeIndex)
+	if !ok {
+		return
+	}
+	if err := checkName(name, method); err != nil {
+		c.add(loc, err)
+	}
+	var err error
+	if method {
+		_, err = descriptor.ParseMethod(desc)
+	} else {
+		_, err = descriptor.ParseField(desc)
+	}
+	if err != nil {
+		c.add(loc, err)
+	}
+}
+
+func (c *checker) moduleConstant(loc string, i uint16) {
+	c.utf8(loc, i)
+	if !c.flags.Has(AccModule) {
+		c.errorf(loc, "Module and Package constants are only allowed in module-info")
+	}
+}
+
+func (c *checker) field(i int, f *FieldInfo, seen map[string]bool) {
+	loc := fmt.Sprintf("field #%d", i)
+	name, ok := c.utf8(loc, f.NameIndex)
+	if ok {
+		loc += " " + name
+		if err := checkName(name, false); err != nil {
+			c.add(loc, err)
+		}
+	}
+	c.add(loc, FieldFlags(f.AccessFlags).Validate(c.cf.MajorVersion, c.flags)...)
+	desc, dok := c.utf8(loc, f.DescriptorIndex)
+	var t *descriptor.Type
+	if dok {
+		var err error
+		if t, err = descriptor.ParseField(desc); err != nil {
+			c.add(loc, err)
+		}
+	}
+	if ok && dok {
+		if seen[name+desc] {
+			c.errorf(loc, "duplicate field %s %s", name, desc)
+		}
+		seen[name+desc] = true
+	}
+	c.attributes(loc, f.Attributes, _inField)
+	if a, ok := f.Attribute(_constantValue).(*ConstantValueAttribute); ok && t != nil && f.AccessFlags&_fAccStatic != 0 {
+		c.constantValue(loc, a.ConstantValueIndex, t)
+	}
+}
+
+// constantValue checks that the ConstantValue of a field matches its type.
+func (c *checker) constantValue(loc string, i uint16, t *descriptor.Type) {
+	var tag uint8
+	switch {
+	case t.Dimensions > 0:
+	case t.Kind == descriptor.Long:
+		tag = _long
+	case t.Kind == descriptor.Float:
+		tag = _float
+	case t.Kind == descriptor.Double:
+		tag = _double
+	case t.Kind == descriptor.Object:
+		if t.ClassName == "java/lang/String" {
+			tag = _string
+		}
+	default:
+		tag = _integer
+	}
+	if tag == 0 {
+		c.errorf(loc, "ConstantValue of a field of type %s", t.Java())
+		return
+	}
+	c.kind(loc, i, tag)
+}
+
+func (c *checker) method(i int, mi *MethodInfo, seen map[string]bool) {
+	loc := fmt.Sprintf("method #%d", i)
+	name, ok := c.utf8(loc, mi.NameIndex)
+	if ok {
+		loc += " " + name
+		if err := checkName(name, true); err != nil {
+			c.add(loc, err)
+		}
+	}
+	fl := MethodFlags(mi.AccessFlags)
+	c.add(loc, fl.Validate(c.cf.MajorVersion, c.flags, name)...)
+	desc, dok := c.utf8(loc, mi.DescriptorIndex)
+	if dok {
+		if md, err := descriptor.ParseMethod(desc); err != nil {
+			c.add(loc, err)
+		} else {
+			args := md.ArgSlots()
+			if !fl.Has(AccStatic) {
+				args++
+			}
+			if args > 255 {
+				c.errorf(loc, "%d argument slots, at most 255 allowed", args)
+			}
+			if (name == "<init>" || name == "<clinit>") && md.Return.Kind != descriptor.Void {
+				c.errorf(loc, "%s must return void", name)
+			}
+			if name == "<clinit>" && c.cf.MajorVersion >= 51 && len(md.Params) > 0 {
+				c.errorf(loc, "<clinit> must take no arguments")
+			}
+		}
+	}
+	if ok && dok {
+		if seen[name+desc] {
+			c.errorf(loc, "duplicate method %s%s", name, desc)
+		}
+		seen[name+desc] = true
+	}
+	c.attributes(loc, mi.Attributes, _inMethod)
+	abstract := mi.AccessFlags&(_mAccAbstract|_mAccNative) != 0
+	switch {
+	case abstract && mi.Code != nil:
+		c.errorf(loc, "abstract or native method must have no Code attribute")
+	case !abstract && mi.Code == nil:
+		c.errorf(loc, "method must have a Code attribute")
+	}
+}
+
+// attributes checks the attributes of the structure at loc, in is the kind
+// of structure. Only the constant pool references of decoded attributes are
+// checked.
+func (c *checker) attributes(loc string, as []*AttributeInfo, in int) {
+	counts := make(map[string]int)
+	for i, a := range as {
+		l := strings.TrimSpace(fmt.Sprintf("%s attribute #%d", loc, i))
+		name, ok := c.utf8(l, a.AttributeNameIndex)
+		if !ok {
+			continue
+		}
+		l += " " + name
+		if counts[name]++; counts[name] == 2 && a.Value != nil && name != _lineNumberTable &&
+			name != _localVariableTable && name != _localVariableTypeTable {
+			c.errorf(l, "duplicate %s attribute", name)
+		}
+		c.attribute(l, a.Value, in)
+	}
+}
+
+func (c *checker) attribute(loc string, v Attribute, in int) {
+	switch u := v.(type) {
+	case *CodeAttribute:
+		c.code(loc, u)
+	case *ConstantValueAttribute:
+		c.kind(loc, u.ConstantValueIndex, _integer, _float, _long, _double, _string)
+	case *SourceFileAttribute:
+		c.utf8(loc, u.SourcefileIndex)
+	case *SignatureAttribute:
+		c.signature(loc, u.SignatureIndex, in)
+	case *ExceptionsAttribute:
+		for _, i := range u.ExceptionIndexTable {
+			c.class(loc, i)
+		}
+	case *InnerClassesAttribute:
+		for _, ic := range u.Classes {
+			c.class(loc, ic.InnerClassInfoIndex)
+			if ic.OuterClassInfoIndex != 0 {
+				c.class(loc, ic.OuterClassInfoIndex)
+			}
+			if ic.InnerNameIndex != 0 {
+				c.utf8(loc, ic.InnerNameIndex)
+			}
+			c.add(loc, InnerClassFlags(ic.InnerClassAccessFlags).Validate(c.cf.MajorVersion)...)
+		}
+	case *EnclosingMethodAttribute:
+		c.class(loc, u.ClassIndex)
+		if u.MethodIndex != 0 {
+			c.nameAndType(loc, u.MethodIndex)
+		}
+	case *BootstrapMethodsAttribute:
+		for _, b := range u.BootstrapMethods {
+			c.kind(loc, b.BootstrapMethodRef, _methodHandle)
+			for _, a := range b.BootstrapArguments {
+				c.kind(loc, a, _integer, _float, _long, _double, _class, _string, _methodHandle, _methodType, _dynamic)
+			}
+		}
+	case *NestHostAttribute:
+		c.class(loc, u.HostClassIndex)
+	case *NestMembersAttribute:
+		for _, i := range u.Classes {
+			c.class(loc, i)
+		}
+	case *PermittedSubclassesAttribute:
+		for _, i := range u.Classes {
+			c.class(loc, i)
+		}
+	case *LocalVariableTableAttribute:
+		for _, l := range u.LocalVariableTable {
+			c.utf8(loc, l.NameIndex)
+			if d, ok := c.utf8(loc, l.DescriptorIndex); ok {
+				if _, err := descriptor.ParseField(d); err != nil {
+					c.add(loc, err)
+				}
+			}
+		}
+	case *RecordAttribute:
+		for j, rc := range u.Components {
+			l := fmt.Sprintf("%s component #%d", loc, j)
+			if n, ok := c.utf8(l, rc.NameIndex); ok {
+				if err := checkName(n, false); err != nil {
+					c.add(l, err)
+				}
+			}
+			if d, ok := c.utf8(l, rc.DescriptorIndex); ok {
+				if _, err := descriptor.ParseField(d); err != nil {
+					c.add(l, err)
+				}
+			}
+			c.attributes(l, rc.Attributes, _inField)
+		}
+	case *ModuleAttribute:
+		major := c.cf.MajorVersion
+		if md, err := u.ParseFromPool(c.cp); err != nil {
+			c.add(loc, err)
+		} else {
+			for _, r := range md.Requires {
+				c.add(loc, r.Flags.Validate(major, r.Name)...)
+			}
+		}
+	}
+}
+
+func (c *checker) signature(loc string, i uint16, in int) {
+	s, ok := c.utf8(loc, i)
+	if !ok {
+		return
+	}
+	var err error
+	switch in {
+	case _inClass:
+		_, err = signature.ParseClass(s)
+	case _inMethod:
+		_, err = signature.ParseMethod(s)
+	default:
+		_, err = signature.ParseField(s)
+	}
+	if err != nil {
+		c.add(loc, err)
+	}
+}
+
+func (c *checker) code(loc string, a *CodeAttribute) {
+	n := len(a.Code)
+	if n == 0 || n >= 65536 {
+		c.errorf(loc, "code length %d out of range 1-65535", n)
+	}
+	for i, e := range a.ExceptionTable {
+		if e.StartPc >= e.EndPc || int(e.EndPc) > n || int(e.HandlerPc) >= n {
+			c.errorf(loc, "exception table entry #%d has invalid range %d-%d or handler %d", i, e.StartPc, e.EndPc, e.HandlerPc)
+		}
+		if e.CatchType != 0 {
+			c.class(loc, e.CatchType)
+		}
+	}
+	c.attributes(loc, a.Attributes, _inCode)
+}
@@ -0,0 +1,41 @@
+package class
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	if errs := cf.Check(); len(errs) != 0 {
+		t.Errorf("expected no violations, got %v", errs)
+	}
+
+	cf.Magic = 0xCAFEBABF
+	cf.SuperClass = 4
+	cf.Methods[1].AccessFlags |= _mAccPrivate
+	cf.Methods[1].Code = nil
+	cf.CpInfo[9].(*NameAndType).DescriptorIndex = 40
+	exp := []string{
+		"class: invalid magic 0xCAFEBABF",
+		"class: constant pool entry #7: constant pool index 40 out of range",
+		"class: constant pool entry #9: constant pool index 40 out of range",
+		"class: super_class: constant pool index 4 is a Utf8, expected Class",
+		"class: method #1 main: at most one of ACC_PUBLIC, ACC_PRIVATE and ACC_PROTECTED may be set",
+		"class: method #1 main: method must have a Code attribute",
+	}
+	errs := cf.Check()
+	if len(errs) != len(exp) {
+		t.Errorf("expected %d violations, got %d: %v", len(exp), len(errs), errs)
+		t.FailNow()
+	}
+	for i, err := range errs {
+		if _, ok := err.(*CheckError); !ok || !strings.HasPrefix(err.Error(), exp[i]) {
+			t.Errorf("expected %s, got %v", exp[i], err)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package class
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -18,7 +21,10 @@ const (
 	_utf8               = 1
 	_methodHandle       = 15
 	_methodType         = 16
+	_dynamic            = 17
 	_invokeDynamic      = 18
+	_module             = 19
+	_package            = 20
 )
 
 var (
@@ -26,22 +32,30 @@ var (
 		_class:              "Class",
 		_fieldRef:           "Fieldref",
 		_methodRef:          "Methodref",
-		_interfaceMethodRef: "Methodref",
+		_interfaceMethodRef: "InterfaceMethodref",
 		_string:             "String",
 		_integer:            "Integer",
 		_float:              "Float",
 		_long:               "Long",
 		_double:             "Double",
 		_nameAndType:        "NameAndType",
-		_utf8:               "utf8",
+		_utf8:               "Utf8",
 		_methodHandle:       "MethodHandle",
 		_methodType:         "MethodType",
+		_dynamic:            "Dynamic",
 		_invokeDynamic:      "InvokeDynamic",
+		_module:             "Module",
+		_package:            "Package",
 	}
 )
 
-func NewConstantInfo(b []byte, s int) (res ConstantInfo, next int) {
-	tag, next := u8(b, s)
+// NewConstantInfo reads a constant pool entry, returns nil if the entry could
+// not be read, the failure is kept by r.
+func NewConstantInfo(r *reader) (res ConstantInfo) {
+	tag := r.u8()
+	if r.err != nil {
+		return
+	}
 	switch tag {
 	case _class:
 		res = new(ClassInfo)
@@ -69,13 +83,25 @@ func NewConstantInfo(b []byte, s int) (res ConstantInfo, next int) {
 		res = new(MethodHandle)
 	case _methodType:
 		res = new(MethodTypeInfo)
+	case _dynamic:
+		res = new(DynamicInfo)
 	case _invokeDynamic:
 		res = new(InvokeDynamicInfo)
+	case _module:
+		res = new(ModuleInfo)
+	case _package:
+		res = new(PackageInfo)
 	default:
-		panic(fmt.Errorf("unsupported tag %d", tag))
+		r.off--
+		r.fail(fmt.Errorf("unsupported tag %d", tag))
+		return
 	}
 	res.SetT(tag)
-	return res, res.Read(b, next)
+	res.Read(r)
+	if r.err != nil {
+		res = nil
+	}
+	return
 }
 
 // ConstantInfo constant info, each constant info holds tag.
@@ -83,7 +109,8 @@ type ConstantInfo interface {
 	T() uint8
 	TN() string
 	SetT(tag uint8)
-	Read(b []byte, s int) (next int)
+	Read(r *reader)
+	Write(w *writer)
 }
 
 type Tag struct {
@@ -108,19 +135,16 @@ type ClassInfo struct {
 	NameIndex uint16
 }
 
-func (m *ClassInfo) Read(b []byte, s int) (next int) {
-	m.NameIndex, next = u16(b, s)
-	return
+func (m *ClassInfo) Read(r *reader) {
+	m.NameIndex = r.u16()
+}
+
+func (m *ClassInfo) Write(w *writer) {
+	w.u16(m.NameIndex)
 }
 
 func (m *ClassInfo) ParseNameFromPool(cp []ConstantInfo) (name string, err error) {
-	u2, ok := cp[m.NameIndex].(*Utf8Info)
-	if !ok {
-		err = fmt.Errorf("NameIndex of class info point to a non utf8 info")
-		return
-	}
-	name, err = u2string(u2.Bytes)
-	return
+	return ui2string(cp, m.NameIndex)
 }
 
 // FiledRefInfo CONSTANT_Fieldref_info.
@@ -130,29 +154,22 @@ type FieldRefInfo struct {
 	NameAndTypeIndex uint16
 }
 
-func (m *FieldRefInfo) Read(b []byte, s int) (next int) {
-	m.ClassIndex, next = u16(b, s)
-	m.NameAndTypeIndex, next = u16(b, next)
-	return
+func (m *FieldRefInfo) Read(r *reader) {
+	m.ClassIndex = r.u16()
+	m.NameAndTypeIndex = r.u16()
+}
+
+func (m *FieldRefInfo) Write(w *writer) {
+	w.u16(m.ClassIndex)
+	w.u16(m.NameAndTypeIndex)
 }
 
 func (m *FieldRefInfo) ParseClassFromPool(cp []ConstantInfo) (class string, err error) {
-	n, ok := cp[m.ClassIndex].(*ClassInfo)
-	if !ok {
-		err = fmt.Errorf("class index must pointer to a ClassInfo")
-		return
-	}
-	return n.ParseNameFromPool(cp)
+	return classNameFromPool(cp, m.ClassIndex)
 }
 
 func (m *FieldRefInfo) ParseNameAndTypeFromPool(cp []ConstantInfo) (name, desc string, err error) {
-	n, ok := cp[m.NameAndTypeIndex].(*NameAndType)
-	if !ok {
-		err = fmt.Errorf("name and type index must pointer to a NameAndType")
-		return
-	}
-	name, desc, err = n.ParseFromPool(cp)
-	return
+	return nameAndTypeFromPool(cp, m.NameAndTypeIndex)
 }
 
 // MethodRefInfo CONSTANT_Methodref_info.
@@ -175,9 +192,12 @@ func (m *StringInfo) ParseStringFromPool(cp []ConstantInfo) (s string, err error
 	return ui2string(cp, m.StringIndex)
 }
 
-func (m *StringInfo) Read(b []byte, s int) (next int) {
-	m.StringIndex, next = u16(b, s)
-	return
+func (m *StringInfo) Read(r *reader) {
+	m.StringIndex = r.u16()
+}
+
+func (m *StringInfo) Write(w *writer) {
+	w.u16(m.StringIndex)
 }
 
 // IntegerInfo CONSTANT_Integer_info.
@@ -186,9 +206,22 @@ type IntegerInfo struct {
 	Bytes uint32
 }
 
-func (m *IntegerInfo) Read(b []byte, s int) (next int) {
-	m.Bytes, next = u32(b, s)
-	return
+func (m *IntegerInfo) Read(r *reader) {
+	m.Bytes = r.u32()
+}
+
+func (m *IntegerInfo) Write(w *writer) {
+	w.u32(m.Bytes)
+}
+
+// Int32 returns the int value.
+func (m *IntegerInfo) Int32() int32 {
+	return int32(m.Bytes)
+}
+
+// Literal returns the value like javap prints it, e.g. -1.
+func (m *IntegerInfo) Literal() string {
+	return strconv.FormatInt(int64(m.Int32()), 10)
 }
 
 // FloatInfo CONSTANT_Float_info.
@@ -196,6 +229,16 @@ type FloatInfo struct {
 	IntegerInfo
 }
 
+// Float32 returns the float value, NaN keeps its bit pattern.
+func (m *FloatInfo) Float32() float32 {
+	return math.Float32frombits(m.Bytes)
+}
+
+// Literal returns the value like javap prints it, e.g. 1.0f or NaNf.
+func (m *FloatInfo) Literal() string {
+	return javaFloat(float64(m.Float32()), 32) + "f"
+}
+
 // LongInfo CONSTANT_Long_info.
 type LongInfo struct {
 	Tag
@@ -203,10 +246,24 @@ type LongInfo struct {
 	LowBytes  uint32
 }
 
-func (m *LongInfo) Read(b []byte, s int) (next int) {
-	m.HighBytes, next = u32(b, s)
-	m.LowBytes, next = u32(b, next)
-	return
+func (m *LongInfo) Read(r *reader) {
+	m.HighBytes = r.u32()
+	m.LowBytes = r.u32()
+}
+
+func (m *LongInfo) Write(w *writer) {
+	w.u32(m.HighBytes)
+	w.u32(m.LowBytes)
+}
+
+// Int64 returns the long value.
+func (m *LongInfo) Int64() int64 {
+	return int64(m.HighBytes)<<32 | int64(m.LowBytes)
+}
+
+// Literal returns the value like javap prints it, e.g. 123l.
+func (m *LongInfo) Literal() string {
+	return strconv.FormatInt(m.Int64(), 10) + "l"
 }
 
 // DoubleInfo CONSTANT_Double_info.
@@ -214,6 +271,60 @@ type DoubleInfo struct {
 	LongInfo
 }
 
+// Float64 returns the double value, NaN keeps its bit pattern.
+func (m *DoubleInfo) Float64() float64 {
+	return math.Float64frombits(uint64(m.HighBytes)<<32 | uint64(m.LowBytes))
+}
+
+// Literal returns the value like javap prints it, e.g. 1.0E10d or NaNd.
+func (m *DoubleInfo) Literal() string {
+	return javaFloat(m.Float64(), 64) + "d"
+}
+
+// javaFloat formats f like Java's Float.toString or Double.toString.
+func javaFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		if math.Signbit(f) {
+			return "-0.0"
+		}
+		return "0.0"
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	// shortest digits that uniquely identify f: d.ddde±xx, Java picks the
+	// closest of at least two digits
+	s := strconv.FormatFloat(f, 'e', -1, bitSize)
+	if !strings.Contains(s, ".") {
+		s = strconv.FormatFloat(f, 'e', 1, bitSize)
+	}
+	i := strings.IndexByte(s, 'e')
+	ds := strings.TrimRight(strings.Replace(s[:i], ".", "", 1), "0")
+	exp, _ := strconv.Atoi(s[i+1:])
+	if f >= 1e-3 && f < 1e7 {
+		if exp < 0 {
+			return sign + "0." + strings.Repeat("0", -exp-1) + ds
+		}
+		if len(ds) <= exp+1 {
+			return sign + ds + strings.Repeat("0", exp+1-len(ds)) + ".0"
+		}
+		return sign + ds[:exp+1] + "." + ds[exp+1:]
+	}
+	frac := ds[1:]
+	if frac == "" {
+		frac = "0"
+	}
+	return fmt.Sprintf("%s%s.%sE%d", sign, ds[:1], frac, exp)
+}
+
 // NameAndTypeInfo CONSTANT_NameAndType_info.
 type NameAndType struct {
 	Tag
@@ -221,10 +332,14 @@ type NameAndType struct {
 	DescriptorIndex uint16
 }
 
-func (m *NameAndType) Read(b []byte, s int) (next int) {
-	m.NameIndex, next = u16(b, s)
-	m.DescriptorIndex, next = u16(b, next)
-	return
+func (m *NameAndType) Read(r *reader) {
+	m.NameIndex = r.u16()
+	m.DescriptorIndex = r.u16()
+}
+
+func (m *NameAndType) Write(w *writer) {
+	w.u16(m.NameIndex)
+	w.u16(m.DescriptorIndex)
 }
 
 func (m *NameAndType) ParseFromPool(cp []ConstantInfo) (name, desc string, err error) {
@@ -244,9 +359,13 @@ func (m *NameAndType) ParseDescFromPool(cp []ConstantInfo) (res string, err erro
 }
 
 func ui2string(cp []ConstantInfo, i uint16) (res string, err error) {
-	u2, ok := cp[i].(*Utf8Info)
+	var c ConstantInfo
+	if c, err = entryFromPool(cp, i); err != nil {
+		return
+	}
+	u2, ok := c.(*Utf8Info)
 	if !ok {
-		err = fmt.Errorf("index %d points to a non utf8 info", i)
+		err = mismatch(i, c, "Utf8")
 		return
 	}
 	res, err = u2string(u2.Bytes)
@@ -260,10 +379,14 @@ type Utf8Info struct {
 	Bytes  []byte
 }
 
-func (m *Utf8Info) Read(b []byte, s int) (next int) {
-	m.Length, next = u16(b, s)
-	m.Bytes, next = bs(b, next, int(m.Length))
-	return
+func (m *Utf8Info) Read(r *reader) {
+	m.Length = r.u16()
+	m.Bytes = r.bytes(int(m.Length))
+}
+
+func (m *Utf8Info) Write(w *writer) {
+	w.u16(uint16(len(m.Bytes)))
+	w.bytes(m.Bytes)
 }
 
 // MethodHandle CONSTANT_MethodHandle_info.
@@ -273,10 +396,14 @@ type MethodHandle struct {
 	ReferenceIndex uint16
 }
 
-func (m *MethodHandle) Read(b []byte, s int) (next int) {
-	m.ReferenceKind, next = u8(b, s)
-	m.ReferenceIndex, next = u16(b, next)
-	return
+func (m *MethodHandle) Read(r *reader) {
+	m.ReferenceKind = r.u8()
+	m.ReferenceIndex = r.u16()
+}
+
+func (m *MethodHandle) Write(w *writer) {
+	w.u8(m.ReferenceKind)
+	w.u16(m.ReferenceIndex)
 }
 
 // MethodTypeInfo CONSTANT_MethodType_info.
@@ -285,9 +412,16 @@ type MethodTypeInfo struct {
 	DescriptorIndex uint16
 }
 
-func (m *MethodTypeInfo) Read(b []byte, s int) (next int) {
-	m.DescriptorIndex, next = u16(b, s)
-	return
+func (m *MethodTypeInfo) Read(r *reader) {
+	m.DescriptorIndex = r.u16()
+}
+
+func (m *MethodTypeInfo) Write(w *writer) {
+	w.u16(m.DescriptorIndex)
+}
+
+func (m *MethodTypeInfo) ParseDescriptorFromPool(cp []ConstantInfo) (desc string, err error) {
+	return ui2string(cp, m.DescriptorIndex)
 }
 
 // InvokeDynamicInfo CONSTANT_InvokeDynamic_info.
@@ -297,8 +431,57 @@ type InvokeDynamicInfo struct {
 	NameAndTypeIndex         uint16
 }
 
-func (m *InvokeDynamicInfo) Read(b []byte, s int) (next int) {
-	m.BootstrapMethodAttrIndex, next = u16(b, s)
-	m.NameAndTypeIndex, next = u16(b, next)
-	return
+func (m *InvokeDynamicInfo) Read(r *reader) {
+	m.BootstrapMethodAttrIndex = r.u16()
+	m.NameAndTypeIndex = r.u16()
+}
+
+func (m *InvokeDynamicInfo) Write(w *writer) {
+	w.u16(m.BootstrapMethodAttrIndex)
+	w.u16(m.NameAndTypeIndex)
+}
+
+func (m *InvokeDynamicInfo) ParseNameAndTypeFromPool(cp []ConstantInfo) (name, desc string, err error) {
+	return nameAndTypeFromPool(cp, m.NameAndTypeIndex)
+}
+
+// DynamicInfo CONSTANT_Dynamic_info.
+type DynamicInfo struct {
+	InvokeDynamicInfo
+}
+
+// ModuleInfo CONSTANT_Module_info.
+type ModuleInfo struct {
+	Tag
+	NameIndex uint16
+}
+
+func (m *ModuleInfo) Read(r *reader) {
+	m.NameIndex = r.u16()
+}
+
+func (m *ModuleInfo) Write(w *writer) {
+	w.u16(m.NameIndex)
+}
+
+func (m *ModuleInfo) ParseNameFromPool(cp []ConstantInfo) (name string, err error) {
+	return ui2string(cp, m.NameIndex)
+}
+
+// PackageInfo CONSTANT_Package_info, the name is in internal form.
+type PackageInfo struct {
+	ModuleInfo
+}
+
+func nameAndTypeFromPool(cp []ConstantInfo, i uint16) (name, desc string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(cp, i); err != nil {
+		return
+	}
+	n, ok := c.(*NameAndType)
+	if !ok {
+		err = mismatch(i, c, "NameAndType")
+		return
+	}
+	return n.ParseFromPool(cp)
 }
@@ -0,0 +1,270 @@
+package class
+
+import (
+	"fmt"
+)
+
+// ConstantError invalid reference to a constant pool entry.
+type ConstantError struct {
+	Index uint16
+	Msg   string
+}
+
+func (e *ConstantError) Error() string {
+	return fmt.Sprintf("class: constant pool index %d: %s", e.Index, e.Msg)
+}
+
+// entryFromPool returns the entry at index i, an error if i is 0, out of
+// range or the unusable slot after a Long or Double.
+func entryFromPool(cp []ConstantInfo, i uint16) (res ConstantInfo, err error) {
+	switch {
+	case i == 0:
+		return nil, &ConstantError{Index: i, Msg: "index 0 is not usable"}
+	case int(i) >= len(cp):
+		return nil, &ConstantError{Index: i, Msg: fmt.Sprintf("out of range 1-%d", len(cp)-1)}
+	case cp[i] == nil:
+		if p := cp[i-1]; p != nil && (p.T() == _long || p.T() == _double) {
+			return nil, &ConstantError{Index: i, Msg: fmt.Sprintf("unusable slot after %s #%d", p.TN(), i-1)}
+		}
+		return nil, &ConstantError{Index: i, Msg: "no entry"}
+	}
+	return cp[i], nil
+}
+
+// mismatch returns the error of an entry of another kind than expected.
+func mismatch(i uint16, c ConstantInfo, expected string) error {
+	return &ConstantError{Index: i, Msg: fmt.Sprintf("%s entry, expected %s", c.TN(), expected)}
+}
+
+// ConstantPool constant pool of a class file with typed, bounds-checked
+// accessors, decoded strings are cached. A ConstantPool is not safe for
+// concurrent use.
+type ConstantPool struct {
+	cp      []ConstantInfo
+	strings []*string
+}
+
+// NewConstantPool returns the constant pool of the entries cp, as in
+// ClassFile.CpInfo.
+func NewConstantPool(cp []ConstantInfo) *ConstantPool {
+	return &ConstantPool{cp: cp, strings: make([]*string, len(cp))}
+}
+
+// ConstantPool returns the constant pool of the class file, later changes of
+// CpInfo require a new one.
+func (m *ClassFile) ConstantPool() *ConstantPool {
+	return NewConstantPool(m.CpInfo)
+}
+
+// Len returns constant_pool_count, valid indices are 1 to Len()-1.
+func (m *ConstantPool) Len() int {
+	return len(m.cp)
+}
+
+// Infos returns the entries of the pool, index 0 and unusable slots are nil.
+func (m *ConstantPool) Infos() []ConstantInfo {
+	return m.cp
+}
+
+// Entry returns the entry at index i.
+func (m *ConstantPool) Entry(i uint16) (ConstantInfo, error) {
+	return entryFromPool(m.cp, i)
+}
+
+// Utf8 returns the string of the CONSTANT_Utf8_info at index i.
+func (m *ConstantPool) Utf8(i uint16) (res string, err error) {
+	if int(i) < len(m.strings) && m.strings[i] != nil {
+		return *m.strings[i], nil
+	}
+	if res, err = ui2string(m.cp, i); err != nil {
+		return
+	}
+	m.strings[i] = &res
+	return
+}
+
+// Class returns the internal name of the CONSTANT_Class_info at index i,
+// e.g. java/lang/Object.
+func (m *ConstantPool) Class(i uint16) (res string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*ClassInfo)
+	if !ok {
+		return "", mismatch(i, c, "Class")
+	}
+	return m.Utf8(u.NameIndex)
+}
+
+// String returns the value of the CONSTANT_String_info at index i.
+func (m *ConstantPool) String(i uint16) (res string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*StringInfo)
+	if !ok {
+		return "", mismatch(i, c, "String")
+	}
+	return m.Utf8(u.StringIndex)
+}
+
+// NameAndType returns the name and descriptor of the
+// CONSTANT_NameAndType_info at index i.
+func (m *ConstantPool) NameAndType(i uint16) (name, desc string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*NameAndType)
+	if !ok {
+		err = mismatch(i, c, "NameAndType")
+		return
+	}
+	if name, err = m.Utf8(u.NameIndex); err != nil {
+		return
+	}
+	desc, err = m.Utf8(u.DescriptorIndex)
+	return
+}
+
+// MemberRef resolved CONSTANT_Fieldref_info, CONSTANT_Methodref_info or
+// CONSTANT_InterfaceMethodref_info, Class in internal form.
+type MemberRef struct {
+	Tag        uint8
+	Class      string
+	Name       string
+	Descriptor string
+}
+
+// IsField reports whether the reference is a Fieldref.
+func (m *MemberRef) IsField() bool {
+	return m.Tag == _fieldRef
+}
+
+// IsInterface reports whether the reference is an InterfaceMethodref.
+func (m *MemberRef) IsInterface() bool {
+	return m.Tag == _interfaceMethodRef
+}
+
+// MemberRef returns the field or method reference at index i.
+func (m *ConstantPool) MemberRef(i uint16) (res *MemberRef, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	var u *FieldRefInfo
+	switch v := c.(type) {
+	case *FieldRefInfo:
+		u = v
+	case *MethodRefInfo:
+		u = &v.FieldRefInfo
+	case *InterfaceMethodRefInfo:
+		u = &v.FieldRefInfo
+	default:
+		return nil, mismatch(i, c, "Fieldref, Methodref or InterfaceMethodref")
+	}
+	res = &MemberRef{Tag: c.T()}
+	if res.Class, err = m.Class(u.ClassIndex); err != nil {
+		return nil, err
+	}
+	if res.Name, res.Descriptor, err = m.NameAndType(u.NameAndTypeIndex); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Integer returns the value of the CONSTANT_Integer_info at index i.
+func (m *ConstantPool) Integer(i uint16) (res int32, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*IntegerInfo)
+	if !ok {
+		return 0, mismatch(i, c, "Integer")
+	}
+	return u.Int32(), nil
+}
+
+// Float returns the value of the CONSTANT_Float_info at index i.
+func (m *ConstantPool) Float(i uint16) (res float32, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*FloatInfo)
+	if !ok {
+		return 0, mismatch(i, c, "Float")
+	}
+	return u.Float32(), nil
+}
+
+// Long returns the value of the CONSTANT_Long_info at index i.
+func (m *ConstantPool) Long(i uint16) (res int64, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*LongInfo)
+	if !ok {
+		return 0, mismatch(i, c, "Long")
+	}
+	return u.Int64(), nil
+}
+
+// Double returns the value of the CONSTANT_Double_info at index i.
+func (m *ConstantPool) Double(i uint16) (res float64, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*DoubleInfo)
+	if !ok {
+		return 0, mismatch(i, c, "Double")
+	}
+	return u.Float64(), nil
+}
+
+// MethodType returns the descriptor of the CONSTANT_MethodType_info at index
+// i.
+func (m *ConstantPool) MethodType(i uint16) (res string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*MethodTypeInfo)
+	if !ok {
+		return "", mismatch(i, c, "MethodType")
+	}
+	return m.Utf8(u.DescriptorIndex)
+}
+
+// MethodHandle returns the reference kind and the resolved reference of the
+// CONSTANT_MethodHandle_info at index i.
+func (m *ConstantPool) MethodHandle(i uint16) (kind uint8, ref *MemberRef, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(m.cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*MethodHandle)
+	if !ok {
+		err = mismatch(i, c, "MethodHandle")
+		return
+	}
+	if ref, err = m.MemberRef(u.ReferenceIndex); err != nil {
+		return
+	}
+	return u.ReferenceKind, ref, nil
+}
+
+// Module returns the name of the CONSTANT_Module_info at index i.
+func (m *ConstantPool) Module(i uint16) (string, error) {
+	return moduleNameFromPool(m.cp, i)
+}
+
+// Package returns the internal name of the CONSTANT_Package_info at index i.
+func (m *ConstantPool) Package(i uint16) (string, error) {
+	return packageNameFromPool(m.cp, i)
+}
@@ -0,0 +1,68 @@
+package class
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConstantPool(t *testing.T) {
+	cp := NewConstantPool([]ConstantInfo{
+		nil,
+		&ClassInfo{Tag: Tag{_class}, NameIndex: 2},
+		utf8Info("java/lang/Object"),
+		&MethodRefInfo{FieldRefInfo{Tag: Tag{_methodRef}, ClassIndex: 1, NameAndTypeIndex: 4}},
+		&NameAndType{Tag: Tag{_nameAndType}, NameIndex: 5, DescriptorIndex: 6},
+		utf8Info("<init>"),
+		utf8Info("()V"),
+		&LongInfo{Tag: Tag{_long}, HighBytes: 0xFFFFFFFF, LowBytes: 0xFFFFFFFE},
+		nil,
+		&IntegerInfo{Tag: Tag{_integer}, Bytes: 0xFFFFFFFF},
+		&ClassInfo{Tag: Tag{_class}, NameIndex: 42},
+	})
+	name, err := cp.Class(1)
+	if err != nil || name != "java/lang/Object" {
+		t.Errorf("failed to resolve class, name(%s) error(%v)", name, err)
+		t.FailNow()
+	}
+	ref, err := cp.MemberRef(3)
+	if err != nil {
+		t.Errorf("failed to resolve member ref, error(%v)", err)
+		t.FailNow()
+	}
+	if *ref != (MemberRef{Tag: _methodRef, Class: "java/lang/Object", Name: "<init>", Descriptor: "()V"}) {
+		t.Errorf("unexpected member ref %+v", *ref)
+	}
+	if v, err := cp.Long(7); err != nil || v != -2 {
+		t.Errorf("unexpected long %d, error(%v)", v, err)
+	}
+	if v, err := cp.Integer(9); err != nil || v != -1 {
+		t.Errorf("unexpected int %d, error(%v)", v, err)
+	}
+	// the cached string survives changes of the entry
+	cp.Infos()[2] = utf8Info("java/lang/String")
+	if name, _ = cp.Utf8(2); name != "java/lang/Object" {
+		t.Errorf("expected cached string, got %s", name)
+	}
+	for _, c := range []struct {
+		f   func() error
+		msg string
+	}{
+		{func() error { _, err := cp.Utf8(0); return err }, "class: constant pool index 0: index 0 is not usable"},
+		{func() error { _, err := cp.Utf8(11); return err }, "class: constant pool index 11: out of range 1-10"},
+		{func() error { _, err := cp.Double(8); return err }, "class: constant pool index 8: unusable slot after Long #7"},
+		{func() error { _, err := cp.Class(2); return err }, "class: constant pool index 2: Utf8 entry, expected Class"},
+		{func() error { _, err := cp.Class(10); return err }, "class: constant pool index 42: out of range 1-10"},
+		{func() error { _, err := cp.MemberRef(4); return err }, "class: constant pool index 4: NameAndType entry, expected Fieldref, Methodref or InterfaceMethodref"},
+	} {
+		err := c.f()
+		var ce *ConstantError
+		if !errors.As(err, &ce) || err.Error() != c.msg {
+			t.Errorf("expected error %q, got %v", c.msg, err)
+		}
+	}
+	// helpers used by the formatter report bad indices instead of panicking
+	cf := &ClassFile{Magic: 0xCAFEBABE, MajorVersion: 52, CpInfo: cp.Infos(), ThisClass: 11, SuperClass: 8}
+	if _, err = cf.Format(); err == nil {
+		t.Errorf("expected an error for a bad this_class")
+	}
+}
@@ -0,0 +1,87 @@
+package class
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewConstantInfo(t *testing.T) {
+	cases := []struct {
+		b []byte
+		t ConstantInfo
+	}{
+		{[]byte{_dynamic, 0, 1, 0, 2}, &DynamicInfo{}},
+		{[]byte{_module, 0, 3}, &ModuleInfo{}},
+		{[]byte{_package, 0, 4}, &PackageInfo{}},
+	}
+	for _, c := range cases {
+		r := newReader(c.b)
+		res := NewConstantInfo(r)
+		if r.err != nil {
+			t.Errorf("failed to read tag %d, error(%v)", c.b[0], r.err)
+			continue
+		}
+		if reflect.TypeOf(res) != reflect.TypeOf(c.t) {
+			t.Errorf("unexpected type %T, expected %T", res, c.t)
+		}
+		if res.T() != c.b[0] || res.TN() != _tm[c.b[0]] {
+			t.Errorf("unexpected tag %d(%s), expected %d", res.T(), res.TN(), c.b[0])
+		}
+		b := new(bytes.Buffer)
+		w := newWriter(b)
+		w.u8(res.T())
+		res.Write(w)
+		if !bytes.Equal(b.Bytes(), c.b) {
+			t.Errorf("unexpected bytes %v, expected %v", b.Bytes(), c.b)
+		}
+	}
+}
+
+func TestNumericConstants(t *testing.T) {
+	i := &IntegerInfo{Tag: Tag{_integer}, Bytes: 0x80000000}
+	if i.Int32() != math.MinInt32 || i.Literal() != "-2147483648" {
+		t.Errorf("unexpected int %d(%s)", i.Int32(), i.Literal())
+	}
+	l := &LongInfo{Tag: Tag{_long}, LowBytes: 123}
+	if l.Int64() != 123 || l.Literal() != "123l" {
+		t.Errorf("unexpected long %d(%s)", l.Int64(), l.Literal())
+	}
+	floats := []struct {
+		bits uint32
+		s    string
+	}{
+		{0x3F800000, "1.0f"},
+		{0x80000000, "-0.0f"},
+		{0x7F800000, "Infinityf"},
+		{0x7FC00001, "NaNf"},
+	}
+	for _, c := range floats {
+		f := &FloatInfo{IntegerInfo{Tag: Tag{_float}, Bytes: c.bits}}
+		if s := f.Literal(); s != c.s {
+			t.Errorf("expected %s, got %s", c.s, s)
+		}
+		if b := math.Float32bits(f.Float32()); b != c.bits {
+			t.Errorf("expected bits %#x, got %#x", c.bits, b)
+		}
+	}
+	doubles := []struct {
+		high, low uint32
+		s         string
+	}{
+		{0x3FF00000, 0, "1.0d"},
+		{0x80000000, 0, "-0.0d"},
+		{0xFFF00000, 0, "-Infinityd"},
+		{0x7FF00000, 1, "NaNd"},
+	}
+	for _, c := range doubles {
+		d := &DoubleInfo{LongInfo{Tag: Tag{_double}, HighBytes: c.high, LowBytes: c.low}}
+		if s := d.Literal(); s != c.s {
+			t.Errorf("expected %s, got %s", c.s, s)
+		}
+		if b := math.Float64bits(d.Float64()); b != uint64(c.high)<<32|uint64(c.low) {
+			t.Errorf("unexpected bits %#x", b)
+		}
+	}
+}
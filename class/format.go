@@ -0,0 +1,1080 @@
+package class
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/descriptor"
+	"github.com/wucongyou/go-jvm/signature"
+)
+
+var (
+	_escaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r", "\b", "\\b", "\f", "\\f", "\"", "\\\"", "'", "\\'")
+
+	_refKinds = map[uint8]string{
+		1: "REF_getField",
+		2: "REF_getStatic",
+		3: "REF_putField",
+		4: "REF_putStatic",
+		5: "REF_invokeVirtual",
+		6: "REF_invokeStatic",
+		7: "REF_invokeSpecial",
+		8: "REF_newInvokeSpecial",
+		9: "REF_invokeInterface",
+	}
+)
+
+// CodePrinter prints the instructions of a Code attribute for FormatCode,
+// every line prefixed with indent. thisClass is the internal name of the
+// class.
+type CodePrinter func(w io.Writer, code []byte, cp []ConstantInfo, thisClass, indent string) error
+
+// Format returns the class file in the format of javap -v, without the
+// leading lines about the file it was read from. The instructions of methods
+// are listed as hex bytes, FormatCode lists them like javap.
+func (m *ClassFile) Format() (res string, err error) {
+	return m.FormatCode(nil)
+}
+
+// FormatCode is Format with the instructions of methods printed by p, e.g.
+// bytecode.PrintCode, or as hex bytes if p is nil.
+func (m *ClassFile) FormatCode(p CodePrinter) (res string, err error) {
+	f := &formatter{cf: m, pool: m.ConstantPool(), printer: p}
+	if err = f.format(); err != nil {
+		return
+	}
+	return f.b.String(), nil
+}
+
+type formatter struct {
+	cf        *ClassFile
+	pool      *ConstantPool
+	printer   CodePrinter
+	b         bytes.Buffer
+	thisClass string
+}
+
+func (f *formatter) printf(format string, a ...interface{}) {
+	f.b.WriteString(fmt.Sprintf(format, a...))
+}
+
+// printc prints s padded to width followed by a // comment.
+func (f *formatter) printc(indent, s string, width int, cm string) {
+	f.printf("%s%s// %s\n", indent, PadColumn(s, width), cm)
+}
+
+// PadColumn pads s with spaces to width, with at least one space, like javap
+// pads its columns.
+func PadColumn(s string, width int) string {
+	if len(s) >= width {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+// RefKindName returns the name of a method handle reference kind, e.g.
+// REF_invokeStatic, empty for an invalid kind.
+func RefKindName(kind uint8) string {
+	return _refKinds[kind]
+}
+
+// Escape escapes s like javap prints string constants.
+func Escape(s string) string {
+	return _escaper.Replace(s)
+}
+
+func (f *formatter) format() (err error) {
+	m := f.cf
+	if f.thisClass, err = f.className(m.ThisClass); err != nil {
+		return
+	}
+	if a, ok := m.Attribute(_sourceFile).(*SourceFileAttribute); ok {
+		var sf string
+		if sf, err = a.ParseSourceFileFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		f.printf("  Compiled from \"%s\"\n", sf)
+	}
+	if err = f.classDecl(); err != nil {
+		return
+	}
+	f.printf("  minor version: %d\n", m.MinorVersion)
+	f.printf("  major version: %d\n", m.MajorVersion)
+	f.printf("  flags: (0x%04x) %s\n", m.AccessFlags, strings.Join(ClassAccessFlagNames(m.AccessFlags), ", "))
+	f.printc("  ", fmt.Sprintf("this_class: #%d", m.ThisClass), 40, f.thisClass)
+	if m.SuperClass != 0 {
+		var sc string
+		if sc, err = f.className(m.SuperClass); err != nil {
+			return
+		}
+		f.printc("  ", fmt.Sprintf("super_class: #%d", m.SuperClass), 40, sc)
+	} else {
+		f.printf("  super_class: #0\n")
+	}
+	f.printf("  interfaces: %d, fields: %d, methods: %d, attributes: %d\n",
+		len(m.Interfaces), len(m.Fields), len(m.Methods), len(m.Attributes))
+
+	if err = f.constantPool(); err != nil {
+		return
+	}
+
+	f.printf("{\n")
+	first := true
+	for _, fi := range m.Fields {
+		if !first {
+			f.printf("\n")
+		}
+		first = false
+		if err = f.field(fi); err != nil {
+			return
+		}
+	}
+	for _, mi := range m.Methods {
+		if !first {
+			f.printf("\n")
+		}
+		first = false
+		if err = f.method(mi); err != nil {
+			return
+		}
+	}
+	f.printf("}\n")
+	return f.attributes(m.Attributes, "")
+}
+
+func (f *formatter) utf8(i uint16) (string, error) {
+	return f.pool.Utf8(i)
+}
+
+func (f *formatter) className(i uint16) (name string, err error) {
+	return f.pool.Class(i)
+}
+
+func (f *formatter) classDecl() (err error) {
+	m := f.cf
+	if a, ok := m.Attribute(_moduleAttribute).(*ModuleAttribute); ok && m.AccessFlags&_cAccModule != 0 {
+		var md *Module
+		if md, err = a.ParseFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		decl := "module " + md.Name
+		if md.Flags.Has(AccOpen) {
+			decl = "open " + decl
+		}
+		if md.Version != "" {
+			decl += "@" + md.Version
+		}
+		f.printf("%s\n", decl)
+		return
+	}
+	mods := make([]string, 0)
+	kind := "class"
+	if m.AccessFlags&_cAccInterface != 0 {
+		kind = "interface"
+	}
+	if m.AccessFlags&_cAccPublic != 0 {
+		mods = append(mods, "public")
+	}
+	if m.AccessFlags&_cAccFinal != 0 {
+		mods = append(mods, "final")
+	}
+	if m.AccessFlags&_cAccAbstract != 0 && kind == "class" {
+		mods = append(mods, "abstract")
+	}
+	mods = append(mods, kind, javaName(f.thisClass))
+	decl := strings.Join(mods, " ")
+
+	var sig string
+	if sig, err = f.signature(m.Attributes); err != nil {
+		return
+	}
+	if sig != "" {
+		var cs *signature.Class
+		if cs, err = signature.ParseClass(sig); err != nil {
+			return
+		}
+		decl += javaTypeParameters(cs)
+		if kind == "interface" {
+			if len(cs.Interfaces) > 0 {
+				is := make([]string, len(cs.Interfaces))
+				for i, t := range cs.Interfaces {
+					is[i] = t.Java()
+				}
+				decl += " extends " + strings.Join(is, ", ")
+			}
+		} else {
+			decl += " " + strings.TrimPrefix(cs.Java(), javaTypeParameters(cs)+" ")
+		}
+		f.printf("%s\n", decl)
+		return
+	}
+
+	if kind == "class" && m.SuperClass != 0 {
+		var sc string
+		if sc, err = f.className(m.SuperClass); err != nil {
+			return
+		}
+		if sc != "java/lang/Object" {
+			decl += " extends " + javaName(sc)
+		}
+	}
+	if len(m.Interfaces) > 0 {
+		is := make([]string, len(m.Interfaces))
+		for i, c := range m.Interfaces {
+			var n string
+			if n, err = f.className(c.NameIndex); err != nil {
+				return
+			}
+			is[i] = javaName(n)
+		}
+		if kind == "interface" {
+			decl += " extends " + strings.Join(is, ",")
+		} else {
+			decl += " implements " + strings.Join(is, ",")
+		}
+	}
+	f.printf("%s\n", decl)
+	return
+}
+
+// javaTypeParameters returns the type parameters of a class signature in
+// Java syntax.
+func javaTypeParameters(cs *signature.Class) string {
+	if len(cs.TypeParameters) == 0 {
+		return ""
+	}
+	ps := make([]string, len(cs.TypeParameters))
+	for i, p := range cs.TypeParameters {
+		ps[i] = p.Java()
+	}
+	return "<" + strings.Join(ps, ", ") + ">"
+}
+
+// signature returns the Signature attribute value, empty if absent.
+func (f *formatter) signature(as []*AttributeInfo) (res string, err error) {
+	if a, ok := findAttribute(as, _signature).(*SignatureAttribute); ok {
+		return a.ParseSignatureFromPool(f.pool.Infos())
+	}
+	return
+}
+
+func javaName(n string) string {
+	return strings.Replace(n, "/", ".", -1)
+}
+
+// quoteClass quotes an array class name like javap does.
+func quoteClass(n string) string {
+	if strings.HasPrefix(n, "[") {
+		return "\"" + n + "\""
+	}
+	return n
+}
+
+// quoteMember quotes a special method name like javap does.
+func quoteMember(n string) string {
+	if strings.HasPrefix(n, "<") {
+		return "\"" + n + "\""
+	}
+	return n
+}
+
+func (f *formatter) constantPool() (err error) {
+	f.printf("Constant pool:\n")
+	for i := 1; i < f.pool.Len(); i++ {
+		c := f.pool.Infos()[i]
+		if c == nil {
+			continue
+		}
+		var v, cm string
+		if v, cm, err = f.constant(uint16(i)); err != nil {
+			return
+		}
+		if cm == "" {
+			f.printf("%5s = %-18s %s\n", fmt.Sprintf("#%d", i), c.TN(), v)
+		} else {
+			f.printf("%5s = %-18s %-14s // %s\n", fmt.Sprintf("#%d", i), c.TN(), v, cm)
+		}
+	}
+	return
+}
+
+// constant returns the value column and the comment of a constant pool entry
+// like javap -v prints it.
+func (f *formatter) constant(i uint16) (v, cm string, err error) {
+	var c ConstantInfo
+	if c, err = f.pool.Entry(i); err != nil {
+		return
+	}
+	switch u := c.(type) {
+	case *ClassInfo:
+		v = fmt.Sprintf("#%d", u.NameIndex)
+		if cm, err = u.ParseNameFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		cm = quoteClass(cm)
+	case *FieldRefInfo:
+		v = fmt.Sprintf("#%d.#%d", u.ClassIndex, u.NameAndTypeIndex)
+		cm, err = f.memberRef(u)
+	case *MethodRefInfo:
+		v = fmt.Sprintf("#%d.#%d", u.ClassIndex, u.NameAndTypeIndex)
+		cm, err = f.memberRef(&u.FieldRefInfo)
+	case *InterfaceMethodRefInfo:
+		v = fmt.Sprintf("#%d.#%d", u.ClassIndex, u.NameAndTypeIndex)
+		cm, err = f.memberRef(&u.FieldRefInfo)
+	case *StringInfo:
+		v = fmt.Sprintf("#%d", u.StringIndex)
+		if cm, err = u.ParseStringFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		cm = _escaper.Replace(cm)
+	case *IntegerInfo:
+		v = u.Literal()
+	case *FloatInfo:
+		v = u.Literal()
+	case *LongInfo:
+		v = u.Literal()
+	case *DoubleInfo:
+		v = u.Literal()
+	case *NameAndType:
+		v = fmt.Sprintf("#%d:#%d", u.NameIndex, u.DescriptorIndex)
+		var name, desc string
+		if name, desc, err = u.ParseFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		cm = fmt.Sprintf("%s:%s", quoteMember(name), desc)
+	case *Utf8Info:
+		if v, err = u2string(u.Bytes); err != nil {
+			return
+		}
+		v = _escaper.Replace(v)
+	case *MethodHandle:
+		v = fmt.Sprintf("%d:#%d", u.ReferenceKind, u.ReferenceIndex)
+		var rcm string
+		if _, rcm, err = f.constant(u.ReferenceIndex); err != nil {
+			return
+		}
+		cm = fmt.Sprintf("%s %s", _refKinds[u.ReferenceKind], rcm)
+	case *MethodTypeInfo:
+		v = fmt.Sprintf("#%d", u.DescriptorIndex)
+		if cm, err = f.utf8(u.DescriptorIndex); err != nil {
+			return
+		}
+		cm = " " + cm
+	case *DynamicInfo:
+		v = fmt.Sprintf("#%d:#%d", u.BootstrapMethodAttrIndex, u.NameAndTypeIndex)
+		var name, desc string
+		if name, desc, err = u.ParseNameAndTypeFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		cm = fmt.Sprintf("#%d:%s:%s", u.BootstrapMethodAttrIndex, quoteMember(name), desc)
+	case *InvokeDynamicInfo:
+		v = fmt.Sprintf("#%d:#%d", u.BootstrapMethodAttrIndex, u.NameAndTypeIndex)
+		var name, desc string
+		if name, desc, err = u.ParseNameAndTypeFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		cm = fmt.Sprintf("#%d:%s:%s", u.BootstrapMethodAttrIndex, quoteMember(name), desc)
+	case *ModuleInfo:
+		v = fmt.Sprintf("#%d", u.NameIndex)
+		cm, err = u.ParseNameFromPool(f.pool.Infos())
+	case *PackageInfo:
+		v = fmt.Sprintf("#%d", u.NameIndex)
+		cm, err = u.ParseNameFromPool(f.pool.Infos())
+	}
+	return
+}
+
+func (f *formatter) memberRef(u *FieldRefInfo) (res string, err error) {
+	var class, name, desc string
+	if class, err = u.ParseClassFromPool(f.pool.Infos()); err != nil {
+		return
+	}
+	if name, desc, err = u.ParseNameAndTypeFromPool(f.pool.Infos()); err != nil {
+		return
+	}
+	return fmt.Sprintf("%s.%s:%s", quoteClass(class), quoteMember(name), desc), nil
+}
+
+// constantValue returns a loadable constant like javap prints a
+// ConstantValue attribute, e.g. int 5 or String foo.
+func (f *formatter) constantValue(i uint16) (res string, err error) {
+	var v, cm string
+	if v, cm, err = f.constant(i); err != nil {
+		return
+	}
+	switch f.pool.Infos()[i].(type) {
+	case *IntegerInfo:
+		res = "int " + v
+	case *FloatInfo:
+		res = "float " + v
+	case *LongInfo:
+		res = "long " + v
+	case *DoubleInfo:
+		res = "double " + v
+	case *StringInfo:
+		res = "String " + cm
+	default:
+		res = f.pool.Infos()[i].TN() + " " + v
+	}
+	return
+}
+
+func (f *formatter) field(fi *FieldInfo) (err error) {
+	var name, desc, sig string
+	if name, err = f.utf8(fi.NameIndex); err != nil {
+		return
+	}
+	if desc, err = f.utf8(fi.DescriptorIndex); err != nil {
+		return
+	}
+	if sig, err = f.signature(fi.Attributes); err != nil {
+		return
+	}
+	var typ string
+	if sig != "" {
+		var t signature.JavaType
+		if t, err = signature.ParseField(sig); err != nil {
+			return
+		}
+		typ = t.Java()
+	} else {
+		var t *descriptor.Type
+		if t, err = descriptor.ParseField(desc); err != nil {
+			return
+		}
+		typ = t.Java()
+	}
+	mods := modifiers(fi.AccessFlags, _fModifiers, _fModifierNames)
+	f.printf("  %s%s %s;\n", mods, typ, name)
+	f.printf("    descriptor: %s\n", desc)
+	f.printf("    flags: (0x%04x) %s\n", fi.AccessFlags, strings.Join(FieldAccessFlagNames(fi.AccessFlags), ", "))
+	return f.attributes(fi.Attributes, "    ")
+}
+
+var (
+	// Java modifiers in the order javap prints them
+	_fModifiers     = []uint16{_fAccPublic, _fAccPrivate, _fAccProtected, _fAccStatic, _fAccFinal, _fAccVolatile, _fAccTransient}
+	_fModifierNames = map[uint16]string{
+		_fAccPublic:    "public",
+		_fAccPrivate:   "private",
+		_fAccProtected: "protected",
+		_fAccStatic:    "static",
+		_fAccFinal:     "final",
+		_fAccVolatile:  "volatile",
+		_fAccTransient: "transient",
+	}
+	_mModifiers     = []uint16{_mAccPublic, _mAccPrivate, _mAccProtected, _mAccStatic, _mAccFinal, _mAccSynchronized, _mAccNative, _mAccAbstract}
+	_mModifierNames = map[uint16]string{
+		_mAccPublic:       "public",
+		_mAccPrivate:      "private",
+		_mAccProtected:    "protected",
+		_mAccStatic:       "static",
+		_mAccFinal:        "final",
+		_mAccSynchronized: "synchronized",
+		_mAccNative:       "native",
+		_mAccAbstract:     "abstract",
+	}
+)
+
+// modifiers returns the Java modifiers of flags fs set in f, followed by a
+// space if not empty.
+func modifiers(f uint16, fs []uint16, names map[uint16]string) string {
+	ms := make([]string, 0, len(fs))
+	for _, fl := range fs {
+		if f&fl != 0 {
+			ms = append(ms, names[fl])
+		}
+	}
+	if len(ms) == 0 {
+		return ""
+	}
+	return strings.Join(ms, " ") + " "
+}
+
+func (f *formatter) method(mi *MethodInfo) (err error) {
+	var name, desc, sig string
+	if name, err = f.utf8(mi.NameIndex); err != nil {
+		return
+	}
+	if desc, err = f.utf8(mi.DescriptorIndex); err != nil {
+		return
+	}
+	if sig, err = f.signature(mi.Attributes); err != nil {
+		return
+	}
+	var throws []string
+	if throws, err = f.exceptions(mi.Attributes); err != nil {
+		return
+	}
+	mods := modifiers(mi.AccessFlags, _mModifiers, _mModifierNames)
+	if f.cf.AccessFlags&_cAccInterface != 0 && mi.AccessFlags&(_mAccAbstract|_mAccStatic|_mAccPrivate) == 0 && name != "<clinit>" {
+		mods += "default "
+	}
+	varargs := mi.AccessFlags&_mAccVarargs != 0
+
+	var tps, ret string
+	var params []string
+	if sig != "" {
+		var ms *signature.Method
+		if ms, err = signature.ParseMethod(sig); err != nil {
+			return
+		}
+		if len(ms.TypeParameters) > 0 {
+			ps := make([]string, len(ms.TypeParameters))
+			for i, p := range ms.TypeParameters {
+				ps[i] = p.Java()
+			}
+			tps = "<" + strings.Join(ps, ", ") + "> "
+		}
+		ret = ms.Return.Java()
+		for _, p := range ms.Params {
+			params = append(params, p.Java())
+		}
+		if len(ms.Throws) > 0 {
+			throws = make([]string, len(ms.Throws))
+			for i, t := range ms.Throws {
+				throws[i] = t.Java()
+			}
+		}
+	} else {
+		var md *descriptor.Method
+		if md, err = descriptor.ParseMethod(desc); err != nil {
+			return
+		}
+		ret = md.Return.Java()
+		for _, p := range md.Params {
+			params = append(params, p.Java())
+		}
+	}
+	if varargs && len(params) > 0 && strings.HasSuffix(params[len(params)-1], "[]") {
+		l := params[len(params)-1]
+		params[len(params)-1] = l[:len(l)-2] + "..."
+	}
+	var decl string
+	switch name {
+	case "<clinit>":
+		decl = "static {}"
+	case "<init>":
+		decl = fmt.Sprintf("%s%s%s(%s)", mods, tps, javaName(f.thisClass), strings.Join(params, ", "))
+	default:
+		decl = fmt.Sprintf("%s%s%s %s(%s)", mods, tps, ret, name, strings.Join(params, ", "))
+	}
+	if len(throws) > 0 {
+		decl += " throws " + strings.Join(throws, ", ")
+	}
+	f.printf("  %s;\n", decl)
+	f.printf("    descriptor: %s\n", desc)
+	f.printf("    flags: (0x%04x) %s\n", mi.AccessFlags, strings.Join(MethodAccessFlagNames(mi.AccessFlags), ", "))
+	for _, a := range mi.Attributes {
+		if c, ok := a.Value.(*CodeAttribute); ok {
+			if err = f.code(mi, c, desc); err != nil {
+				return
+			}
+			continue
+		}
+		if err = f.attribute(a, "    "); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// exceptions returns the Java names of the Exceptions attribute classes.
+func (f *formatter) exceptions(as []*AttributeInfo) (res []string, err error) {
+	a, ok := findAttribute(as, _exceptions).(*ExceptionsAttribute)
+	if !ok {
+		return
+	}
+	for _, i := range a.ExceptionIndexTable {
+		var cn string
+		if cn, err = f.className(i); err != nil {
+			return
+		}
+		res = append(res, javaName(cn))
+	}
+	return
+}
+
+func (f *formatter) code(mi *MethodInfo, c *CodeAttribute, desc string) (err error) {
+	var md *descriptor.Method
+	if md, err = descriptor.ParseMethod(desc); err != nil {
+		return
+	}
+	args := md.ArgSlots()
+	if mi.AccessFlags&_mAccStatic == 0 {
+		args++
+	}
+	f.printf("    Code:\n")
+	f.printf("      stack=%d, locals=%d, args_size=%d\n", c.MaxStack, c.MaxLocals, args)
+	if f.printer != nil {
+		if err = f.printer(&f.b, c.Code, f.pool.Infos(), f.thisClass, "      "); err != nil {
+			return
+		}
+	} else {
+		for i := 0; i < len(c.Code); i += 16 {
+			e := i + 16
+			if e > len(c.Code) {
+				e = len(c.Code)
+			}
+			f.printf("      %4d: % x\n", i, c.Code[i:e])
+		}
+	}
+	if len(c.ExceptionTable) > 0 {
+		f.printf("      Exception table:\n")
+		f.printf("         from    to  target type\n")
+		for _, e := range c.ExceptionTable {
+			t := "any"
+			if e.CatchType != 0 {
+				var cn string
+				if cn, err = f.className(e.CatchType); err != nil {
+					return
+				}
+				t = "Class " + cn
+			}
+			f.printf("         %5d %5d %5d   %s\n", e.StartPc, e.EndPc, e.HandlerPc, t)
+		}
+	}
+	return f.attributes(c.Attributes, "      ")
+}
+
+func (f *formatter) attributes(as []*AttributeInfo, indent string) (err error) {
+	for _, a := range as {
+		if err = f.attribute(a, indent); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (f *formatter) attribute(a *AttributeInfo, indent string) (err error) {
+	n := a.Name
+	if n == "" {
+		if n, err = f.utf8(a.AttributeNameIndex); err != nil {
+			return
+		}
+	}
+	switch u := a.Value.(type) {
+	case *SourceFileAttribute:
+		var sf string
+		if sf, err = u.ParseSourceFileFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		f.printf("%sSourceFile: \"%s\"\n", indent, sf)
+	case *ConstantValueAttribute:
+		var v string
+		if v, err = f.constantValue(u.ConstantValueIndex); err != nil {
+			return
+		}
+		f.printf("%sConstantValue: %s\n", indent, v)
+	case *SignatureAttribute:
+		var s string
+		if s, err = u.ParseSignatureFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		f.printc(indent, fmt.Sprintf("Signature: #%d", u.SignatureIndex), 40-len(indent), s)
+	case *DeprecatedAttribute, *SyntheticAttribute:
+		f.printf("%s%s: true\n", indent, n)
+	case *ExceptionsAttribute:
+		var ts []string
+		if ts, err = f.exceptions([]*AttributeInfo{a}); err != nil {
+			return
+		}
+		f.printf("%sExceptions:\n", indent)
+		f.printf("%s  throws %s\n", indent, strings.Join(ts, ", "))
+	case *LineNumberTableAttribute:
+		f.printf("%s%s:\n", indent, n)
+		for _, l := range u.LineNumberTable {
+			f.printf("%s  line %d: %d\n", indent, l.LineNumber, l.StartPc)
+		}
+	case *LocalVariableTableAttribute:
+		f.printf("%s%s:\n", indent, n)
+		f.printf("%s  Start  Length  Slot  Name   Signature\n", indent)
+		for _, l := range u.LocalVariableTable {
+			if err = f.localVariable(indent, l.StartPc, l.Length, l.Index, l.NameIndex, l.DescriptorIndex); err != nil {
+				return
+			}
+		}
+	case *LocalVariableTypeTableAttribute:
+		f.printf("%s%s:\n", indent, n)
+		f.printf("%s  Start  Length  Slot  Name   Signature\n", indent)
+		for _, l := range u.LocalVariableTypeTable {
+			if err = f.localVariable(indent, l.StartPc, l.Length, l.Index, l.NameIndex, l.SignatureIndex); err != nil {
+				return
+			}
+		}
+	case *InnerClassesAttribute:
+		f.printf("%sInnerClasses:\n", indent)
+		for _, c := range u.Classes {
+			if err = f.innerClass(indent+"  ", c); err != nil {
+				return
+			}
+		}
+	case *EnclosingMethodAttribute:
+		var cm string
+		if cm, err = f.className(u.ClassIndex); err != nil {
+			return
+		}
+		if u.MethodIndex != 0 {
+			var name string
+			if name, _, err = nameAndTypeFromPool(f.pool.Infos(), u.MethodIndex); err != nil {
+				return
+			}
+			cm += "." + name
+		}
+		f.printc(indent, fmt.Sprintf("EnclosingMethod: #%d.#%d", u.ClassIndex, u.MethodIndex), 40-len(indent), cm)
+	case *SourceDebugExtensionAttribute:
+		var s string
+		if s, err = DecodeString(u.DebugExtension); err != nil {
+			return
+		}
+		f.printf("%sSourceDebugExtension:\n", indent)
+		for _, l := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+			f.printf("%s  %s\n", indent, l)
+		}
+	case *BootstrapMethodsAttribute:
+		f.printf("%sBootstrapMethods:\n", indent)
+		for i, b := range u.BootstrapMethods {
+			if err = f.bootstrapMethod(indent+"  ", i, b); err != nil {
+				return
+			}
+		}
+	case *MethodParametersAttribute:
+		f.printf("%sMethodParameters:\n", indent)
+		f.printf("%s  %-30s %s\n", indent, "Name", "Flags")
+		for _, p := range u.Parameters {
+			name := "<no name>"
+			if p.NameIndex != 0 {
+				if name, err = f.utf8(p.NameIndex); err != nil {
+					return
+				}
+			}
+			fs := ParameterFlags(p.AccessFlags).Names()
+			for i, fl := range fs {
+				fs[i] = strings.ToLower(strings.TrimPrefix(fl, "ACC_"))
+			}
+			f.printf("%s\n", strings.TrimRight(fmt.Sprintf("%s  %-30s %s", indent, name, strings.Join(fs, " ")), " "))
+		}
+	case *NestHostAttribute:
+		var cn string
+		if cn, err = f.className(u.HostClassIndex); err != nil {
+			return
+		}
+		f.printf("%sNestHost: class %s\n", indent, cn)
+	case *NestMembersAttribute:
+		err = f.classList(indent, n, u.Classes)
+	case *PermittedSubclassesAttribute:
+		err = f.classList(indent, n, u.Classes)
+	case *RecordAttribute:
+		f.printf("%sRecord:\n", indent)
+		for _, c := range u.Components {
+			if err = f.recordComponent(indent+"  ", c); err != nil {
+				return
+			}
+		}
+	case *RuntimeVisibleAnnotationsAttribute:
+		err = f.annotations(indent, n, u.Annotations)
+	case *RuntimeInvisibleAnnotationsAttribute:
+		err = f.annotations(indent, n, u.Annotations)
+	case *RuntimeVisibleParameterAnnotationsAttribute:
+		err = f.parameterAnnotations(indent, n, u.ParameterAnnotations)
+	case *RuntimeInvisibleParameterAnnotationsAttribute:
+		err = f.parameterAnnotations(indent, n, u.ParameterAnnotations)
+	case *RuntimeVisibleTypeAnnotationsAttribute:
+		err = f.typeAnnotations(indent, n, u.Annotations)
+	case *RuntimeInvisibleTypeAnnotationsAttribute:
+		err = f.typeAnnotations(indent, n, u.Annotations)
+	case *AnnotationDefaultAttribute:
+		var v string
+		if v, err = f.elementValue(u.DefaultValue); err != nil {
+			return
+		}
+		f.printf("%sAnnotationDefault:\n", indent)
+		f.printf("%s  default_value: %s\n", indent, elementValueIndices(u.DefaultValue))
+		f.printf("%s    %s\n", indent, v)
+	case *StackMapTableAttribute:
+		err = f.stackMapTable(indent, u)
+	case *ModuleAttribute:
+		err = f.moduleAttribute(indent, u)
+	case *ModulePackagesAttribute:
+		f.printf("%sModulePackages:\n", indent)
+		for _, i := range u.PackageIndex {
+			var p string
+			if p, err = packageNameFromPool(f.pool.Infos(), i); err != nil {
+				return
+			}
+			f.printc(indent+"  ", fmt.Sprintf("#%d", i), 38-len(indent), p)
+		}
+	case *ModuleMainClassAttribute:
+		var cn string
+		if cn, err = f.className(u.MainClassIndex); err != nil {
+			return
+		}
+		f.printc(indent, fmt.Sprintf("ModuleMainClass: #%d", u.MainClassIndex), 40-len(indent), cn)
+	case AttributeFormatter:
+		var ls []string
+		if ls, err = u.FormatAttribute(f.pool); err != nil {
+			return
+		}
+		f.printf("%s%s:\n", indent, n)
+		for _, l := range ls {
+			f.printf("%s  %s\n", indent, l)
+		}
+	default:
+		f.printf("%s%s: length = 0x%x (unknown attribute)\n", indent, n, len(a.Info))
+		for i := 0; i < len(a.Info); i += 16 {
+			e := i + 16
+			if e > len(a.Info) {
+				e = len(a.Info)
+			}
+			f.printf("%s % x\n", indent, a.Info[i:e])
+		}
+	}
+	return
+}
+
+func (f *formatter) localVariable(indent string, start, length, slot, ni, di uint16) (err error) {
+	var name, desc string
+	if name, err = f.utf8(ni); err != nil {
+		return
+	}
+	if desc, err = f.utf8(di); err != nil {
+		return
+	}
+	f.printf("%s  %5d %7d %5d %5s   %s\n", indent, start, length, slot, name, desc)
+	return
+}
+
+var (
+	// Java modifiers of an inner class in the order javap prints them
+	_iModifiers     = []uint16{_iAccPublic, _iAccPrivate, _iAccProtected, _iAccStatic, _iAccFinal, _iAccAbstract}
+	_iModifierNames = map[uint16]string{
+		_iAccPublic:    "public",
+		_iAccPrivate:   "private",
+		_iAccProtected: "protected",
+		_iAccStatic:    "static",
+		_iAccFinal:     "final",
+		_iAccAbstract:  "abstract",
+	}
+)
+
+// innerClass prints an InnerClasses entry like javap, e.g.
+// public static #7= #2 of #5; // Inner=class Outer$Inner of class Outer.
+func (f *formatter) innerClass(indent string, c *InnerClass) (err error) {
+	fl := c.InnerClassAccessFlags
+	if fl&_iAccInterface != 0 {
+		fl &^= _iAccAbstract
+	}
+	s := modifiers(fl, _iModifiers, _iModifierNames)
+	var inner, outer, name string
+	if inner, err = f.className(c.InnerClassInfoIndex); err != nil {
+		return
+	}
+	cm := "class " + inner
+	if c.InnerNameIndex != 0 {
+		if name, err = f.utf8(c.InnerNameIndex); err != nil {
+			return
+		}
+		s += fmt.Sprintf("#%d= #%d", c.InnerNameIndex, c.InnerClassInfoIndex)
+		cm = name + "=" + cm
+	} else {
+		s += fmt.Sprintf("#%d", c.InnerClassInfoIndex)
+	}
+	if c.OuterClassInfoIndex != 0 {
+		if outer, err = f.className(c.OuterClassInfoIndex); err != nil {
+			return
+		}
+		s += fmt.Sprintf(" of #%d", c.OuterClassInfoIndex)
+		cm += " of class " + outer
+	}
+	f.printc(indent, s+";", 40-len(indent), cm)
+	return
+}
+
+func (f *formatter) bootstrapMethod(indent string, i int, b *BootstrapMethod) (err error) {
+	var cm string
+	if _, cm, err = f.constant(b.BootstrapMethodRef); err != nil {
+		return
+	}
+	f.printf("%s%d: #%d %s\n", indent, i, b.BootstrapMethodRef, cm)
+	f.printf("%s  Method arguments:\n", indent)
+	for _, a := range b.BootstrapArguments {
+		var v string
+		if v, cm, err = f.constant(a); err != nil {
+			return
+		}
+		if cm != "" {
+			v = strings.TrimSpace(cm)
+		}
+		f.printf("%s    #%d %s\n", indent, a, v)
+	}
+	return
+}
+
+// classList prints an attribute holding a list of classes, e.g. NestMembers.
+func (f *formatter) classList(indent, name string, cs []uint16) (err error) {
+	f.printf("%s%s:\n", indent, name)
+	for _, i := range cs {
+		var cn string
+		if cn, err = f.className(i); err != nil {
+			return
+		}
+		f.printf("%s  %s\n", indent, cn)
+	}
+	return
+}
+
+func (f *formatter) recordComponent(indent string, c *RecordComponent) (err error) {
+	var name, desc, sig string
+	if name, err = f.utf8(c.NameIndex); err != nil {
+		return
+	}
+	if desc, err = f.utf8(c.DescriptorIndex); err != nil {
+		return
+	}
+	if sig, err = f.signature(c.Attributes); err != nil {
+		return
+	}
+	var typ string
+	if sig != "" {
+		var t signature.JavaType
+		if t, err = signature.ParseField(sig); err != nil {
+			return
+		}
+		typ = t.Java()
+	} else {
+		var t *descriptor.Type
+		if t, err = descriptor.ParseField(desc); err != nil {
+			return
+		}
+		typ = t.Java()
+	}
+	f.printf("%s%s %s;\n", indent, typ, name)
+	f.printf("%s  descriptor: %s\n", indent, desc)
+	return f.attributes(c.Attributes, indent+"  ")
+}
+
+// stackMapTable prints a StackMapTable attribute like javap.
+func (f *formatter) stackMapTable(indent string, a *StackMapTableAttribute) (err error) {
+	f.printf("%sStackMapTable: number_of_entries = %d\n", indent, len(a.Entries))
+	for _, fr := range a.Entries {
+		f.printf("%s  frame_type = %d /* %s */\n", indent, fr.FrameType, fr.Kind())
+		t := fr.FrameType
+		if t > _sameLocals1StackItemFrameMax {
+			f.printf("%s    offset_delta = %d\n", indent, fr.OffsetDelta)
+		}
+		if len(fr.Locals) > 0 || t == _fullFrame {
+			var ls string
+			if ls, err = f.verificationTypes(fr.Locals); err != nil {
+				return
+			}
+			f.printf("%s    locals = %s\n", indent, ls)
+		}
+		if len(fr.Stack) > 0 || t == _fullFrame {
+			var ss string
+			if ss, err = f.verificationTypes(fr.Stack); err != nil {
+				return
+			}
+			f.printf("%s    stack = %s\n", indent, ss)
+		}
+	}
+	return
+}
+
+func (f *formatter) verificationTypes(vs []*VerificationTypeInfo) (res string, err error) {
+	if len(vs) == 0 {
+		return "[]", nil
+	}
+	ts := make([]string, len(vs))
+	for i, v := range vs {
+		switch v.Tag {
+		case ItemTop:
+			ts[i] = "top"
+		case ItemInteger:
+			ts[i] = "int"
+		case ItemFloat:
+			ts[i] = "float"
+		case ItemDouble:
+			ts[i] = "double"
+		case ItemLong:
+			ts[i] = "long"
+		case ItemNull:
+			ts[i] = "null"
+		case ItemUninitializedThis:
+			ts[i] = "this"
+		case ItemObject:
+			var cn string
+			if cn, err = f.className(v.CpoolIndex); err != nil {
+				return
+			}
+			ts[i] = "class " + quoteClass(cn)
+		case ItemUninitialized:
+			ts[i] = fmt.Sprintf("uninitialized %d", v.Offset)
+		}
+	}
+	return "[ " + strings.Join(ts, ", ") + " ]", nil
+}
+
+// moduleAttribute prints a Module attribute like javap, entries as indices
+// with the resolved names in comments.
+func (f *formatter) moduleAttribute(indent string, a *ModuleAttribute) (err error) {
+	var md *Module
+	if md, err = a.ParseFromPool(f.pool.Infos()); err != nil {
+		return
+	}
+	in := indent + "  "
+	w := 40 - len(in)
+	f.printf("%sModule:\n", indent)
+	f.printc(in, fmt.Sprintf("#%d,%x", a.ModuleNameIndex, a.ModuleFlags), w, strings.TrimSpace(fmt.Sprintf("\"%s\" %s", md.Name, md.Flags)))
+	f.version(in, a.ModuleVersionIndex, md.Version)
+	f.printc(in, strconv.Itoa(len(a.Requires)), w, "requires")
+	for i, q := range a.Requires {
+		r := md.Requires[i]
+		f.printc(in+"  ", fmt.Sprintf("#%d,%x", q.RequiresIndex, q.RequiresFlags), w-2, strings.TrimSpace(fmt.Sprintf("\"%s\" %s", r.Name, r.Flags)))
+		f.version(in+"  ", q.RequiresVersionIndex, r.Version)
+	}
+	f.exports(in, "exports", a.Exports, md.Exports)
+	f.exports(in, "opens", a.Opens, md.Opens)
+	f.printc(in, strconv.Itoa(len(a.UsesIndex)), w, "uses")
+	for i, u := range a.UsesIndex {
+		f.printc(in+"  ", fmt.Sprintf("#%d", u), w-2, "class "+md.Uses[i])
+	}
+	f.printc(in, strconv.Itoa(len(a.Provides)), w, "provides")
+	for i, p := range a.Provides {
+		mp := md.Provides[i]
+		f.printc(in+"  ", fmt.Sprintf("#%d", p.ProvidesIndex), w-2, "class "+mp.Service)
+		f.printc(in+"  ", strconv.Itoa(len(p.ProvidesWithIndex)), w-2, "with")
+		for j, c := range p.ProvidesWithIndex {
+			f.printc(in+"    ", fmt.Sprintf("#%d", c), w-4, "class "+mp.With[j])
+		}
+	}
+	return
+}
+
+// version prints the version index of a module or requires entry, with the
+// version in a comment if recorded.
+func (f *formatter) version(indent string, i uint16, v string) {
+	if i == 0 {
+		f.printf("%s#0\n", indent)
+		return
+	}
+	f.printc(indent, fmt.Sprintf("#%d", i), 40-len(indent), v)
+}
+
+func (f *formatter) exports(indent, name string, es []*Exports, mes []*ModuleExports) {
+	w := 40 - len(indent)
+	f.printc(indent, strconv.Itoa(len(es)), w, name)
+	for i, e := range es {
+		me := mes[i]
+		f.printc(indent+"  ", fmt.Sprintf("#%d,%x", e.ExportsIndex, e.ExportsFlags), w-2, strings.TrimSpace(fmt.Sprintf("package %s %s", me.Package, me.Flags)))
+		if len(e.ExportsToIndex) == 0 {
+			continue
+		}
+		f.printc(indent+"  ", strconv.Itoa(len(e.ExportsToIndex)), w-2, "to")
+		for j, t := range e.ExportsToIndex {
+			f.printc(indent+"    ", fmt.Sprintf("#%d", t), w-4, "\""+me.To[j]+"\"")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package class
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/descriptor"
+)
+
+// annotations prints a Runtime(In)visibleAnnotations attribute like javap,
+// every annotation as indices followed by its resolved form.
+func (f *formatter) annotations(indent, name string, as []*Annotation) (err error) {
+	f.printf("%s%s:\n", indent, name)
+	for i, a := range as {
+		if err = f.annotation(indent+"  ", fmt.Sprintf("%d: ", i), a, ""); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (f *formatter) parameterAnnotations(indent, name string, ps []*ParameterAnnotations) (err error) {
+	f.printf("%s%s:\n", indent, name)
+	for i, p := range ps {
+		f.printf("%s  parameter %d:\n", indent, i)
+		for j, a := range p.Annotations {
+			if err = f.annotation(indent+"    ", fmt.Sprintf("%d: ", j), a, ""); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (f *formatter) typeAnnotations(indent, name string, as []*TypeAnnotation) (err error) {
+	f.printf("%s%s:\n", indent, name)
+	for i, a := range as {
+		if err = f.annotation(indent+"  ", fmt.Sprintf("%d: ", i), &a.Annotation, ": "+a.TargetString()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// annotation prints prefix, the indices of a and suffix on one line and the
+// resolved annotation on the next ones.
+func (f *formatter) annotation(indent, prefix string, a *Annotation, suffix string) (err error) {
+	f.printf("%s%s%s%s\n", indent, prefix, annotationIndices(a), suffix)
+	var t string
+	if t, err = a.ParseTypeFromPool(f.pool.Infos()); err != nil {
+		return
+	}
+	if len(a.ElementValuePairs) == 0 {
+		f.printf("%s  %s\n", indent, javaType(t))
+		return
+	}
+	f.printf("%s  %s(\n", indent, javaType(t))
+	for _, p := range a.ElementValuePairs {
+		var n, v string
+		if n, err = f.utf8(p.ElementNameIndex); err != nil {
+			return
+		}
+		if v, err = f.elementValue(p.Value); err != nil {
+			return
+		}
+		f.printf("%s    %s=%s\n", indent, n, v)
+	}
+	f.printf("%s  )\n", indent)
+	return
+}
+
+func annotationIndices(a *Annotation) string {
+	ps := make([]string, len(a.ElementValuePairs))
+	for i, p := range a.ElementValuePairs {
+		ps[i] = fmt.Sprintf("#%d=%s", p.ElementNameIndex, elementValueIndices(p.Value))
+	}
+	return fmt.Sprintf("#%d(%s)", a.TypeIndex, strings.Join(ps, ","))
+}
+
+func elementValueIndices(v *ElementValue) string {
+	switch v.Tag {
+	case ElementEnum:
+		return fmt.Sprintf("e#%d.#%d", v.TypeNameIndex, v.ConstNameIndex)
+	case ElementClass:
+		return fmt.Sprintf("c#%d", v.ClassInfoIndex)
+	case ElementAnnotation:
+		return "@" + annotationIndices(v.AnnotationValue)
+	case ElementArray:
+		vs := make([]string, len(v.Values))
+		for i, e := range v.Values {
+			vs[i] = elementValueIndices(e)
+		}
+		return "[" + strings.Join(vs, ",") + "]"
+	}
+	return fmt.Sprintf("%c#%d", v.Tag, v.ConstValueIndex)
+}
+
+// elementValue returns v in Java syntax, e.g. "foo", 1l or
+// java.lang.annotation.RetentionPolicy.RUNTIME.
+func (f *formatter) elementValue(v *ElementValue) (res string, err error) {
+	switch v.Tag {
+	case ElementString:
+		if res, err = f.utf8(v.ConstValueIndex); err != nil {
+			return
+		}
+		return "\"" + _escaper.Replace(res) + "\"", nil
+	case ElementEnum:
+		var t, c string
+		if t, err = f.utf8(v.TypeNameIndex); err != nil {
+			return
+		}
+		if c, err = f.utf8(v.ConstNameIndex); err != nil {
+			return
+		}
+		return javaType(t) + "." + c, nil
+	case ElementClass:
+		if res, err = f.utf8(v.ClassInfoIndex); err != nil {
+			return
+		}
+		return javaType(res) + ".class", nil
+	case ElementAnnotation:
+		var t string
+		if t, err = v.AnnotationValue.ParseTypeFromPool(f.pool.Infos()); err != nil {
+			return
+		}
+		ps := make([]string, len(v.AnnotationValue.ElementValuePairs))
+		for i, p := range v.AnnotationValue.ElementValuePairs {
+			var n, e string
+			if n, err = f.utf8(p.ElementNameIndex); err != nil {
+				return
+			}
+			if e, err = f.elementValue(p.Value); err != nil {
+				return
+			}
+			ps[i] = n + "=" + e
+		}
+		return "@" + javaType(t) + "(" + strings.Join(ps, ",") + ")", nil
+	case ElementArray:
+		vs := make([]string, len(v.Values))
+		for i, e := range v.Values {
+			if vs[i], err = f.elementValue(e); err != nil {
+				return
+			}
+		}
+		return "[" + strings.Join(vs, ",") + "]", nil
+	}
+	var c ConstantInfo
+	if c, err = f.pool.Entry(v.ConstValueIndex); err != nil {
+		return
+	}
+	if u, ok := c.(*IntegerInfo); ok {
+		i := int32(u.Bytes)
+		switch v.Tag {
+		case ElementByte:
+			return "(byte)" + strconv.Itoa(int(int8(i))), nil
+		case ElementShort:
+			return "(short)" + strconv.Itoa(int(int16(i))), nil
+		case ElementChar:
+			return strconv.QuoteRuneToASCII(rune(uint16(i))), nil
+		case ElementBoolean:
+			return strconv.FormatBool(i != 0), nil
+		case ElementInt:
+			return strconv.Itoa(int(i)), nil
+		}
+	}
+	switch u := c.(type) {
+	case *LongInfo:
+		if v.Tag == ElementLong {
+			return u.Literal(), nil
+		}
+	case *FloatInfo:
+		if v.Tag == ElementFloat {
+			return u.Literal(), nil
+		}
+	case *DoubleInfo:
+		if v.Tag == ElementDouble {
+			return u.Literal(), nil
+		}
+	}
+	err = fmt.Errorf("element value tag %q doesn't match constant %s", v.Tag, c.TN())
+	return
+}
+
+// javaType returns a field descriptor in Java syntax, the descriptor itself
+// if it's invalid.
+func javaType(desc string) string {
+	if desc == "V" {
+		return "void"
+	}
+	t, err := descriptor.ParseField(desc)
+	if err != nil {
+		return desc
+	}
+	return t.Java()
+}
@@ -0,0 +1,48 @@
+package class_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/bytecode"
+	"github.com/wucongyou/go-jvm/class"
+)
+
+// TestFormatGolden compares the listing of testdata/HelloWorld.class with
+// testdata/HelloWorld.javap, the output of javap -v on it without the leading
+// Classfile, Last modified and SHA-256 lines. It is an external test to list
+// the code with package bytecode.
+func TestFormatGolden(t *testing.T) {
+	cf, err := class.ParseFile("testdata/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	exp, err := ioutil.ReadFile("testdata/HelloWorld.javap")
+	if err != nil {
+		t.Errorf("failed to read golden file, error(%v)", err)
+		t.FailNow()
+	}
+	s, err := cf.FormatCode(bytecode.PrintCode)
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	if s == string(exp) {
+		return
+	}
+	// report the first differing line
+	ls, el := strings.Split(s, "\n"), strings.Split(string(exp), "\n")
+	for i := range el {
+		if i >= len(ls) || ls[i] != el[i] {
+			var got string
+			if i < len(ls) {
+				got = ls[i]
+			}
+			t.Errorf("line %d: expected\n%q\ngot\n%q", i+1, el[i], got)
+			return
+		}
+	}
+	t.Errorf("unexpected trailing lines:\n%s", strings.Join(ls[len(el):], "\n"))
+}
@@ -0,0 +1,53 @@
+package class
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestJavaFloat(t *testing.T) {
+	cases := []struct {
+		f       float64
+		bitSize int
+		s       string
+	}{
+		{1, 64, "1.0"},
+		{-0.5, 64, "-0.5"},
+		{100, 64, "100.0"},
+		{1234567, 64, "1234567.0"},
+		{1e7, 64, "1.0E7"},
+		{0.001, 64, "0.001"},
+		{0.0001, 64, "1.0E-4"},
+		{1.5e300, 64, "1.5E300"},
+		{math.Copysign(0, -1), 64, "-0.0"},
+		{math.NaN(), 64, "NaN"},
+		{math.Inf(-1), 64, "-Infinity"},
+		{float64(float32(0.1)), 32, "0.1"},
+		{float64(float32(3.4028235e38)), 32, "3.4028235E38"},
+		{math.SmallestNonzeroFloat64, 64, "4.9E-324"},
+	}
+	for _, c := range cases {
+		if s := javaFloat(c.f, c.bitSize); s != c.s {
+			t.Errorf("expected %s, got %s", c.s, s)
+		}
+	}
+}
+
+func TestFormatInterfaces(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	cf.CpInfo = append(cf.CpInfo, &Utf8Info{Tag: Tag{_utf8}, Length: 18, Bytes: []byte("java/lang/Runnable")}, &ClassInfo{Tag: Tag{_class}, NameIndex: uint16(len(cf.CpInfo))})
+	cf.Interfaces = []*ClassInfo{{NameIndex: uint16(len(cf.CpInfo) - 1)}}
+	s, err := cf.Format()
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	if exp := "\npublic class HelloWorld implements java.lang.Runnable\n"; !strings.Contains(s, exp) {
+		t.Errorf("expected %q in\n%s", exp, s)
+	}
+}
@@ -3,12 +3,16 @@ package class
 import (
 	"errors"
 	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 var (
 	ErrPartialCharacter = errors.New("malformed input: partial character at end")
 )
 
+// DecodeRunes decodes JVM modified UTF-8, a surrogate pair is recombined into
+// a single supplementary character, an unpaired surrogate is kept as is.
 func DecodeRunes(b []byte) (res []rune, err error) {
 	count := 0
 	cpCount := 0
@@ -57,9 +61,68 @@ func DecodeRunes(b []byte) (res []rune, err error) {
 			return
 		}
 	}
-	res = make([]rune, len(cps))
-	for i := 0; i < len(cps); i++ {
-		res[i] = rune(cps[i])
+	res = make([]rune, 0, cpCount)
+	for i := 0; i < cpCount; i++ {
+		r := rune(cps[i])
+		if utf16.IsSurrogate(r) && i+1 < cpCount {
+			if sr := utf16.DecodeRune(r, rune(cps[i+1])); sr != utf8.RuneError {
+				res = append(res, sr)
+				i++
+				continue
+			}
+		}
+		res = append(res, r)
 	}
 	return
 }
+
+// DecodeString decodes JVM modified UTF-8 into a string, an unpaired surrogate
+// becomes U+FFFD.
+func DecodeString(b []byte) (res string, err error) {
+	var rs []rune
+	if rs, err = DecodeRunes(b); err != nil {
+		return
+	}
+	res = string(rs)
+	return
+}
+
+// EncodeRunes encodes runes into JVM modified UTF-8: null is encoded as
+// 0xC0 0x80 and a supplementary character as the surrogate pair of two 3-byte
+// sequences. An invalid rune is encoded as U+FFFD.
+func EncodeRunes(rs []rune) (res []byte) {
+	res = make([]byte, 0, len(rs))
+	for _, r := range rs {
+		switch {
+		case r < 0 || r > utf8.MaxRune:
+			res = encodeChar(res, utf8.RuneError)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			res = encodeChar(res, r1)
+			res = encodeChar(res, r2)
+		default:
+			res = encodeChar(res, r)
+		}
+	}
+	return
+}
+
+// EncodeString encodes a string into JVM modified UTF-8.
+func EncodeString(s string) (res []byte) {
+	return EncodeRunes([]rune(s))
+}
+
+// encodeChar appends the modified UTF-8 encoding of a 16-bit char.
+func encodeChar(b []byte, c rune) []byte {
+	switch {
+	case c >= 0x01 && c <= 0x7F:
+		// 0xxxxxxx
+		return append(b, byte(c))
+	case c <= 0x7FF:
+		// 110x xxxx   10xx xxxx
+		return append(b, byte(0xC0|(c>>6)&0x1F), byte(0x80|c&0x3F))
+	default:
+		// 1110 xxxx  10xx xxxx  10xx xxxx
+		return append(b, byte(0xE0|(c>>12)&0x0F), byte(0x80|(c>>6)&0x3F), byte(0x80|c&0x3F))
+	}
+}
@@ -0,0 +1,58 @@
+package class
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestEncodeString(t *testing.T) {
+	cases := []struct {
+		s string
+		b []byte
+	}{
+		{"", []byte{}},
+		{"a\x00b", []byte{'a', 0xC0, 0x80, 'b'}},
+		{"é", []byte{0xC3, 0xA9}},
+		{"中", []byte{0xE4, 0xB8, 0xAD}},
+		{"\U0001F600", []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}},
+	}
+	for _, c := range cases {
+		b := EncodeString(c.s)
+		if !bytes.Equal(b, c.b) {
+			t.Errorf("encode %q, expected %x, got %x", c.s, c.b, b)
+		}
+		s, err := DecodeString(b)
+		if err != nil {
+			t.Errorf("decode %x, error(%v)", b, err)
+		}
+		if s != c.s {
+			t.Errorf("decode %x, expected %q, got %q", b, c.s, s)
+		}
+	}
+}
+
+func TestRoundTripUnicode(t *testing.T) {
+	for r := rune(0); r <= 0x10FFFF; r++ {
+		rs := []rune{'x', r, 'y'}
+		b := EncodeRunes(rs)
+		res, err := DecodeRunes(b)
+		if err != nil {
+			t.Fatalf("decode %U, error(%v)", r, err)
+		}
+		if len(res) != 3 || res[1] != r {
+			t.Fatalf("round trip %U, got %U", r, res)
+		}
+		if !utf16.IsSurrogate(r) {
+			s, err := DecodeString(EncodeString(string(r)))
+			if err != nil || s != string(r) {
+				t.Fatalf("round trip string %U, got %q error(%v)", r, s, err)
+			}
+		}
+		for _, c := range b {
+			if c == 0 {
+				t.Fatalf("encoding of %U contains a null byte", r)
+			}
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package class
+
+const (
+	_moduleAttribute = "Module"
+	_modulePackages  = "ModulePackages"
+	_moduleMainClass = "ModuleMainClass"
+)
+
+// ModuleAttribute Module_attribute.
+type ModuleAttribute struct {
+	ModuleNameIndex    uint16
+	ModuleFlags        uint16
+	ModuleVersionIndex uint16
+	RequiresCount      uint16
+	Requires           []*Requires
+	ExportsCount       uint16
+	Exports            []*Exports
+	OpensCount         uint16
+	Opens              []*Exports
+	UsesCount          uint16
+	UsesIndex          []uint16
+	ProvidesCount      uint16
+	Provides           []*Provides
+}
+
+func (m *ModuleAttribute) AttributeName() string {
+	return _moduleAttribute
+}
+
+func (m *ModuleAttribute) Read(r *reader) {
+	m.ModuleNameIndex = r.u16()
+	m.ModuleFlags = r.u16()
+	m.ModuleVersionIndex = r.u16()
+	m.RequiresCount = r.u16()
+	m.Requires = make([]*Requires, m.RequiresCount)
+	for i := 0; i < int(m.RequiresCount) && r.err == nil; i++ {
+		m.Requires[i] = &Requires{RequiresIndex: r.u16(), RequiresFlags: r.u16(), RequiresVersionIndex: r.u16()}
+	}
+	m.ExportsCount, m.Exports = readExports(r, "exports")
+	m.OpensCount, m.Opens = readExports(r, "opens")
+	m.UsesCount = r.u16()
+	m.UsesIndex = readU16s(r, int(m.UsesCount))
+	m.ProvidesCount = r.u16()
+	m.Provides = make([]*Provides, m.ProvidesCount)
+	for i := 0; i < int(m.ProvidesCount) && r.err == nil; i++ {
+		p := &Provides{ProvidesIndex: r.u16(), ProvidesWithCount: r.u16()}
+		p.ProvidesWithIndex = readU16s(r, int(p.ProvidesWithCount))
+		m.Provides[i] = p
+	}
+}
+
+func (m *ModuleAttribute) Write(w *writer) {
+	w.u16(m.ModuleNameIndex)
+	w.u16(m.ModuleFlags)
+	w.u16(m.ModuleVersionIndex)
+	w.u16(uint16(len(m.Requires)))
+	for _, q := range m.Requires {
+		w.u16(q.RequiresIndex)
+		w.u16(q.RequiresFlags)
+		w.u16(q.RequiresVersionIndex)
+	}
+	writeExports(w, m.Exports)
+	writeExports(w, m.Opens)
+	writeU16s(w, m.UsesIndex)
+	w.u16(uint16(len(m.Provides)))
+	for _, p := range m.Provides {
+		w.u16(p.ProvidesIndex)
+		writeU16s(w, p.ProvidesWithIndex)
+	}
+}
+
+// Requires entry of the requires in Module_attribute, RequiresVersionIndex
+// is 0 if no version was recorded.
+type Requires struct {
+	RequiresIndex        uint16
+	RequiresFlags        uint16
+	RequiresVersionIndex uint16
+}
+
+// Exports entry of the exports or opens in Module_attribute, an unqualified
+// export or open has no ExportsToIndex.
+type Exports struct {
+	ExportsIndex   uint16
+	ExportsFlags   uint16
+	ExportsToCount uint16
+	ExportsToIndex []uint16
+}
+
+func readExports(r *reader, name string) (count uint16, es []*Exports) {
+	count = r.u16()
+	es = make([]*Exports, count)
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		r.enter(name, i)
+		e := &Exports{ExportsIndex: r.u16(), ExportsFlags: r.u16(), ExportsToCount: r.u16()}
+		e.ExportsToIndex = readU16s(r, int(e.ExportsToCount))
+		es[i] = e
+		r.leave()
+	}
+	return
+}
+
+func writeExports(w *writer, es []*Exports) {
+	w.u16(uint16(len(es)))
+	for _, e := range es {
+		w.u16(e.ExportsIndex)
+		w.u16(e.ExportsFlags)
+		writeU16s(w, e.ExportsToIndex)
+	}
+}
+
+// Provides entry of the provides in Module_attribute.
+type Provides struct {
+	ProvidesIndex     uint16
+	ProvidesWithCount uint16
+	ProvidesWithIndex []uint16
+}
+
+// ModulePackagesAttribute ModulePackages_attribute.
+type ModulePackagesAttribute struct {
+	PackageCount uint16
+	PackageIndex []uint16
+}
+
+func (m *ModulePackagesAttribute) AttributeName() string {
+	return _modulePackages
+}
+
+func (m *ModulePackagesAttribute) Read(r *reader) {
+	m.PackageCount = r.u16()
+	m.PackageIndex = readU16s(r, int(m.PackageCount))
+}
+
+func (m *ModulePackagesAttribute) Write(w *writer) {
+	writeU16s(w, m.PackageIndex)
+}
+
+// ModuleMainClassAttribute ModuleMainClass_attribute.
+type ModuleMainClassAttribute struct {
+	MainClassIndex uint16
+}
+
+func (m *ModuleMainClassAttribute) AttributeName() string {
+	return _moduleMainClass
+}
+
+func (m *ModuleMainClassAttribute) Read(r *reader) {
+	m.MainClassIndex = r.u16()
+}
+
+func (m *ModuleMainClassAttribute) Write(w *writer) {
+	w.u16(m.MainClassIndex)
+}
+
+// Module module descriptor of a module-info class with all names resolved,
+// package and class names are in internal form.
+type Module struct {
+	Name      string
+	Flags     ModuleFlags
+	Version   string
+	Requires  []*ModuleRequires
+	Exports   []*ModuleExports
+	Opens     []*ModuleExports
+	Uses      []string
+	Provides  []*ModuleProvides
+	Packages  []string
+	MainClass string
+}
+
+// ModuleRequires dependence of a module.
+type ModuleRequires struct {
+	Name    string
+	Flags   RequiresFlags
+	Version string
+}
+
+// ModuleExports package exported or opened by a module, To lists the
+// modules of a qualified export or open.
+type ModuleExports struct {
+	Package string
+	Flags   ExportsFlags
+	To      []string
+}
+
+// ModuleProvides service implementations provided by a module.
+type ModuleProvides struct {
+	Service string
+	With    []string
+}
+
+// Module returns the module descriptor of a module-info class, nil if the
+// class has no Module attribute.
+func (m *ClassFile) Module() (res *Module, err error) {
+	a, ok := m.Attribute(_moduleAttribute).(*ModuleAttribute)
+	if !ok {
+		return
+	}
+	if res, err = a.ParseFromPool(m.CpInfo); err != nil {
+		return nil, err
+	}
+	if p, ok := m.Attribute(_modulePackages).(*ModulePackagesAttribute); ok {
+		if res.Packages, err = packageNames(m.CpInfo, p.PackageIndex); err != nil {
+			return nil, err
+		}
+	}
+	if c, ok := m.Attribute(_moduleMainClass).(*ModuleMainClassAttribute); ok {
+		if res.MainClass, err = classNameFromPool(m.CpInfo, c.MainClassIndex); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// ParseFromPool resolves the names of the module attribute, Packages and
+// MainClass of the result are left empty.
+func (m *ModuleAttribute) ParseFromPool(cp []ConstantInfo) (res *Module, err error) {
+	res = &Module{Flags: ModuleFlags(m.ModuleFlags)}
+	if res.Name, err = moduleNameFromPool(cp, m.ModuleNameIndex); err != nil {
+		return nil, err
+	}
+	if res.Version, err = optionalString(cp, m.ModuleVersionIndex); err != nil {
+		return nil, err
+	}
+	for _, q := range m.Requires {
+		mr := &ModuleRequires{Flags: RequiresFlags(q.RequiresFlags)}
+		if mr.Name, err = moduleNameFromPool(cp, q.RequiresIndex); err != nil {
+			return nil, err
+		}
+		if mr.Version, err = optionalString(cp, q.RequiresVersionIndex); err != nil {
+			return nil, err
+		}
+		res.Requires = append(res.Requires, mr)
+	}
+	if res.Exports, err = moduleExports(cp, m.Exports); err != nil {
+		return nil, err
+	}
+	if res.Opens, err = moduleExports(cp, m.Opens); err != nil {
+		return nil, err
+	}
+	for _, i := range m.UsesIndex {
+		var s string
+		if s, err = classNameFromPool(cp, i); err != nil {
+			return nil, err
+		}
+		res.Uses = append(res.Uses, s)
+	}
+	for _, p := range m.Provides {
+		mp := new(ModuleProvides)
+		if mp.Service, err = classNameFromPool(cp, p.ProvidesIndex); err != nil {
+			return nil, err
+		}
+		for _, i := range p.ProvidesWithIndex {
+			var s string
+			if s, err = classNameFromPool(cp, i); err != nil {
+				return nil, err
+			}
+			mp.With = append(mp.With, s)
+		}
+		res.Provides = append(res.Provides, mp)
+	}
+	return
+}
+
+func moduleExports(cp []ConstantInfo, es []*Exports) (res []*ModuleExports, err error) {
+	for _, e := range es {
+		me := &ModuleExports{Flags: ExportsFlags(e.ExportsFlags)}
+		if me.Package, err = packageNameFromPool(cp, e.ExportsIndex); err != nil {
+			return nil, err
+		}
+		for _, i := range e.ExportsToIndex {
+			var s string
+			if s, err = moduleNameFromPool(cp, i); err != nil {
+				return nil, err
+			}
+			me.To = append(me.To, s)
+		}
+		res = append(res, me)
+	}
+	return
+}
+
+func packageNames(cp []ConstantInfo, is []uint16) (res []string, err error) {
+	for _, i := range is {
+		var s string
+		if s, err = packageNameFromPool(cp, i); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return
+}
+
+// optionalString returns the utf8 at index i, empty for index 0.
+func optionalString(cp []ConstantInfo, i uint16) (string, error) {
+	if i == 0 {
+		return "", nil
+	}
+	return ui2string(cp, i)
+}
+
+func classNameFromPool(cp []ConstantInfo, i uint16) (name string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*ClassInfo)
+	if !ok {
+		err = mismatch(i, c, "Class")
+		return
+	}
+	return u.ParseNameFromPool(cp)
+}
+
+func moduleNameFromPool(cp []ConstantInfo, i uint16) (name string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*ModuleInfo)
+	if !ok {
+		err = mismatch(i, c, "Module")
+		return
+	}
+	return u.ParseNameFromPool(cp)
+}
+
+func packageNameFromPool(cp []ConstantInfo, i uint16) (name string, err error) {
+	var c ConstantInfo
+	if c, err = entryFromPool(cp, i); err != nil {
+		return
+	}
+	u, ok := c.(*PackageInfo)
+	if !ok {
+		err = mismatch(i, c, "Package")
+		return
+	}
+	return u.ParseNameFromPool(cp)
+}
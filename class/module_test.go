@@ -0,0 +1,108 @@
+package class
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func utf8Info(s string) *Utf8Info {
+	return &Utf8Info{Tag: Tag{_utf8}, Length: uint16(len(s)), Bytes: []byte(s)}
+}
+
+func TestModule(t *testing.T) {
+	cp := []ConstantInfo{
+		nil,
+		&ClassInfo{Tag: Tag{_class}, NameIndex: 2},
+		utf8Info("module-info"),
+		&ModuleInfo{Tag: Tag{_module}, NameIndex: 4},
+		utf8Info("com.example"),
+		utf8Info("1.0"),
+		&ModuleInfo{Tag: Tag{_module}, NameIndex: 7},
+		utf8Info("java.base"),
+		&PackageInfo{ModuleInfo{Tag: Tag{_package}, NameIndex: 9}},
+		utf8Info("com/example/api"),
+		&ClassInfo{Tag: Tag{_class}, NameIndex: 11},
+		utf8Info("com/example/api/Service"),
+		&ClassInfo{Tag: Tag{_class}, NameIndex: 13},
+		utf8Info("com/example/impl/Main"),
+		utf8Info("Module"),
+		utf8Info("ModulePackages"),
+		utf8Info("ModuleMainClass"),
+	}
+	module := &ModuleAttribute{
+		ModuleNameIndex:    3,
+		ModuleFlags:        AccOpen,
+		ModuleVersionIndex: 5,
+		Requires:           []*Requires{{RequiresIndex: 6, RequiresFlags: AccMandated}},
+		Exports:            []*Exports{{ExportsIndex: 8, ExportsToIndex: []uint16{6}}},
+		UsesIndex:          []uint16{10},
+		Provides:           []*Provides{{ProvidesIndex: 10, ProvidesWithIndex: []uint16{12}}},
+	}
+	cf := &ClassFile{
+		Magic:        0xCAFEBABE,
+		MajorVersion: 53,
+		CpInfo:       cp,
+		AccessFlags:  AccModule,
+		ThisClass:    1,
+		Attributes: []*AttributeInfo{
+			{AttributeNameIndex: 14, Value: module},
+			{AttributeNameIndex: 15, Value: &ModulePackagesAttribute{PackageIndex: []uint16{8}}},
+			{AttributeNameIndex: 16, Value: &ModuleMainClassAttribute{MainClassIndex: 12}},
+		},
+	}
+	b, err := cf.Bytes()
+	if err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	if cf, err = ParseBytes(b); err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	md, err := cf.Module()
+	if err != nil {
+		t.Errorf("failed to resolve module, error(%v)", err)
+		t.FailNow()
+	}
+	exp := &Module{
+		Name:      "com.example",
+		Flags:     AccOpen,
+		Version:   "1.0",
+		Requires:  []*ModuleRequires{{Name: "java.base", Flags: AccMandated}},
+		Exports:   []*ModuleExports{{Package: "com/example/api", To: []string{"java.base"}}},
+		Uses:      []string{"com/example/api/Service"},
+		Provides:  []*ModuleProvides{{Service: "com/example/api/Service", With: []string{"com/example/impl/Main"}}},
+		Packages:  []string{"com/example/api"},
+		MainClass: "com/example/impl/Main",
+	}
+	if !reflect.DeepEqual(md, exp) {
+		t.Errorf("expected %+v, got %+v", exp, md)
+	}
+
+	s, err := cf.Format()
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	for _, l := range []string{
+		"open module com.example@1.0\n",
+		"Module:\n" +
+			"  #3,20                                 // \"com.example\" ACC_OPEN\n" +
+			"  #5                                    // 1.0\n" +
+			"  1                                     // requires\n" +
+			"    #6,8000                             // \"java.base\" ACC_MANDATED\n" +
+			"    #0\n" +
+			"  1                                     // exports\n" +
+			"    #8,0                                // package com/example/api\n" +
+			"    1                                   // to\n" +
+			"      #6                                // \"java.base\"\n" +
+			"  0                                     // opens\n",
+		"ModulePackages:\n  #8                                    // com/example/api\n",
+		"ModuleMainClass: #12                    // com/example/impl/Main\n",
+	} {
+		if !strings.Contains(s, l) {
+			t.Errorf("expected\n%s\nin\n%s", l, s)
+		}
+	}
+}
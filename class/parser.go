@@ -1,7 +1,9 @@
 package class
 
 import (
-	"encoding/binary"
+	"bufio"
+	"fmt"
+	"io"
 	"io/ioutil"
 )
 
@@ -13,79 +15,137 @@ func ParseFile(filename string) (res *ClassFile, err error) {
 	return ParseBytes(b)
 }
 
+// ParseBytes parses a class file, a malformed or truncated input results in
+// a *ParseError.
 func ParseBytes(b []byte) (res *ClassFile, err error) {
+	r := newReader(b)
+	if res, err = decode(r); err != nil {
+		return
+	}
+	if r.off != len(b) {
+		r.fail(fmt.Errorf("%d extra bytes after class file", len(b)-r.off))
+		return nil, r.err
+	}
+	return
+}
+
+// Parse parses a class file from rd until EOF, the result is the same as
+// ParseBytes on the whole content of rd.
+func Parse(rd io.Reader) (res *ClassFile, err error) {
+	d := NewDecoder(rd)
+	if res, err = d.Decode(); err != nil {
+		return
+	}
+	if _, err = d.r.src.(*bufio.Reader).ReadByte(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("extra bytes after class file")
+		}
+		d.r.fail(err)
+		return nil, d.r.err
+	}
+	return res, nil
+}
+
+// Decoder reads and decodes class files from an input stream incrementally,
+// without buffering the whole class file first.
+type Decoder struct {
+	r *reader
+}
+
+// NewDecoder returns a decoder that reads from rd, the decoder introduces
+// its own buffering and may read data from rd beyond the class file.
+func NewDecoder(rd io.Reader) *Decoder {
+	return &Decoder{r: newStreamReader(bufio.NewReader(rd))}
+}
+
+// Decode reads the next class file from the input, errors are sticky: once
+// Decode failed, all following calls return the same error.
+func (d *Decoder) Decode() (res *ClassFile, err error) {
+	if d.r.err != nil {
+		return nil, d.r.err
+	}
+	d.r.off = 0
+	return decode(d.r)
+}
+
+func decode(r *reader) (res *ClassFile, err error) {
 	res = new(ClassFile)
-	var next int
-	res.Magic, next = u32(b, 0)
-	res.MinorVersion, next = u16(b, next)
-	res.MajorVersion, next = u16(b, next)
+	res.Magic = r.u32()
+	res.MinorVersion = r.u16()
+	res.MajorVersion = r.u16()
 
-	res.ConstantPoolCount, next = u16(b, next)
+	res.ConstantPoolCount = r.u16()
 	// parse constant pool: #1 - #constant_pool_count-1
 	res.CpInfo = make([]ConstantInfo, res.ConstantPoolCount)
-	var c ConstantInfo
-	for i := 1; i < int(res.ConstantPoolCount); i++ {
-		c, next = NewConstantInfo(b, next)
+	for i := 1; i < int(res.ConstantPoolCount) && r.err == nil; i++ {
+		r.enter("constant pool entry", i)
+		c := NewConstantInfo(r)
+		r.leave()
+		if c == nil {
+			break
+		}
 		res.CpInfo[i] = c
 		if c.T() == _double || c.T() == _long {
 			i++
 		}
 	}
 
-	res.AccessFlags, next = u16(b, next)
-	res.ThisClass, next = u16(b, next)
-	res.SuperClass, next = u16(b, next)
+	res.AccessFlags = r.u16()
+	res.ThisClass = r.u16()
+	res.SuperClass = r.u16()
 
-	res.InterfacesCount, next = u16(b, next)
+	res.InterfacesCount = r.u16()
 	res.Interfaces = make([]*ClassInfo, res.InterfacesCount)
-	for i := 0; i < int(res.InterfacesCount); i++ {
+	for i := 0; i < int(res.InterfacesCount) && r.err == nil; i++ {
+		r.enter("interface", i)
 		res.Interfaces[i] = new(ClassInfo)
-		next = res.Interfaces[i].Read(b, next)
+		res.Interfaces[i].Read(r)
+		r.leave()
 	}
 
-	res.FieldsCount, next = u16(b, next)
+	res.FieldsCount = r.u16()
 	res.Fields = make([]*FieldInfo, res.FieldsCount)
-	for i := 0; i < int(res.FieldsCount); i++ {
+	for i := 0; i < int(res.FieldsCount) && r.err == nil; i++ {
+		r.enter("field", i)
 		res.Fields[i] = new(FieldInfo)
-		next = res.Fields[i].Read(b, next)
+		res.Fields[i].Read(r)
+		r.leave()
 	}
 
-	res.MethodsCount, next = u16(b, next)
+	res.MethodsCount = r.u16()
 	res.Methods = make([]*MethodInfo, res.MethodsCount)
-	for i := 0; i < int(res.MethodsCount); i++ {
+	for i := 0; i < int(res.MethodsCount) && r.err == nil; i++ {
+		r.enter("method", i)
 		res.Methods[i] = new(MethodInfo)
-		next = res.Methods[i].Read(b, next)
+		res.Methods[i].Read(r)
+		r.leave()
 	}
 
-	res.AttributesCount, next = u16(b, next)
-	res.Attributes = make([]*AttributeInfo, res.AttributesCount)
-	for i := 0; i < int(res.AttributesCount); i++ {
-		res.Attributes[i] = new(AttributeInfo)
-		next = res.Attributes[i].Read(b, next)
+	res.AttributesCount, res.Attributes = readAttributes(r)
+	if r.err != nil {
+		return nil, r.err
 	}
-	return
-}
 
-func u8(b []byte, s int) (res uint8, next int) {
-	next = s + 1
-	res = uint8(b[s])
-	return
-}
-
-func u16(b []byte, s int) (res uint16, next int) {
-	next = s + 2
-	res = binary.BigEndian.Uint16(b[s:next])
-	return
-}
-
-func u32(b []byte, s int) (res uint32, next int) {
-	next = s + 4
-	res = binary.BigEndian.Uint32(b[s:next])
+	if err = res.decodeAttributes(); err != nil {
+		r.err = err
+		return nil, err
+	}
 	return
 }
 
-func bs(b []byte, s int, len int) (res []byte, next int) {
-	next = s + len
-	res = b[s:next]
-	return
+// decodeAttributes decodes the attributes of the class and its members.
+func (m *ClassFile) decodeAttributes() (err error) {
+	cp := m.ConstantPool()
+	for i, f := range m.Fields {
+		if err = decodeAttributes(cp, f.Attributes, []frame{{"field", i}}); err != nil {
+			return
+		}
+	}
+	for i, f := range m.Methods {
+		if err = decodeAttributes(cp, f.Attributes, []frame{{"method", i}}); err != nil {
+			return
+		}
+		f.Code, _ = f.Attribute(_code).(*CodeAttribute)
+	}
+	return decodeAttributes(cp, m.Attributes, nil)
 }
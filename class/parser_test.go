@@ -1,7 +1,11 @@
 package class
 
 import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
 	"testing"
+	"testing/iotest"
 )
 
 func TestParseFile(t *testing.T) {
@@ -10,6 +14,14 @@ func TestParseFile(t *testing.T) {
 		t.Errorf("failed to parse file, error(%v)", err)
 		t.FailNow()
 	}
+	for _, m := range res.Methods {
+		if m.Code == nil {
+			continue
+		}
+		if int(m.Code.CodeLength) != len(m.Code.Code) {
+			t.Errorf("code length mismatch, expected %d, got %d", m.Code.CodeLength, len(m.Code.Code))
+		}
+	}
 	str, err := res.Format()
 	if err != nil {
 		t.Errorf("failed to format, error(%v)", err)
@@ -17,3 +29,73 @@ func TestParseFile(t *testing.T) {
 	}
 	t.Logf("format: \n%s", str)
 }
+
+func TestParseBytesTruncated(t *testing.T) {
+	b, err := ioutil.ReadFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to read file, error(%v)", err)
+		t.FailNow()
+	}
+	for i := 0; i < len(b); i++ {
+		_, err = ParseBytes(b[:i])
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("expected *ParseError for %d bytes, got %v", i, err)
+		}
+	}
+	// unsupported tag of the first constant pool entry
+	bad := append([]byte{}, b...)
+	bad[10] = 2
+	_, err = ParseBytes(bad)
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Offset != 10 || pe.Structure != "constant pool entry #1" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	b, err := ioutil.ReadFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to read file, error(%v)", err)
+		t.FailNow()
+	}
+	exp, err := ParseBytes(b)
+	if err != nil {
+		t.Errorf("failed to parse bytes, error(%v)", err)
+		t.FailNow()
+	}
+	res, err := Parse(iotest.OneByteReader(bytes.NewReader(b)))
+	if err != nil {
+		t.Errorf("failed to parse stream, error(%v)", err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("stream result differs from ParseBytes")
+	}
+	if _, err = Parse(bytes.NewReader(b[:len(b)-1])); err == nil {
+		t.Errorf("expected error for truncated stream")
+	}
+	if _, err = Parse(bytes.NewReader(append(b, 0))); err == nil {
+		t.Errorf("expected error for extra bytes")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	b, err := ioutil.ReadFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to read file, error(%v)", err)
+		t.FailNow()
+	}
+	res, err := ParseBytes(b)
+	if err != nil {
+		t.Errorf("failed to parse bytes, error(%v)", err)
+		t.FailNow()
+	}
+	out, err := res.Bytes()
+	if err != nil {
+		t.Errorf("failed to write, error(%v)", err)
+		t.FailNow()
+	}
+	if !bytes.Equal(b, out) {
+		t.Errorf("round trip is not byte-identical")
+	}
+}
@@ -0,0 +1,170 @@
+package class
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// _chunk upper bound of a single allocation when reading a variable
+	// length item from a stream, so that a bogus length doesn't allocate
+	// more than the stream actually holds.
+	_chunk = 64 * 1024
+)
+
+var (
+	ErrTruncated = errors.New("unexpected end of class file")
+)
+
+// ParseError error occurred while parsing a class file, holds the byte offset
+// and the structure being parsed.
+type ParseError struct {
+	Offset    int
+	Structure string
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	if e.Structure == "" {
+		return fmt.Sprintf("class: offset %d: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("class: %s at offset %d: %v", e.Structure, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+type frame struct {
+	name  string
+	index int
+}
+
+// reader reads big-endian values from a class file held in memory or from a
+// stream, every read is checked against the remaining length. The first
+// failure is kept in err and turns all following reads into no-ops returning
+// zero values.
+type reader struct {
+	b   []byte
+	src io.Reader
+	buf [8]byte
+	off int
+	// base offset of b in the class file, for errors.
+	base   int
+	err    error
+	frames []frame
+}
+
+func newReader(b []byte) *reader {
+	return &reader{b: b}
+}
+
+func newStreamReader(src io.Reader) *reader {
+	return &reader{src: src}
+}
+
+// enter pushes the structure being parsed, index < 0 means no index.
+func (r *reader) enter(name string, index int) {
+	r.frames = append(r.frames, frame{name: name, index: index})
+}
+
+// leave pops the structure being parsed.
+func (r *reader) leave() {
+	r.frames = r.frames[:len(r.frames)-1]
+}
+
+func (r *reader) structure() string {
+	return structure(r.frames)
+}
+
+func structure(frames []frame) string {
+	ns := make([]string, 0, len(frames))
+	for _, f := range frames {
+		if f.index < 0 {
+			ns = append(ns, f.name)
+		} else {
+			ns = append(ns, fmt.Sprintf("%s #%d", f.name, f.index))
+		}
+	}
+	return strings.Join(ns, " > ")
+}
+
+// fail records err at the current offset, only the first failure is kept.
+func (r *reader) fail(err error) {
+	if r.err != nil {
+		return
+	}
+	r.err = &ParseError{Offset: r.base + r.off, Structure: r.structure(), Err: err}
+}
+
+// next returns the next n bytes, for a stream reader the result is only
+// valid until the next call unless keep is set.
+func (r *reader) next(n int, keep bool) (res []byte) {
+	if r.err != nil {
+		return
+	}
+	if n < 0 {
+		r.fail(fmt.Errorf("negative length %d", n))
+		return
+	}
+	if r.src == nil {
+		if len(r.b)-r.off < n {
+			r.fail(ErrTruncated)
+			return
+		}
+		res = r.b[r.off : r.off+n]
+		r.off += n
+		return
+	}
+	var err error
+	switch {
+	case n <= len(r.buf) && !keep:
+		res = r.buf[:n]
+		_, err = io.ReadFull(r.src, res)
+	case n <= _chunk:
+		res = make([]byte, n)
+		_, err = io.ReadFull(r.src, res)
+	default:
+		w := bytes.NewBuffer(make([]byte, 0, _chunk))
+		_, err = io.CopyN(w, r.src, int64(n))
+		res = w.Bytes()
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrTruncated
+	}
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	r.off += n
+	return
+}
+
+func (r *reader) u8() (res uint8) {
+	if b := r.next(1, false); b != nil {
+		res = b[0]
+	}
+	return
+}
+
+func (r *reader) u16() (res uint16) {
+	if b := r.next(2, false); b != nil {
+		res = binary.BigEndian.Uint16(b)
+	}
+	return
+}
+
+func (r *reader) u32() (res uint32) {
+	if b := r.next(4, false); b != nil {
+		res = binary.BigEndian.Uint32(b)
+	}
+	return
+}
+
+func (r *reader) bytes(n int) (res []byte) {
+	return r.next(n, true)
+}
@@ -0,0 +1,217 @@
+package class
+
+import (
+	"fmt"
+)
+
+const (
+	_stackMapTable = "StackMapTable"
+)
+
+// verification_type_info tags
+const (
+	ItemTop               = 0
+	ItemInteger           = 1
+	ItemFloat             = 2
+	ItemDouble            = 3
+	ItemLong              = 4
+	ItemNull              = 5
+	ItemUninitializedThis = 6
+	ItemObject            = 7
+	ItemUninitialized     = 8
+)
+
+// stack_map_frame frame_type ranges
+const (
+	_sameFrameMax                      = 63
+	_sameLocals1StackItemFrameMax      = 127
+	_sameLocals1StackItemFrameExtended = 247
+	_chopFrameMin                      = 248
+	_chopFrameMax                      = 250
+	_sameFrameExtended                 = 251
+	_appendFrameMin                    = 252
+	_appendFrameMax                    = 254
+	_fullFrame                         = 255
+)
+
+// StackMapTableAttribute StackMapTable_attribute.
+type StackMapTableAttribute struct {
+	NumberOfEntries uint16
+	Entries         []*StackMapFrame
+}
+
+func (m *StackMapTableAttribute) AttributeName() string {
+	return _stackMapTable
+}
+
+func (m *StackMapTableAttribute) Read(r *reader) {
+	m.NumberOfEntries = r.u16()
+	m.Entries = make([]*StackMapFrame, m.NumberOfEntries)
+	off := -1
+	for i := 0; i < int(m.NumberOfEntries) && r.err == nil; i++ {
+		r.enter("frame", i)
+		f := new(StackMapFrame)
+		f.Read(r)
+		off += int(f.OffsetDelta) + 1
+		f.Offset = off
+		m.Entries[i] = f
+		r.leave()
+	}
+}
+
+func (m *StackMapTableAttribute) Write(w *writer) {
+	w.u16(uint16(len(m.Entries)))
+	for _, f := range m.Entries {
+		f.Write(w)
+	}
+}
+
+// StackMapFrame stack_map_frame, OffsetDelta is implied by FrameType for the
+// same and same_locals_1_stack_item frames. Locals holds the added locals of
+// an append frame and all locals of a full frame, Stack the single item of
+// the same_locals_1_stack_item frames and the stack of a full frame.
+type StackMapFrame struct {
+	FrameType          uint8
+	OffsetDelta        uint16
+	NumberOfLocals     uint16
+	Locals             []*VerificationTypeInfo
+	NumberOfStackItems uint16
+	Stack              []*VerificationTypeInfo
+	// Offset bytecode offset the frame applies to, resolved from the offset
+	// deltas of the frames when the table is read.
+	Offset int
+}
+
+func (m *StackMapFrame) Read(r *reader) {
+	m.FrameType = r.u8()
+	t := m.FrameType
+	switch {
+	case t <= _sameFrameMax:
+		m.OffsetDelta = uint16(t)
+	case t <= _sameLocals1StackItemFrameMax:
+		m.OffsetDelta = uint16(t - 64)
+		m.NumberOfStackItems, m.Stack = 1, readVerificationTypes(r, 1)
+	case t < _sameLocals1StackItemFrameExtended:
+		if r.err == nil {
+			r.off--
+			r.fail(fmt.Errorf("reserved frame type %d", t))
+		}
+	case t == _sameLocals1StackItemFrameExtended:
+		m.OffsetDelta = r.u16()
+		m.NumberOfStackItems, m.Stack = 1, readVerificationTypes(r, 1)
+	case t <= _sameFrameExtended:
+		m.OffsetDelta = r.u16()
+	case t <= _appendFrameMax:
+		m.OffsetDelta = r.u16()
+		m.NumberOfLocals = uint16(t - _sameFrameExtended)
+		m.Locals = readVerificationTypes(r, int(m.NumberOfLocals))
+	default:
+		m.OffsetDelta = r.u16()
+		m.NumberOfLocals = r.u16()
+		m.Locals = readVerificationTypes(r, int(m.NumberOfLocals))
+		m.NumberOfStackItems = r.u16()
+		m.Stack = readVerificationTypes(r, int(m.NumberOfStackItems))
+	}
+}
+
+func (m *StackMapFrame) Write(w *writer) {
+	w.u8(m.FrameType)
+	t := m.FrameType
+	switch {
+	case t <= _sameFrameMax:
+	case t <= _sameLocals1StackItemFrameMax:
+		writeVerificationTypes(w, m.Stack)
+	case t == _sameLocals1StackItemFrameExtended:
+		w.u16(m.OffsetDelta)
+		writeVerificationTypes(w, m.Stack)
+	case t >= _chopFrameMin && t <= _appendFrameMax:
+		w.u16(m.OffsetDelta)
+		writeVerificationTypes(w, m.Locals)
+	case t == _fullFrame:
+		w.u16(m.OffsetDelta)
+		w.u16(uint16(len(m.Locals)))
+		writeVerificationTypes(w, m.Locals)
+		w.u16(uint16(len(m.Stack)))
+		writeVerificationTypes(w, m.Stack)
+	}
+}
+
+// Kind returns the name of the frame type like javap prints it, e.g. chop.
+func (m *StackMapFrame) Kind() string {
+	t := m.FrameType
+	switch {
+	case t <= _sameFrameMax:
+		return "same"
+	case t <= _sameLocals1StackItemFrameMax:
+		return "same_locals_1_stack_item"
+	case t < _sameLocals1StackItemFrameExtended:
+		return "reserved"
+	case t == _sameLocals1StackItemFrameExtended:
+		return "same_locals_1_stack_item_frame_extended"
+	case t <= _chopFrameMax:
+		return "chop"
+	case t == _sameFrameExtended:
+		return "same_frame_extended"
+	case t <= _appendFrameMax:
+		return "append"
+	}
+	return "full_frame"
+}
+
+// Chopped returns the number of locals removed by a chop frame, 0 for the
+// other frames.
+func (m *StackMapFrame) Chopped() int {
+	if m.FrameType >= _chopFrameMin && m.FrameType <= _chopFrameMax {
+		return _sameFrameExtended - int(m.FrameType)
+	}
+	return 0
+}
+
+// VerificationTypeInfo verification_type_info, CpoolIndex is set for
+// ItemObject, Offset of the new instruction for ItemUninitialized.
+type VerificationTypeInfo struct {
+	Tag        uint8
+	CpoolIndex uint16
+	Offset     uint16
+}
+
+func (m *VerificationTypeInfo) Read(r *reader) {
+	m.Tag = r.u8()
+	switch m.Tag {
+	case ItemObject:
+		m.CpoolIndex = r.u16()
+	case ItemUninitialized:
+		m.Offset = r.u16()
+	case ItemTop, ItemInteger, ItemFloat, ItemDouble, ItemLong, ItemNull, ItemUninitializedThis:
+	default:
+		if r.err == nil {
+			r.off--
+			r.fail(fmt.Errorf("invalid verification type tag %d", m.Tag))
+		}
+	}
+}
+
+func (m *VerificationTypeInfo) Write(w *writer) {
+	w.u8(m.Tag)
+	switch m.Tag {
+	case ItemObject:
+		w.u16(m.CpoolIndex)
+	case ItemUninitialized:
+		w.u16(m.Offset)
+	}
+}
+
+func readVerificationTypes(r *reader, n int) (res []*VerificationTypeInfo) {
+	res = make([]*VerificationTypeInfo, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		res[i] = new(VerificationTypeInfo)
+		res[i].Read(r)
+	}
+	return
+}
+
+func writeVerificationTypes(w *writer, vs []*VerificationTypeInfo) {
+	for _, v := range vs {
+		v.Write(w)
+	}
+}
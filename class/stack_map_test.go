@@ -0,0 +1,79 @@
+package class
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStackMapTable(t *testing.T) {
+	cf, err := ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	n := len(cf.CpInfo)
+	cf.CpInfo = append(cf.CpInfo, &Utf8Info{Tag: Tag{_utf8}, Length: 13, Bytes: []byte(_stackMapTable)})
+	info := []byte{0, 6,
+		3,                   // same, offset 3
+		64 + 2, ItemInteger, // same_locals_1_stack_item, offset 6
+		253, 0, 1, ItemLong, ItemObject, 0, 2, // append, offset 8
+		249, 0, 0, // chop 2, offset 9
+		247, 0, 2, ItemUninitialized, 0, 5, // same_locals_1_stack_item_extended, offset 12
+		255, 0, 3, 0, 1, ItemUninitializedThis, 0, 2, ItemNull, ItemTop, // full, offset 16
+	}
+	code := cf.Methods[1].Code
+	code.Attributes = append(code.Attributes, &AttributeInfo{AttributeNameIndex: uint16(n), Info: info})
+	b, err := cf.Bytes()
+	if err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+	if cf, err = ParseBytes(b); err != nil {
+		t.Errorf("failed to parse written class, error(%v)", err)
+		t.FailNow()
+	}
+	a, ok := cf.Methods[1].Code.Attribute(_stackMapTable).(*StackMapTableAttribute)
+	if !ok {
+		t.Errorf("expected StackMapTable attribute")
+		t.FailNow()
+	}
+	offs := []int{3, 6, 8, 9, 12, 16}
+	kinds := []string{"same", "same_locals_1_stack_item", "append", "chop", "same_locals_1_stack_item_frame_extended", "full_frame"}
+	for i, f := range a.Entries {
+		if f.Offset != offs[i] || f.Kind() != kinds[i] {
+			t.Errorf("expected %s frame at %d, got %s at %d", kinds[i], offs[i], f.Kind(), f.Offset)
+		}
+	}
+	if c := a.Entries[3].Chopped(); c != 2 {
+		t.Errorf("expected 2 chopped locals, got %d", c)
+	}
+	if b2, _ := cf.Bytes(); !bytes.Equal(b, b2) {
+		t.Errorf("expected identical bytes after round trip")
+	}
+
+	s, err := cf.Format()
+	if err != nil {
+		t.Errorf("failed to format, error(%v)", err)
+		t.FailNow()
+	}
+	exp := "      StackMapTable: number_of_entries = 6\n" +
+		"        frame_type = 3 /* same */\n" +
+		"        frame_type = 66 /* same_locals_1_stack_item */\n" +
+		"          stack = [ int ]\n" +
+		"        frame_type = 253 /* append */\n" +
+		"          offset_delta = 1\n" +
+		"          locals = [ long, class java/lang/Object ]\n" +
+		"        frame_type = 249 /* chop */\n" +
+		"          offset_delta = 0\n" +
+		"        frame_type = 247 /* same_locals_1_stack_item_frame_extended */\n" +
+		"          offset_delta = 2\n" +
+		"          stack = [ uninitialized 5 ]\n" +
+		"        frame_type = 255 /* full_frame */\n" +
+		"          offset_delta = 3\n" +
+		"          locals = [ this ]\n" +
+		"          stack = [ null, top ]\n"
+	if !strings.Contains(s, exp) {
+		t.Errorf("expected\n%s\nin\n%s", exp, s)
+	}
+}
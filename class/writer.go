@@ -0,0 +1,102 @@
+package class
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// writer writes big-endian values of a class file, the first failure is kept
+// in err and turns all following writes into no-ops.
+type writer struct {
+	w   io.Writer
+	n   int64
+	err error
+	buf [4]byte
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{w: w}
+}
+
+func (w *writer) bytes(b []byte) {
+	if w.err != nil {
+		return
+	}
+	var n int
+	n, w.err = w.w.Write(b)
+	w.n += int64(n)
+}
+
+func (w *writer) u8(v uint8) {
+	w.buf[0] = v
+	w.bytes(w.buf[:1])
+}
+
+func (w *writer) u16(v uint16) {
+	binary.BigEndian.PutUint16(w.buf[:2], v)
+	w.bytes(w.buf[:2])
+}
+
+func (w *writer) u32(v uint32) {
+	binary.BigEndian.PutUint32(w.buf[:4], v)
+	w.bytes(w.buf[:4])
+}
+
+// WriteTo writes the class file to w. Counts and lengths are taken from the
+// slices they describe, so that the result stays consistent after entries are
+// added or removed, a decoded attribute is written from AttributeInfo.Value.
+func (m *ClassFile) WriteTo(w io.Writer) (n int64, err error) {
+	cw := newWriter(w)
+	m.Write(cw)
+	return cw.n, cw.err
+}
+
+// Bytes returns the serialized class file.
+func (m *ClassFile) Bytes() (res []byte, err error) {
+	b := new(bytes.Buffer)
+	if _, err = m.WriteTo(b); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+func (m *ClassFile) Write(w *writer) {
+	w.u32(m.Magic)
+	w.u16(m.MinorVersion)
+	w.u16(m.MajorVersion)
+
+	w.u16(uint16(len(m.CpInfo)))
+	for i := 1; i < len(m.CpInfo); i++ {
+		c := m.CpInfo[i]
+		if c == nil {
+			continue
+		}
+		w.u8(c.T())
+		c.Write(w)
+		if c.T() == _double || c.T() == _long {
+			i++
+		}
+	}
+
+	w.u16(m.AccessFlags)
+	w.u16(m.ThisClass)
+	w.u16(m.SuperClass)
+
+	w.u16(uint16(len(m.Interfaces)))
+	for _, c := range m.Interfaces {
+		c.Write(w)
+	}
+
+	w.u16(uint16(len(m.Fields)))
+	for _, f := range m.Fields {
+		f.Write(w)
+	}
+
+	w.u16(uint16(len(m.Methods)))
+	for _, f := range m.Methods {
+		f.Write(w)
+	}
+
+	writeAttributes(w, m.Attributes)
+}
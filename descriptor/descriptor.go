@@ -0,0 +1,285 @@
+// Package descriptor parses field and method descriptors (JVMS 4.3).
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind kind of a field type, the value is the descriptor character.
+type Kind byte
+
+const (
+	Byte    Kind = 'B'
+	Char    Kind = 'C'
+	Double  Kind = 'D'
+	Float   Kind = 'F'
+	Int     Kind = 'I'
+	Long    Kind = 'J'
+	Short   Kind = 'S'
+	Boolean Kind = 'Z'
+	Object  Kind = 'L'
+	// Void only valid as a method return type.
+	Void Kind = 'V'
+)
+
+const (
+	// MaxDimensions the max number of array dimensions.
+	MaxDimensions = 255
+)
+
+var (
+	_kn = map[Kind]string{
+		Byte:    "byte",
+		Char:    "char",
+		Double:  "double",
+		Float:   "float",
+		Int:     "int",
+		Long:    "long",
+		Short:   "short",
+		Boolean: "boolean",
+		Void:    "void",
+	}
+)
+
+// String returns the Java keyword of a base type or void.
+func (k Kind) String() string {
+	if n, ok := _kn[k]; ok {
+		return n
+	}
+	if k == Object {
+		return "reference"
+	}
+	return fmt.Sprintf("Kind(%q)", byte(k))
+}
+
+// Error descriptor syntax error.
+type Error struct {
+	Descriptor string
+	Offset     int
+	Msg        string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("descriptor: invalid %q at offset %d: %s", e.Descriptor, e.Offset, e.Msg)
+}
+
+// Type a field type or a method return type. For an array type Kind and
+// ClassName describe the element type.
+type Type struct {
+	Kind Kind
+	// ClassName class name in internal form, e.g. java/lang/String, only for
+	// Object.
+	ClassName  string
+	Dimensions int
+}
+
+// IsArray reports whether t is an array type.
+func (t *Type) IsArray() bool {
+	return t.Dimensions > 0
+}
+
+// IsReference reports whether t is an object or array type.
+func (t *Type) IsReference() bool {
+	return t.Kind == Object || t.IsArray()
+}
+
+// Elem returns the component type of an array type, nil for a non array.
+func (t *Type) Elem() *Type {
+	if !t.IsArray() {
+		return nil
+	}
+	return &Type{Kind: t.Kind, ClassName: t.ClassName, Dimensions: t.Dimensions - 1}
+}
+
+// Slots returns the number of local variable slots taken by a value of t:
+// 2 for long and double, 0 for void, 1 otherwise.
+func (t *Type) Slots() int {
+	switch {
+	case t.IsArray():
+		return 1
+	case t.Kind == Long || t.Kind == Double:
+		return 2
+	case t.Kind == Void:
+		return 0
+	}
+	return 1
+}
+
+// String returns the descriptor of t.
+func (t *Type) String() string {
+	b := new(strings.Builder)
+	t.write(b)
+	return b.String()
+}
+
+func (t *Type) write(b *strings.Builder) {
+	b.WriteString(strings.Repeat("[", t.Dimensions))
+	b.WriteByte(byte(t.Kind))
+	if t.Kind == Object {
+		b.WriteString(t.ClassName)
+		b.WriteByte(';')
+	}
+}
+
+// Java returns t in Java source syntax, e.g. java.lang.String[].
+func (t *Type) Java() string {
+	var n string
+	if t.Kind == Object {
+		n = strings.Replace(t.ClassName, "/", ".", -1)
+	} else {
+		n = t.Kind.String()
+	}
+	return n + strings.Repeat("[]", t.Dimensions)
+}
+
+// Method method descriptor.
+type Method struct {
+	Params []*Type
+	Return *Type
+}
+
+// ArgSlots returns the number of local variable slots taken by the
+// parameters, not including this.
+func (m *Method) ArgSlots() (n int) {
+	for _, p := range m.Params {
+		n += p.Slots()
+	}
+	return
+}
+
+// String returns the descriptor of m.
+func (m *Method) String() string {
+	b := new(strings.Builder)
+	b.WriteByte('(')
+	for _, p := range m.Params {
+		p.write(b)
+	}
+	b.WriteByte(')')
+	m.Return.write(b)
+	return b.String()
+}
+
+// Java returns m in Java source syntax with the given method name, e.g.
+// void main(java.lang.String[]).
+func (m *Method) Java(name string) string {
+	ps := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		ps[i] = p.Java()
+	}
+	return fmt.Sprintf("%s %s(%s)", m.Return.Java(), name, strings.Join(ps, ", "))
+}
+
+// ParseField parses a field descriptor.
+func ParseField(s string) (res *Type, err error) {
+	p := &parser{s: s}
+	if res, err = p.fieldType(); err != nil {
+		return
+	}
+	if p.off != len(s) {
+		return nil, p.error("unexpected trailing characters")
+	}
+	return
+}
+
+// ParseMethod parses a method descriptor. The parameters may take at most
+// 255 slots, which is the bound of a static method: callers checking an
+// instance method add one slot for this.
+func ParseMethod(s string) (res *Method, err error) {
+	p := &parser{s: s}
+	if p.off >= len(s) || s[p.off] != '(' {
+		return nil, p.error("expected '('")
+	}
+	p.off++
+	res = &Method{Params: make([]*Type, 0)}
+	var t *Type
+	for p.off < len(s) && s[p.off] != ')' {
+		if t, err = p.fieldType(); err != nil {
+			return nil, err
+		}
+		res.Params = append(res.Params, t)
+	}
+	if p.off >= len(s) {
+		return nil, p.error("expected ')'")
+	}
+	p.off++
+	if p.off < len(s) && s[p.off] == byte(Void) {
+		p.off++
+		res.Return = &Type{Kind: Void}
+	} else if res.Return, err = p.fieldType(); err != nil {
+		return nil, err
+	}
+	if p.off != len(s) {
+		return nil, p.error("unexpected trailing characters")
+	}
+	// the bound of a static method, ArgSlots doesn't include this (JVMS 4.3.3)
+	if res.ArgSlots() > 255 {
+		return nil, &Error{Descriptor: s, Offset: 0, Msg: "too many parameters"}
+	}
+	return
+}
+
+type parser struct {
+	s   string
+	off int
+}
+
+func (p *parser) error(msg string) error {
+	return &Error{Descriptor: p.s, Offset: p.off, Msg: msg}
+}
+
+func (p *parser) fieldType() (res *Type, err error) {
+	res = new(Type)
+	for p.off < len(p.s) && p.s[p.off] == '[' {
+		res.Dimensions++
+		p.off++
+	}
+	if res.Dimensions > MaxDimensions {
+		return nil, p.error("too many array dimensions")
+	}
+	if p.off >= len(p.s) {
+		return nil, p.error("unexpected end of descriptor")
+	}
+	res.Kind = Kind(p.s[p.off])
+	switch res.Kind {
+	case Byte, Char, Double, Float, Int, Long, Short, Boolean:
+		p.off++
+	case Object:
+		p.off++
+		end := strings.IndexByte(p.s[p.off:], ';')
+		if end < 0 {
+			return nil, p.error("missing ';' after class name")
+		}
+		res.ClassName = p.s[p.off : p.off+end]
+		if err = p.className(res.ClassName); err != nil {
+			return nil, err
+		}
+		p.off += end + 1
+	default:
+		return nil, p.error(fmt.Sprintf("unexpected character %q", p.s[p.off]))
+	}
+	return
+}
+
+// className validates a binary class name in internal form starting at the
+// current offset.
+func (p *parser) className(n string) error {
+	seg := 0
+	for i := 0; i < len(n); i++ {
+		switch n[i] {
+		case '/':
+			if seg == 0 {
+				return &Error{Descriptor: p.s, Offset: p.off + i, Msg: "empty class name segment"}
+			}
+			seg = 0
+			continue
+		case '.', ';', '[':
+			return &Error{Descriptor: p.s, Offset: p.off + i, Msg: fmt.Sprintf("illegal character %q in class name", n[i])}
+		}
+		seg++
+	}
+	if seg == 0 {
+		return &Error{Descriptor: p.s, Offset: p.off + len(n), Msg: "empty class name segment"}
+	}
+	return nil
+}
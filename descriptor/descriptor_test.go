@@ -0,0 +1,62 @@
+package descriptor
+
+import (
+	"testing"
+)
+
+func TestParseMethod(t *testing.T) {
+	m, err := ParseMethod("(I[Ljava/lang/String;J)V")
+	if err != nil {
+		t.Errorf("failed to parse, error(%v)", err)
+		t.FailNow()
+	}
+	if len(m.Params) != 3 || m.Return.Kind != Void {
+		t.Errorf("unexpected method %+v", m)
+	}
+	if p := m.Params[1]; p.Kind != Object || p.ClassName != "java/lang/String" || p.Dimensions != 1 {
+		t.Errorf("unexpected param %+v", p)
+	}
+	if n := m.ArgSlots(); n != 4 {
+		t.Errorf("expected 4 arg slots, got %d", n)
+	}
+	if s := m.String(); s != "(I[Ljava/lang/String;J)V" {
+		t.Errorf("unexpected descriptor %s", s)
+	}
+	if s := m.Java("f"); s != "void f(int, java.lang.String[], long)" {
+		t.Errorf("unexpected java %s", s)
+	}
+}
+
+func TestParseField(t *testing.T) {
+	cases := map[string]string{
+		"I":                  "int",
+		"[[D":                "double[][]",
+		"Ljava/lang/Object;": "java.lang.Object",
+		"[La/B$C;":           "a.B$C[]",
+	}
+	for d, j := range cases {
+		f, err := ParseField(d)
+		if err != nil {
+			t.Errorf("failed to parse %s, error(%v)", d, err)
+			continue
+		}
+		if f.String() != d || f.Java() != j {
+			t.Errorf("unexpected type %s %s for %s", f.String(), f.Java(), d)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	fields := []string{"", "V", "[", "L;", "Ljava/lang/String", "La//b;", "La.b;", "II", "X"}
+	for _, d := range fields {
+		if _, err := ParseField(d); err == nil {
+			t.Errorf("expected error for field %q", d)
+		}
+	}
+	methods := []string{"", "I", "(", "()", "(V)V", "()VI", "(I", "()[V"}
+	for _, d := range methods {
+		if _, err := ParseMethod(d); err == nil {
+			t.Errorf("expected error for method %q", d)
+		}
+	}
+}
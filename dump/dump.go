@@ -0,0 +1,877 @@
+// Package dump exports a parsed class file as JSON or YAML with a stable
+// schema, constant pool references are resolved to their symbolic values.
+package dump
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/wucongyou/go-jvm/bytecode"
+	"github.com/wucongyou/go-jvm/class"
+)
+
+const (
+	// FormatVersion identifies the schema of the dump, bumped on any
+	// incompatible change.
+	FormatVersion = "go-jvm/classfile/v1"
+)
+
+// Class dump of a class file.
+type Class struct {
+	Format       string      `json:"format"`
+	Magic        string      `json:"magic"`
+	MinorVersion uint16      `json:"minor_version"`
+	MajorVersion uint16      `json:"major_version"`
+	ConstantPool []*Constant `json:"constant_pool"`
+	AccessFlags  *Flags      `json:"access_flags"`
+	ThisClass    string      `json:"this_class"`
+	// SuperClass empty for java/lang/Object and module-info.
+	SuperClass string       `json:"super_class"`
+	Interfaces []string     `json:"interfaces"`
+	Fields     []*Member    `json:"fields"`
+	Methods    []*Member    `json:"methods"`
+	Attributes []*Attribute `json:"attributes"`
+}
+
+// Constant constant pool entry, the unusable slot after a Long or Double is
+// omitted. Value holds the value of Utf8, String, Class, Module, Package,
+// MethodType and numeric entries, a float is rendered as the shortest decimal
+// that identifies it, or NaN, Infinity and -Infinity.
+type Constant struct {
+	Index      int    `json:"index"`
+	Tag        string `json:"tag"`
+	Value      string `json:"value,omitempty"`
+	Class      string `json:"class,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Descriptor string `json:"descriptor,omitempty"`
+	// BootstrapMethod index into BootstrapMethods of Dynamic and
+	// InvokeDynamic.
+	BootstrapMethod *int `json:"bootstrap_method,omitempty"`
+	// ReferenceKind of MethodHandle, e.g. REF_invokeStatic.
+	ReferenceKind string `json:"reference_kind,omitempty"`
+}
+
+// Flags access flags.
+type Flags struct {
+	Value uint16   `json:"value"`
+	Names []string `json:"names"`
+}
+
+// Member field or method.
+type Member struct {
+	Name        string `json:"name"`
+	Descriptor  string `json:"descriptor"`
+	AccessFlags *Flags `json:"access_flags"`
+	// Attributes attributes except Code.
+	Attributes []*Attribute `json:"attributes"`
+	Code       *Code        `json:"code,omitempty"`
+}
+
+// Code Code attribute.
+type Code struct {
+	MaxStack       uint16            `json:"max_stack"`
+	MaxLocals      uint16            `json:"max_locals"`
+	Instructions   []*Instruction    `json:"instructions"`
+	ExceptionTable []*ExceptionEntry `json:"exception_table"`
+	Attributes     []*Attribute      `json:"attributes"`
+}
+
+// Instruction decoded instruction, branch targets are absolute offsets.
+type Instruction struct {
+	Offset   int     `json:"offset"`
+	Opcode   string  `json:"opcode"`
+	Wide     bool    `json:"wide,omitempty"`
+	Operands []int32 `json:"operands"`
+	// Constant symbolic value of the constant pool operand.
+	Constant string `json:"constant,omitempty"`
+	// Default and Targets of tableswitch and lookupswitch.
+	Default *int    `json:"default,omitempty"`
+	Targets []*Case `json:"targets,omitempty"`
+}
+
+// Case switch case.
+type Case struct {
+	Key    int32 `json:"key"`
+	Target int   `json:"target"`
+}
+
+// ExceptionEntry exception table entry, CatchType is empty for any.
+type ExceptionEntry struct {
+	StartPc   uint16 `json:"start_pc"`
+	EndPc     uint16 `json:"end_pc"`
+	HandlerPc uint16 `json:"handler_pc"`
+	CatchType string `json:"catch_type"`
+}
+
+// Attribute attribute, Value holds the decoded value of a known attribute,
+// Info the hex encoded bytes of a custom or unknown one.
+type Attribute struct {
+	Name   string      `json:"name"`
+	Length int         `json:"length"`
+	Value  interface{} `json:"value,omitempty"`
+	Info   string      `json:"info,omitempty"`
+}
+
+// LineNumber LineNumberTable entry.
+type LineNumber struct {
+	StartPc uint16 `json:"start_pc"`
+	Line    uint16 `json:"line"`
+}
+
+// LocalVariable LocalVariableTable or LocalVariableTypeTable entry,
+// Descriptor holds the signature for the latter.
+type LocalVariable struct {
+	StartPc    uint16 `json:"start_pc"`
+	Length     uint16 `json:"length"`
+	Name       string `json:"name"`
+	Descriptor string `json:"descriptor"`
+	Index      uint16 `json:"index"`
+}
+
+// InnerClass InnerClasses entry, Outer and Name are empty for a local or
+// anonymous class.
+type InnerClass struct {
+	Inner       string `json:"inner"`
+	Outer       string `json:"outer,omitempty"`
+	Name        string `json:"name,omitempty"`
+	AccessFlags *Flags `json:"access_flags"`
+}
+
+// EnclosingMethod EnclosingMethod value, Method is empty if the class is not
+// enclosed by a method.
+type EnclosingMethod struct {
+	Class  string `json:"class"`
+	Method string `json:"method,omitempty"`
+}
+
+// BootstrapMethod BootstrapMethods entry.
+type BootstrapMethod struct {
+	Method    string   `json:"method"`
+	Arguments []string `json:"arguments"`
+}
+
+// Parameter MethodParameters entry, Name is empty for a parameter without
+// name.
+type Parameter struct {
+	Name        string `json:"name,omitempty"`
+	AccessFlags *Flags `json:"access_flags"`
+}
+
+// RecordComponent Record component.
+type RecordComponent struct {
+	Name       string       `json:"name"`
+	Descriptor string       `json:"descriptor"`
+	Attributes []*Attribute `json:"attributes"`
+}
+
+// Annotation annotation, Type is the field descriptor of the annotation
+// interface.
+type Annotation struct {
+	Type     string          `json:"type"`
+	Elements []*ElementValue `json:"elements"`
+}
+
+// ElementValue element value, Name is set for the elements of an annotation
+// only. Value holds constants, enum constants as Type.NAME and class
+// descriptors, Annotation and Values nested annotations and arrays.
+type ElementValue struct {
+	Name       string          `json:"name,omitempty"`
+	Tag        string          `json:"tag"`
+	Value      string          `json:"value,omitempty"`
+	Annotation *Annotation     `json:"annotation,omitempty"`
+	Values     []*ElementValue `json:"values,omitempty"`
+}
+
+// TypeAnnotation type annotation, Target is the target type followed by the
+// target info and type path like javap prints them.
+type TypeAnnotation struct {
+	Target     string      `json:"target"`
+	Annotation *Annotation `json:"annotation"`
+}
+
+// Frame StackMapTable entry, Offset is the bytecode offset the frame applies
+// to. Locals holds the added locals of an append frame, Chopped the number
+// of locals removed by a chop frame.
+type Frame struct {
+	Type    string   `json:"type"`
+	Offset  int      `json:"offset"`
+	Chopped int      `json:"chopped,omitempty"`
+	Locals  []string `json:"locals,omitempty"`
+	Stack   []string `json:"stack,omitempty"`
+}
+
+// Module Module attribute, package and class names are in internal form.
+type Module struct {
+	Name     string      `json:"name"`
+	Flags    *Flags      `json:"flags"`
+	Version  string      `json:"version,omitempty"`
+	Requires []*Requires `json:"requires"`
+	Exports  []*Exports  `json:"exports"`
+	Opens    []*Exports  `json:"opens"`
+	Uses     []string    `json:"uses"`
+	Provides []*Provides `json:"provides"`
+}
+
+// Requires module dependence.
+type Requires struct {
+	Name    string `json:"name"`
+	Flags   *Flags `json:"flags"`
+	Version string `json:"version,omitempty"`
+}
+
+// Exports exported or opened package, To lists the modules of a qualified
+// export or open.
+type Exports struct {
+	Package string   `json:"package"`
+	Flags   *Flags   `json:"flags"`
+	To      []string `json:"to,omitempty"`
+}
+
+// Provides service implementations.
+type Provides struct {
+	Service string   `json:"service"`
+	With    []string `json:"with"`
+}
+
+// WriteJSON writes the dump of cf to w as indented JSON.
+func WriteJSON(w io.Writer, cf *class.ClassFile) (err error) {
+	var c *Class
+	if c, err = New(cf); err != nil {
+		return
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(c)
+}
+
+// WriteYAML writes the dump of cf to w as YAML.
+func WriteYAML(w io.Writer, cf *class.ClassFile) (err error) {
+	var c *Class
+	if c, err = New(cf); err != nil {
+		return
+	}
+	return encodeYAML(w, c)
+}
+
+// New returns the dump of cf.
+func New(cf *class.ClassFile) (res *Class, err error) {
+	d := &dumper{cp: cf.CpInfo, pool: cf.ConstantPool()}
+	res = &Class{
+		Format:       FormatVersion,
+		Magic:        fmt.Sprintf("%08x", cf.Magic),
+		MinorVersion: cf.MinorVersion,
+		MajorVersion: cf.MajorVersion,
+		ConstantPool: make([]*Constant, 0, len(cf.CpInfo)),
+		AccessFlags:  &Flags{Value: cf.AccessFlags, Names: class.ClassAccessFlagNames(cf.AccessFlags)},
+		Interfaces:   make([]string, 0, len(cf.Interfaces)),
+		Fields:       make([]*Member, 0, len(cf.Fields)),
+		Methods:      make([]*Member, 0, len(cf.Methods)),
+	}
+	for i := 1; i < len(cf.CpInfo); i++ {
+		if cf.CpInfo[i] == nil {
+			continue
+		}
+		var c *Constant
+		if c, err = d.constant(uint16(i)); err != nil {
+			return nil, err
+		}
+		res.ConstantPool = append(res.ConstantPool, c)
+	}
+	if res.ThisClass, err = d.class(cf.ThisClass); err != nil {
+		return nil, err
+	}
+	if cf.SuperClass != 0 {
+		if res.SuperClass, err = d.class(cf.SuperClass); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range cf.Interfaces {
+		var n string
+		if n, err = d.class(c.NameIndex); err != nil {
+			return nil, err
+		}
+		res.Interfaces = append(res.Interfaces, n)
+	}
+	for _, f := range cf.Fields {
+		var m *Member
+		if m, err = d.member(f, nil, class.FieldAccessFlagNames(f.AccessFlags)); err != nil {
+			return nil, err
+		}
+		res.Fields = append(res.Fields, m)
+	}
+	for _, f := range cf.Methods {
+		var m *Member
+		if m, err = d.member(&f.FieldInfo, f, class.MethodAccessFlagNames(f.AccessFlags)); err != nil {
+			return nil, err
+		}
+		res.Methods = append(res.Methods, m)
+	}
+	if res.Attributes, err = d.attributes(cf.Attributes); err != nil {
+		return nil, err
+	}
+	return
+}
+
+type dumper struct {
+	cp   []class.ConstantInfo
+	pool *class.ConstantPool
+}
+
+func (d *dumper) entry(i uint16) (class.ConstantInfo, error) {
+	return d.pool.Entry(i)
+}
+
+func (d *dumper) utf8(i uint16) (string, error) {
+	return d.pool.Utf8(i)
+}
+
+func (d *dumper) class(i uint16) (string, error) {
+	return d.pool.Class(i)
+}
+
+func (d *dumper) constant(i uint16) (res *Constant, err error) {
+	var c class.ConstantInfo
+	if c, err = d.entry(i); err != nil {
+		return
+	}
+	res = &Constant{Index: int(i), Tag: c.TN()}
+	switch u := c.(type) {
+	case *class.ClassInfo:
+		res.Value, err = u.ParseNameFromPool(d.cp)
+	case *class.FieldRefInfo:
+		err = d.memberRef(res, u)
+	case *class.MethodRefInfo:
+		err = d.memberRef(res, &u.FieldRefInfo)
+	case *class.InterfaceMethodRefInfo:
+		err = d.memberRef(res, &u.FieldRefInfo)
+	case *class.StringInfo:
+		res.Value, err = u.ParseStringFromPool(d.cp)
+	case *class.IntegerInfo:
+		res.Value = strconv.FormatInt(int64(u.Int32()), 10)
+	case *class.FloatInfo:
+		res.Value = floatString(float64(u.Float32()), 32)
+	case *class.LongInfo:
+		res.Value = strconv.FormatInt(u.Int64(), 10)
+	case *class.DoubleInfo:
+		res.Value = floatString(u.Float64(), 64)
+	case *class.NameAndType:
+		res.Name, res.Descriptor, err = u.ParseFromPool(d.cp)
+	case *class.Utf8Info:
+		res.Value, err = class.DecodeString(u.Bytes)
+	case *class.MethodHandle:
+		res.ReferenceKind = class.RefKindName(u.ReferenceKind)
+		var r *Constant
+		if r, err = d.constant(u.ReferenceIndex); err != nil {
+			return
+		}
+		res.Class, res.Name, res.Descriptor = r.Class, r.Name, r.Descriptor
+	case *class.MethodTypeInfo:
+		res.Value, err = d.utf8(u.DescriptorIndex)
+	case *class.DynamicInfo:
+		bm := int(u.BootstrapMethodAttrIndex)
+		res.BootstrapMethod = &bm
+		res.Name, res.Descriptor, err = u.ParseNameAndTypeFromPool(d.cp)
+	case *class.InvokeDynamicInfo:
+		bm := int(u.BootstrapMethodAttrIndex)
+		res.BootstrapMethod = &bm
+		res.Name, res.Descriptor, err = u.ParseNameAndTypeFromPool(d.cp)
+	case *class.ModuleInfo:
+		res.Value, err = u.ParseNameFromPool(d.cp)
+	case *class.PackageInfo:
+		res.Value, err = u.ParseNameFromPool(d.cp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func floatString(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+func (d *dumper) memberRef(res *Constant, u *class.FieldRefInfo) (err error) {
+	if res.Class, err = u.ParseClassFromPool(d.cp); err != nil {
+		return
+	}
+	res.Name, res.Descriptor, err = u.ParseNameAndTypeFromPool(d.cp)
+	return
+}
+
+// symbol returns the symbolic value of constant pool entry i as a single
+// string, e.g. java/lang/Object."<init>":()V.
+func (d *dumper) symbol(i uint16) (res string, err error) {
+	var c *Constant
+	if c, err = d.constant(i); err != nil {
+		return
+	}
+	switch {
+	case c.Class != "":
+		return fmt.Sprintf("%s.%s:%s", c.Class, c.Name, c.Descriptor), nil
+	case c.Name != "":
+		return fmt.Sprintf("%s:%s", c.Name, c.Descriptor), nil
+	}
+	return c.Value, nil
+}
+
+func (d *dumper) member(f *class.FieldInfo, mi *class.MethodInfo, flags []string) (res *Member, err error) {
+	res = &Member{AccessFlags: &Flags{Value: f.AccessFlags, Names: flags}}
+	if res.Name, err = d.utf8(f.NameIndex); err != nil {
+		return nil, err
+	}
+	if res.Descriptor, err = d.utf8(f.DescriptorIndex); err != nil {
+		return nil, err
+	}
+	as := f.Attributes
+	if mi != nil && mi.Code != nil {
+		if res.Code, err = d.code(mi.Code); err != nil {
+			return nil, err
+		}
+		as = make([]*class.AttributeInfo, 0, len(f.Attributes))
+		for _, a := range f.Attributes {
+			if _, ok := a.Value.(*class.CodeAttribute); !ok {
+				as = append(as, a)
+			}
+		}
+	}
+	if res.Attributes, err = d.attributes(as); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (d *dumper) code(c *class.CodeAttribute) (res *Code, err error) {
+	res = &Code{
+		MaxStack:       c.MaxStack,
+		MaxLocals:      c.MaxLocals,
+		ExceptionTable: make([]*ExceptionEntry, 0, len(c.ExceptionTable)),
+	}
+	var insts []*bytecode.Instruction
+	if insts, err = bytecode.Decode(c.Code); err != nil {
+		return nil, err
+	}
+	res.Instructions = make([]*Instruction, 0, len(insts))
+	for _, in := range insts {
+		var di *Instruction
+		if di, err = d.instruction(in); err != nil {
+			return nil, err
+		}
+		res.Instructions = append(res.Instructions, di)
+	}
+	for _, e := range c.ExceptionTable {
+		de := &ExceptionEntry{StartPc: e.StartPc, EndPc: e.EndPc, HandlerPc: e.HandlerPc}
+		if e.CatchType != 0 {
+			if de.CatchType, err = d.class(e.CatchType); err != nil {
+				return nil, err
+			}
+		}
+		res.ExceptionTable = append(res.ExceptionTable, de)
+	}
+	if res.Attributes, err = d.attributes(c.Attributes); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (d *dumper) instruction(in *bytecode.Instruction) (res *Instruction, err error) {
+	res = &Instruction{Offset: in.Offset, Opcode: in.Opcode.String(), Wide: in.Wide, Operands: in.Operands}
+	if res.Operands == nil {
+		res.Operands = make([]int32, 0)
+	}
+	switch {
+	case in.Switch != nil:
+		def := in.Offset + int(in.Switch.Default)
+		res.Default = &def
+		res.Targets = make([]*Case, len(in.Switch.Keys))
+		for i, k := range in.Switch.Keys {
+			res.Targets[i] = &Case{Key: k, Target: in.Offset + int(in.Switch.Offsets[i])}
+		}
+	case in.IsBranch():
+		res.Operands = []int32{int32(in.Branch())}
+	case in.HasConstant():
+		res.Constant, err = d.symbol(uint16(in.Operands[0]))
+	}
+	return
+}
+
+func (d *dumper) attributes(as []*class.AttributeInfo) (res []*Attribute, err error) {
+	res = make([]*Attribute, 0, len(as))
+	for _, a := range as {
+		var da *Attribute
+		if da, err = d.attribute(a); err != nil {
+			return nil, err
+		}
+		res = append(res, da)
+	}
+	return
+}
+
+func (d *dumper) attribute(a *class.AttributeInfo) (res *Attribute, err error) {
+	res = &Attribute{Name: a.Name, Length: len(a.Info)}
+	if res.Name == "" {
+		if res.Name, err = d.utf8(a.AttributeNameIndex); err != nil {
+			return nil, err
+		}
+	}
+	switch u := a.Value.(type) {
+	case *class.SourceFileAttribute:
+		res.Value, err = d.utf8(u.SourcefileIndex)
+	case *class.SignatureAttribute:
+		res.Value, err = d.utf8(u.SignatureIndex)
+	case *class.ConstantValueAttribute:
+		res.Value, err = d.symbol(u.ConstantValueIndex)
+	case *class.DeprecatedAttribute, *class.SyntheticAttribute:
+		res.Value = true
+	case *class.ExceptionsAttribute:
+		res.Value, err = d.classes(u.ExceptionIndexTable)
+	case *class.LineNumberTableAttribute:
+		ls := make([]*LineNumber, 0, len(u.LineNumberTable))
+		for _, l := range u.LineNumberTable {
+			ls = append(ls, &LineNumber{StartPc: l.StartPc, Line: l.LineNumber})
+		}
+		res.Value = ls
+	case *class.LocalVariableTableAttribute:
+		ls := make([]*LocalVariable, 0, len(u.LocalVariableTable))
+		for _, l := range u.LocalVariableTable {
+			var dl *LocalVariable
+			if dl, err = d.localVariable(l.StartPc, l.Length, l.NameIndex, l.DescriptorIndex, l.Index); err != nil {
+				return nil, err
+			}
+			ls = append(ls, dl)
+		}
+		res.Value = ls
+	case *class.LocalVariableTypeTableAttribute:
+		ls := make([]*LocalVariable, 0, len(u.LocalVariableTypeTable))
+		for _, l := range u.LocalVariableTypeTable {
+			var dl *LocalVariable
+			if dl, err = d.localVariable(l.StartPc, l.Length, l.NameIndex, l.SignatureIndex, l.Index); err != nil {
+				return nil, err
+			}
+			ls = append(ls, dl)
+		}
+		res.Value = ls
+	case *class.InnerClassesAttribute:
+		cs := make([]*InnerClass, 0, len(u.Classes))
+		for _, c := range u.Classes {
+			var dc *InnerClass
+			if dc, err = d.innerClass(c); err != nil {
+				return nil, err
+			}
+			cs = append(cs, dc)
+		}
+		res.Value = cs
+	case *class.EnclosingMethodAttribute:
+		em := new(EnclosingMethod)
+		if em.Class, err = d.class(u.ClassIndex); err == nil && u.MethodIndex != 0 {
+			em.Method, err = d.symbol(u.MethodIndex)
+		}
+		res.Value = em
+	case *class.SourceDebugExtensionAttribute:
+		res.Value, err = class.DecodeString(u.DebugExtension)
+	case *class.BootstrapMethodsAttribute:
+		bs := make([]*BootstrapMethod, 0, len(u.BootstrapMethods))
+		for _, b := range u.BootstrapMethods {
+			db := &BootstrapMethod{Arguments: make([]string, 0, len(b.BootstrapArguments))}
+			if db.Method, err = d.symbol(b.BootstrapMethodRef); err != nil {
+				return nil, err
+			}
+			for _, i := range b.BootstrapArguments {
+				var arg string
+				if arg, err = d.symbol(i); err != nil {
+					return nil, err
+				}
+				db.Arguments = append(db.Arguments, arg)
+			}
+			bs = append(bs, db)
+		}
+		res.Value = bs
+	case *class.MethodParametersAttribute:
+		ps := make([]*Parameter, 0, len(u.Parameters))
+		for _, p := range u.Parameters {
+			dp := &Parameter{AccessFlags: &Flags{Value: p.AccessFlags, Names: class.ParameterFlags(p.AccessFlags).Names()}}
+			if p.NameIndex != 0 {
+				if dp.Name, err = d.utf8(p.NameIndex); err != nil {
+					return nil, err
+				}
+			}
+			ps = append(ps, dp)
+		}
+		res.Value = ps
+	case *class.NestHostAttribute:
+		res.Value, err = d.class(u.HostClassIndex)
+	case *class.NestMembersAttribute:
+		res.Value, err = d.classes(u.Classes)
+	case *class.PermittedSubclassesAttribute:
+		res.Value, err = d.classes(u.Classes)
+	case *class.RecordAttribute:
+		cs := make([]*RecordComponent, 0, len(u.Components))
+		for _, c := range u.Components {
+			dc := new(RecordComponent)
+			if dc.Name, err = d.utf8(c.NameIndex); err != nil {
+				return nil, err
+			}
+			if dc.Descriptor, err = d.utf8(c.DescriptorIndex); err != nil {
+				return nil, err
+			}
+			if dc.Attributes, err = d.attributes(c.Attributes); err != nil {
+				return nil, err
+			}
+			cs = append(cs, dc)
+		}
+		res.Value = cs
+	case *class.RuntimeVisibleAnnotationsAttribute:
+		res.Value, err = d.annotations(u.Annotations)
+	case *class.RuntimeInvisibleAnnotationsAttribute:
+		res.Value, err = d.annotations(u.Annotations)
+	case *class.RuntimeVisibleParameterAnnotationsAttribute:
+		res.Value, err = d.parameterAnnotations(u.ParameterAnnotations)
+	case *class.RuntimeInvisibleParameterAnnotationsAttribute:
+		res.Value, err = d.parameterAnnotations(u.ParameterAnnotations)
+	case *class.RuntimeVisibleTypeAnnotationsAttribute:
+		res.Value, err = d.typeAnnotations(u.Annotations)
+	case *class.RuntimeInvisibleTypeAnnotationsAttribute:
+		res.Value, err = d.typeAnnotations(u.Annotations)
+	case *class.StackMapTableAttribute:
+		fs := make([]*Frame, 0, len(u.Entries))
+		for _, f := range u.Entries {
+			df := &Frame{Type: f.Kind(), Offset: f.Offset, Chopped: f.Chopped()}
+			if df.Locals, err = d.verificationTypes(f.Locals); err != nil {
+				return nil, err
+			}
+			if df.Stack, err = d.verificationTypes(f.Stack); err != nil {
+				return nil, err
+			}
+			fs = append(fs, df)
+		}
+		res.Value = fs
+	case *class.ModuleAttribute:
+		var md *class.Module
+		if md, err = u.ParseFromPool(d.cp); err != nil {
+			return nil, err
+		}
+		res.Value = module(md)
+	case *class.ModulePackagesAttribute:
+		ps := make([]string, 0, len(u.PackageIndex))
+		for _, i := range u.PackageIndex {
+			var p *Constant
+			if p, err = d.constant(i); err != nil {
+				return nil, err
+			}
+			ps = append(ps, p.Value)
+		}
+		res.Value = ps
+	case *class.ModuleMainClassAttribute:
+		res.Value, err = d.class(u.MainClassIndex)
+	case *class.AnnotationDefaultAttribute:
+		res.Value, err = d.elementValue(u.DefaultValue)
+	default:
+		// a custom attribute registered with class.RegisterAttributeDecoder
+		// is dumped as is next to its info
+		res.Value = a.Value
+		res.Info = hex.EncodeToString(a.Info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (d *dumper) classes(is []uint16) (res []string, err error) {
+	res = make([]string, 0, len(is))
+	for _, i := range is {
+		var c string
+		if c, err = d.class(i); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return
+}
+
+func (d *dumper) localVariable(start, length, ni, di, index uint16) (res *LocalVariable, err error) {
+	res = &LocalVariable{StartPc: start, Length: length, Index: index}
+	if res.Name, err = d.utf8(ni); err != nil {
+		return nil, err
+	}
+	if res.Descriptor, err = d.utf8(di); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (d *dumper) innerClass(c *class.InnerClass) (res *InnerClass, err error) {
+	res = &InnerClass{AccessFlags: &Flags{Value: c.InnerClassAccessFlags, Names: class.InnerClassFlags(c.InnerClassAccessFlags).Names()}}
+	if res.Inner, err = d.class(c.InnerClassInfoIndex); err != nil {
+		return nil, err
+	}
+	if c.OuterClassInfoIndex != 0 {
+		if res.Outer, err = d.class(c.OuterClassInfoIndex); err != nil {
+			return nil, err
+		}
+	}
+	if c.InnerNameIndex != 0 {
+		if res.Name, err = d.utf8(c.InnerNameIndex); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (d *dumper) annotations(as []*class.Annotation) (res []*Annotation, err error) {
+	res = make([]*Annotation, 0, len(as))
+	for _, a := range as {
+		var da *Annotation
+		if da, err = d.annotation(a); err != nil {
+			return nil, err
+		}
+		res = append(res, da)
+	}
+	return
+}
+
+func (d *dumper) parameterAnnotations(ps []*class.ParameterAnnotations) (res [][]*Annotation, err error) {
+	res = make([][]*Annotation, 0, len(ps))
+	for _, p := range ps {
+		var as []*Annotation
+		if as, err = d.annotations(p.Annotations); err != nil {
+			return nil, err
+		}
+		res = append(res, as)
+	}
+	return
+}
+
+func (d *dumper) typeAnnotations(as []*class.TypeAnnotation) (res []*TypeAnnotation, err error) {
+	res = make([]*TypeAnnotation, 0, len(as))
+	for _, a := range as {
+		da := &TypeAnnotation{Target: a.TargetString()}
+		if da.Annotation, err = d.annotation(&a.Annotation); err != nil {
+			return nil, err
+		}
+		res = append(res, da)
+	}
+	return
+}
+
+func (d *dumper) annotation(a *class.Annotation) (res *Annotation, err error) {
+	res = &Annotation{Elements: make([]*ElementValue, 0, len(a.ElementValuePairs))}
+	if res.Type, err = d.utf8(a.TypeIndex); err != nil {
+		return nil, err
+	}
+	for _, p := range a.ElementValuePairs {
+		var v *ElementValue
+		if v, err = d.elementValue(p.Value); err != nil {
+			return nil, err
+		}
+		if v.Name, err = d.utf8(p.ElementNameIndex); err != nil {
+			return nil, err
+		}
+		res.Elements = append(res.Elements, v)
+	}
+	return
+}
+
+func (d *dumper) elementValue(v *class.ElementValue) (res *ElementValue, err error) {
+	res = &ElementValue{Tag: string(rune(v.Tag))}
+	switch v.Tag {
+	case class.ElementString:
+		res.Value, err = d.utf8(v.ConstValueIndex)
+	case class.ElementEnum:
+		var t, c string
+		if t, err = d.utf8(v.TypeNameIndex); err == nil {
+			c, err = d.utf8(v.ConstNameIndex)
+		}
+		res.Value = t + "." + c
+	case class.ElementClass:
+		res.Value, err = d.utf8(v.ClassInfoIndex)
+	case class.ElementAnnotation:
+		res.Annotation, err = d.annotation(v.AnnotationValue)
+	case class.ElementArray:
+		res.Values = make([]*ElementValue, 0, len(v.Values))
+		for _, e := range v.Values {
+			var de *ElementValue
+			if de, err = d.elementValue(e); err != nil {
+				return nil, err
+			}
+			res.Values = append(res.Values, de)
+		}
+	default:
+		res.Value, err = d.symbol(v.ConstValueIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// verificationTypes returns the verification types as javap prints them,
+// e.g. int or class java/lang/String.
+func (d *dumper) verificationTypes(vs []*class.VerificationTypeInfo) (res []string, err error) {
+	for _, v := range vs {
+		var t string
+		switch v.Tag {
+		case class.ItemTop:
+			t = "top"
+		case class.ItemInteger:
+			t = "int"
+		case class.ItemFloat:
+			t = "float"
+		case class.ItemDouble:
+			t = "double"
+		case class.ItemLong:
+			t = "long"
+		case class.ItemNull:
+			t = "null"
+		case class.ItemUninitializedThis:
+			t = "this"
+		case class.ItemObject:
+			if t, err = d.class(v.CpoolIndex); err != nil {
+				return nil, err
+			}
+			t = "class " + t
+		case class.ItemUninitialized:
+			t = fmt.Sprintf("uninitialized %d", v.Offset)
+		}
+		res = append(res, t)
+	}
+	return
+}
+
+func module(md *class.Module) (res *Module) {
+	res = &Module{
+		Name:     md.Name,
+		Flags:    &Flags{Value: uint16(md.Flags), Names: md.Flags.Names()},
+		Version:  md.Version,
+		Requires: make([]*Requires, 0, len(md.Requires)),
+		Exports:  exports(md.Exports),
+		Opens:    exports(md.Opens),
+		Uses:     append(make([]string, 0, len(md.Uses)), md.Uses...),
+		Provides: make([]*Provides, 0, len(md.Provides)),
+	}
+	for _, r := range md.Requires {
+		res.Requires = append(res.Requires, &Requires{Name: r.Name, Flags: &Flags{Value: uint16(r.Flags), Names: r.Flags.Names()}, Version: r.Version})
+	}
+	for _, p := range md.Provides {
+		res.Provides = append(res.Provides, &Provides{Service: p.Service, With: p.With})
+	}
+	return
+}
+
+func exports(es []*class.ModuleExports) (res []*Exports) {
+	res = make([]*Exports, 0, len(es))
+	for _, e := range es {
+		res = append(res, &Exports{Package: e.Package, Flags: &Flags{Value: uint16(e.Flags), Names: e.Flags.Names()}, To: e.To})
+	}
+	return
+}
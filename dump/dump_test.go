@@ -0,0 +1,83 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+func TestWriteJSON(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	b := new(bytes.Buffer)
+	if err = WriteJSON(b, cf); err != nil {
+		t.Errorf("failed to write json, error(%v)", err)
+		t.FailNow()
+	}
+	res := new(Class)
+	if err = json.Unmarshal(b.Bytes(), res); err != nil {
+		t.Errorf("failed to unmarshal, error(%v)", err)
+		t.FailNow()
+	}
+	if res.Format != FormatVersion || res.ThisClass != "HelloWorld" || res.SuperClass != "java/lang/Object" {
+		t.Errorf("unexpected class %+v", res)
+	}
+	m := res.Methods[1]
+	if m.Name != "main" || m.Code == nil || m.Code.Instructions[2].Constant != "java/io/PrintStream.println:(Ljava/lang/String;)V" {
+		t.Errorf("unexpected method %+v", m)
+	}
+	if len(m.Attributes) != 0 || m.Code.Attributes[0].Name != "LineNumberTable" {
+		t.Errorf("unexpected attributes %+v %+v", m.Attributes, m.Code.Attributes)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	b := new(bytes.Buffer)
+	if err = WriteYAML(b, cf); err != nil {
+		t.Errorf("failed to write yaml, error(%v)", err)
+		t.FailNow()
+	}
+	s := b.String()
+	for _, exp := range []string{
+		"format: \"" + FormatVersion + "\"\n",
+		"constant_pool:\n  - index: 1\n    tag: \"Methodref\"\n    class: \"java/lang/Object\"\n",
+		"  names:\n    - \"ACC_PUBLIC\"\n    - \"ACC_SUPER\"\n",
+		"interfaces: []\n",
+	} {
+		if !strings.Contains(s, exp) {
+			t.Errorf("missing %q in:\n%s", exp, s)
+		}
+	}
+}
+
+func TestNewInterfaces(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	n := uint16(len(cf.CpInfo))
+	cf.CpInfo = append(cf.CpInfo,
+		&class.Utf8Info{Tag: class.Tag{Tag: 1}, Length: 18, Bytes: []byte("java/lang/Runnable")},
+		&class.ClassInfo{Tag: class.Tag{Tag: 7}, NameIndex: n})
+	cf.Interfaces = []*class.ClassInfo{{NameIndex: n + 1}}
+	res, err := New(cf)
+	if err != nil {
+		t.Errorf("failed to dump, error(%v)", err)
+		t.FailNow()
+	}
+	if len(res.Interfaces) != 1 || res.Interfaces[0] != "java/lang/Runnable" {
+		t.Errorf("unexpected interfaces %v", res.Interfaces)
+	}
+}
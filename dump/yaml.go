@@ -0,0 +1,196 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// encodeYAML writes v as block style YAML, struct fields are written in
+// declaration order under their json names, strings are always double
+// quoted.
+func encodeYAML(w io.Writer, v interface{}) error {
+	e := &yamlEncoder{w: bufio.NewWriter(w)}
+	e.value(reflect.ValueOf(v), 0, false)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type yamlEncoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *yamlEncoder) printf(format string, a ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, a...)
+}
+
+// scalar returns the YAML of a scalar value, ok is false for a mapping or a
+// sequence. Values are rendered like encoding/json renders them, a byte
+// slice as its base64 string.
+func (e *yamlEncoder) scalar(v reflect.Value) (res string, ok bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "null", true
+		}
+		return e.scalar(v.Elem())
+	case reflect.String:
+		return e.json(v.String()), true
+	case reflect.Bool:
+		return fmt.Sprintf("%t", v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", v.Uint()), true
+	case reflect.Float32:
+		return e.json(float32(v.Float())), true
+	case reflect.Float64:
+		return e.json(v.Float()), true
+	case reflect.Slice:
+		switch {
+		case v.IsNil():
+			return "null", true
+		case v.Type().Elem().Kind() == reflect.Uint8:
+			return e.json(v.Bytes()), true
+		case v.Len() == 0:
+			return "[]", true
+		}
+	case reflect.Array:
+		if v.Len() == 0 {
+			return "[]", true
+		}
+	case reflect.Map:
+		switch {
+		case v.IsNil():
+			return "null", true
+		case v.Len() == 0:
+			return "{}", true
+		}
+	case reflect.Struct:
+		if v.NumField() == 0 {
+			return "{}", true
+		}
+	}
+	return "", false
+}
+
+// json returns the JSON of a scalar, a YAML flow scalar as well.
+func (e *yamlEncoder) json(v interface{}) string {
+	b := new(bytes.Buffer)
+	je := json.NewEncoder(b)
+	je.SetEscapeHTML(false)
+	if err := je.Encode(v); err != nil && e.err == nil {
+		e.err = err
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// value writes v at the given indent level, inline is set when the first line
+// continues a "- " sequence entry.
+func (e *yamlEncoder) value(v reflect.Value, indent int, inline bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	pad := strings.Repeat("  ", indent)
+	// prefix writes the indent of the next line, the first one of an inline
+	// value is already indented
+	first := true
+	prefix := func() {
+		if !(first && inline) {
+			e.printf("%s", pad)
+		}
+		first = false
+	}
+	entry := func(key string, fv reflect.Value) {
+		prefix()
+		if s, ok := e.scalar(fv); ok {
+			e.printf("%s: %s\n", key, s)
+			return
+		}
+		e.printf("%s:\n", key)
+		e.value(fv, indent+1, false)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, omitEmpty := jsonName(t.Field(i))
+			fv := v.Field(i)
+			if name == "" || (omitEmpty && isEmpty(fv)) {
+				continue
+			}
+			entry(name, fv)
+		}
+	case reflect.Map:
+		// keys sorted like encoding/json sorts them, always quoted
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			k := fmt.Sprint(it.Key().Interface())
+			keys = append(keys, k)
+			values[k] = it.Value()
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entry(e.json(k), values[k])
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			ev := v.Index(i)
+			prefix()
+			if s, ok := e.scalar(ev); ok {
+				e.printf("- %s\n", s)
+				continue
+			}
+			e.printf("- ")
+			e.value(ev, indent+1, true)
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) (name string, omitEmpty bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" || f.PkgPath != "" {
+		return
+	}
+	ps := strings.Split(tag, ",")
+	name = ps[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, p := range ps[1:] {
+		if p == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
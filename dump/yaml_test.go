@@ -0,0 +1,163 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+var (
+	_yamlKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*:( |$)`)
+)
+
+type yamlLine struct {
+	col  int
+	text string
+}
+
+// parseYAML parses the block style YAML written by encodeYAML, scalars are
+// JSON.
+func parseYAML(s string) (res interface{}, err error) {
+	var ls []yamlLine
+	for _, l := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		t := strings.TrimLeft(l, " ")
+		ls = append(ls, yamlLine{col: len(l) - len(t), text: t})
+	}
+	if res, ls, err = yamlNode(ls); err == nil && len(ls) > 0 {
+		err = fmt.Errorf("unexpected line %q", ls[0].text)
+	}
+	return
+}
+
+// yamlKey splits a mapping entry into its key and the rest, ok is false for
+// a scalar.
+func yamlKey(t string) (key, rest string, ok bool) {
+	if !strings.HasPrefix(t, `"`) {
+		if !_yamlKey.MatchString(t) {
+			return
+		}
+		i := strings.Index(t, ":")
+		return t[:i], strings.TrimPrefix(t[i+1:], " "), true
+	}
+	d := json.NewDecoder(strings.NewReader(t))
+	if d.Decode(&key) != nil {
+		return
+	}
+	rest = t[d.InputOffset():]
+	if !strings.HasPrefix(rest, ":") {
+		return
+	}
+	return key, strings.TrimPrefix(rest[1:], " "), true
+}
+
+func yamlNode(ls []yamlLine) (res interface{}, rest []yamlLine, err error) {
+	col, t := ls[0].col, ls[0].text
+	if t == "-" || strings.HasPrefix(t, "- ") {
+		seq := make([]interface{}, 0)
+		for len(ls) > 0 && ls[0].col == col && strings.HasPrefix(ls[0].text, "- ") {
+			var v interface{}
+			if v, ls, err = yamlNode(append([]yamlLine{{col + 2, ls[0].text[2:]}}, ls[1:]...)); err != nil {
+				return
+			}
+			seq = append(seq, v)
+		}
+		return seq, ls, nil
+	}
+	if _, _, ok := yamlKey(t); ok {
+		m := make(map[string]interface{})
+		for len(ls) > 0 && ls[0].col == col {
+			k, v, ok := yamlKey(ls[0].text)
+			if !ok {
+				return nil, nil, fmt.Errorf("expected mapping entry, got %q", ls[0].text)
+			}
+			ls = ls[1:]
+			if v != "" {
+				if m[k], err = yamlScalar(v); err != nil {
+					return
+				}
+				continue
+			}
+			if len(ls) == 0 || ls[0].col <= col {
+				return nil, nil, fmt.Errorf("missing value of %s", k)
+			}
+			if m[k], ls, err = yamlNode(ls); err != nil {
+				return
+			}
+		}
+		return m, ls, nil
+	}
+	res, err = yamlScalar(t)
+	return res, ls[1:], err
+}
+
+func yamlScalar(s string) (res interface{}, err error) {
+	err = json.Unmarshal([]byte(s), &res)
+	return
+}
+
+type custom struct {
+	Ratio   float32            `json:"ratio"`
+	Scale   float64            `json:"scale"`
+	Weights map[string]float64 `json:"weights"`
+	Groups  map[int][]string   `json:"groups"`
+	Empty   map[string]int     `json:"empty"`
+	Nil     []int              `json:"nil"`
+	Point   [3]int             `json:"point"`
+	Matrix  [][]float64        `json:"matrix"`
+	Raw     []byte             `json:"raw"`
+	Skipped float64            `json:"skipped,omitempty"`
+}
+
+func TestYAMLEquivalence(t *testing.T) {
+	cf, err := class.ParseFile("/tmp/HelloWorld.class")
+	if err != nil {
+		t.Errorf("failed to parse file, error(%v)", err)
+		t.FailNow()
+	}
+	c, err := New(cf)
+	if err != nil {
+		t.Errorf("failed to dump, error(%v)", err)
+		t.FailNow()
+	}
+	c.Attributes = append(c.Attributes, &Attribute{Name: "Custom", Length: 1, Value: &custom{
+		Ratio:   0.1,
+		Scale:   1e21,
+		Weights: map[string]float64{"b: \"x\"": -2.5, "a": 1e-7},
+		Groups:  map[int][]string{10: {"x", "y"}, 2: nil},
+		Empty:   map[string]int{},
+		Point:   [3]int{1, 2, 3},
+		Matrix:  [][]float64{{1, 0.5}, {}, {-0}},
+		Raw:     []byte{0xCA, 0xFE},
+	}})
+	for _, v := range []interface{}{c, c.Attributes[len(c.Attributes)-1]} {
+		jb, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("failed to marshal, error(%v)", err)
+			t.FailNow()
+		}
+		var exp interface{}
+		if err = json.Unmarshal(jb, &exp); err != nil {
+			t.Errorf("failed to unmarshal, error(%v)", err)
+			t.FailNow()
+		}
+		yb := new(bytes.Buffer)
+		if err = encodeYAML(yb, v); err != nil {
+			t.Errorf("failed to encode yaml, error(%v)", err)
+			t.FailNow()
+		}
+		res, err := parseYAML(yb.String())
+		if err != nil {
+			t.Errorf("failed to parse yaml, error(%v)\n%s", err, yb)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(res, exp) {
+			t.Errorf("yaml differs from json:\n%s\n%s", yb, jb)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package classtest builds class files for tests.
+package classtest
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+// constant pool tags
+const (
+	_utf8               = 1
+	_integer            = 3
+	_float              = 4
+	_long               = 5
+	_double             = 6
+	_class              = 7
+	_string             = 8
+	_fieldRef           = 9
+	_methodRef          = 10
+	_interfaceMethodRef = 11
+	_nameAndType        = 12
+)
+
+// Builder builds a class file, equal constants are added once.
+type Builder struct {
+	File   *class.ClassFile
+	consts map[string]uint16
+}
+
+// New returns the builder of a class of major version 52, super empty for
+// none.
+func New(name, super string, flags uint16, interfaces ...string) *Builder {
+	b := &Builder{
+		File:   &class.ClassFile{Magic: 0xCAFEBABE, MajorVersion: 52, CpInfo: []class.ConstantInfo{nil}, AccessFlags: flags},
+		consts: make(map[string]uint16),
+	}
+	b.File.ThisClass = b.Class(name)
+	if super != "" {
+		b.File.SuperClass = b.Class(super)
+	}
+	for _, in := range interfaces {
+		b.File.Interfaces = append(b.File.Interfaces, &class.ClassInfo{NameIndex: b.Class(in)})
+	}
+	return b
+}
+
+func (b *Builder) add(key string, c class.ConstantInfo) uint16 {
+	if i, ok := b.consts[key]; ok {
+		return i
+	}
+	i := uint16(len(b.File.CpInfo))
+	b.File.CpInfo = append(b.File.CpInfo, c)
+	if t := c.T(); t == _long || t == _double {
+		b.File.CpInfo = append(b.File.CpInfo, nil)
+	}
+	b.consts[key] = i
+	return i
+}
+
+func (b *Builder) Utf8(s string) uint16 {
+	return b.add("Utf8 "+s, &class.Utf8Info{Tag: class.Tag{Tag: _utf8}, Length: uint16(len(s)), Bytes: []byte(s)})
+}
+
+func (b *Builder) Class(name string) uint16 {
+	n := b.Utf8(name)
+	return b.add("Class "+name, &class.ClassInfo{Tag: class.Tag{Tag: _class}, NameIndex: n})
+}
+
+func (b *Builder) String(s string) uint16 {
+	n := b.Utf8(s)
+	return b.add("String "+s, &class.StringInfo{Tag: class.Tag{Tag: _string}, StringIndex: n})
+}
+
+func (b *Builder) Integer(v int32) uint16 {
+	return b.add(fmt.Sprint("Integer ", v), &class.IntegerInfo{Tag: class.Tag{Tag: _integer}, Bytes: uint32(v)})
+}
+
+func (b *Builder) Float(v float32) uint16 {
+	bits := math.Float32bits(v)
+	return b.add(fmt.Sprint("Float ", bits), &class.FloatInfo{IntegerInfo: class.IntegerInfo{Tag: class.Tag{Tag: _float}, Bytes: bits}})
+}
+
+func (b *Builder) Long(v int64) uint16 {
+	return b.add(fmt.Sprint("Long ", v), &class.LongInfo{Tag: class.Tag{Tag: _long}, HighBytes: uint32(uint64(v) >> 32), LowBytes: uint32(v)})
+}
+
+func (b *Builder) Double(v float64) uint16 {
+	bits := math.Float64bits(v)
+	return b.add(fmt.Sprint("Double ", bits), &class.DoubleInfo{LongInfo: class.LongInfo{Tag: class.Tag{Tag: _double}, HighBytes: uint32(bits >> 32), LowBytes: uint32(bits)}})
+}
+
+func (b *Builder) NameAndType(name, desc string) uint16 {
+	n, d := b.Utf8(name), b.Utf8(desc)
+	return b.add("NameAndType "+name+":"+desc, &class.NameAndType{Tag: class.Tag{Tag: _nameAndType}, NameIndex: n, DescriptorIndex: d})
+}
+
+func (b *Builder) ref(tag uint8, kind, cls, name, desc string) uint16 {
+	c, nt := b.Class(cls), b.NameAndType(name, desc)
+	r := class.FieldRefInfo{Tag: class.Tag{Tag: tag}, ClassIndex: c, NameAndTypeIndex: nt}
+	var info class.ConstantInfo = &r
+	switch tag {
+	case _methodRef:
+		info = &class.MethodRefInfo{FieldRefInfo: r}
+	case _interfaceMethodRef:
+		info = &class.InterfaceMethodRefInfo{FieldRefInfo: r}
+	}
+	return b.add(kind+" "+cls+"."+name+":"+desc, info)
+}
+
+func (b *Builder) FieldRef(cls, name, desc string) uint16 {
+	return b.ref(_fieldRef, "Fieldref", cls, name, desc)
+}
+
+func (b *Builder) MethodRef(cls, name, desc string) uint16 {
+	return b.ref(_methodRef, "Methodref", cls, name, desc)
+}
+
+func (b *Builder) InterfaceMethodRef(cls, name, desc string) uint16 {
+	return b.ref(_interfaceMethodRef, "InterfaceMethodref", cls, name, desc)
+}
+
+// Field adds a field, with a ConstantValue attribute if cv isn't 0.
+func (b *Builder) Field(flags uint16, name, desc string, cv uint16) *class.FieldInfo {
+	f := &class.FieldInfo{AccessFlags: flags, NameIndex: b.Utf8(name), DescriptorIndex: b.Utf8(desc)}
+	if cv != 0 {
+		f.Attributes = append(f.Attributes, &class.AttributeInfo{
+			AttributeNameIndex: b.Utf8("ConstantValue"),
+			Value:              &class.ConstantValueAttribute{ConstantValueIndex: cv},
+		})
+	}
+	b.File.Fields = append(b.File.Fields, f)
+	return f
+}
+
+// Method adds a method, without Code if code is nil.
+func (b *Builder) Method(flags uint16, name, desc string, maxStack, maxLocals uint16, code []byte, handlers ...*class.ExceptionTableEntry) *class.MethodInfo {
+	f := &class.MethodInfo{FieldInfo: class.FieldInfo{AccessFlags: flags, NameIndex: b.Utf8(name), DescriptorIndex: b.Utf8(desc)}}
+	if code != nil {
+		f.Code = &class.CodeAttribute{MaxStack: maxStack, MaxLocals: maxLocals, Code: code, ExceptionTable: handlers}
+		f.Attributes = append(f.Attributes, &class.AttributeInfo{AttributeNameIndex: b.Utf8("Code"), Value: f.Code})
+	}
+	b.File.Methods = append(b.File.Methods, f)
+	return f
+}
+
+// Bytes returns the serialized class file, it panics if it can't be
+// written.
+func (b *Builder) Bytes() []byte {
+	res, err := b.File.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// Classes returns the built class files by internal name, e.g. for a
+// loader.MemoryEntry.
+func Classes(bs ...*Builder) map[string][]byte {
+	res := make(map[string][]byte, len(bs))
+	for _, b := range bs {
+		name, err := b.File.ClassName()
+		if err != nil {
+			panic(err)
+		}
+		res[name] = b.Bytes()
+	}
+	return res
+}
+
+// Object returns the builder of a java/lang/Object with a constructor.
+func Object() *Builder {
+	b := New("java/lang/Object", "", class.AccPublic|class.AccSuper)
+	// return
+	b.Method(class.AccPublic, "<init>", "()V", 0, 1, []byte{0xb1})
+	return b
+}
@@ -0,0 +1,117 @@
+package interp
+
+import (
+	"math"
+
+	"github.com/wucongyou/go-jvm/rt"
+)
+
+// Frame frame of a method invocation (JVMS 2.6), the local variables and
+// the operand stack are sized from max_locals and max_stack.
+type Frame struct {
+	Method *rt.Method
+	Locals rt.Slots
+	Stack  *OperandStack
+	// PC offset of the current instruction, only moved under the lock of
+	// the thread.
+	PC int
+	// entry frame pushed by Thread.Invoke, its return ends the invocation.
+	entry bool
+}
+
+func newFrame(m *rt.Method) *Frame {
+	return &Frame{
+		Method: m,
+		Locals: make(rt.Slots, m.MaxLocals),
+		Stack:  &OperandStack{slots: make(rt.Slots, m.MaxStack)},
+	}
+}
+
+// OperandStack operand stack of a frame, a long or double takes two slots
+// like in the local variables. Overflow and underflow panic with
+// errStackOverflow and errStackUnderflow, the interpreter turns them into an
+// Error.
+type OperandStack struct {
+	slots rt.Slots
+	top   int
+}
+
+// Len returns the number of slots on the stack.
+func (m *OperandStack) Len() int {
+	return m.top
+}
+
+// Clear empties the stack.
+func (m *OperandStack) Clear() {
+	for i := 0; i < m.top; i++ {
+		m.slots[i] = rt.Slot{}
+	}
+	m.top = 0
+}
+
+func (m *OperandStack) Push(s rt.Slot) {
+	if m.top == len(m.slots) {
+		panic(errStackOverflow)
+	}
+	m.slots[m.top] = s
+	m.top++
+}
+
+func (m *OperandStack) Pop() (res rt.Slot) {
+	if m.top == 0 {
+		panic(errStackUnderflow)
+	}
+	m.top--
+	res, m.slots[m.top] = m.slots[m.top], rt.Slot{}
+	return
+}
+
+// Peek returns the slot n slots below the top, 0 for the top.
+func (m *OperandStack) Peek(n int) rt.Slot {
+	if n >= m.top {
+		panic(errStackUnderflow)
+	}
+	return m.slots[m.top-1-n]
+}
+
+func (m *OperandStack) PushInt(v int32) {
+	m.Push(rt.Slot{Num: int64(v)})
+}
+
+func (m *OperandStack) PopInt() int32 {
+	return int32(m.Pop().Num)
+}
+
+func (m *OperandStack) PushFloat(v float32) {
+	m.Push(rt.Slot{Num: int64(math.Float32bits(v))})
+}
+
+func (m *OperandStack) PopFloat() float32 {
+	return math.Float32frombits(uint32(m.Pop().Num))
+}
+
+func (m *OperandStack) PushLong(v int64) {
+	m.Push(rt.Slot{Num: v})
+	m.Push(rt.Slot{})
+}
+
+func (m *OperandStack) PopLong() int64 {
+	m.Pop()
+	return m.Pop().Num
+}
+
+func (m *OperandStack) PushDouble(v float64) {
+	m.PushLong(int64(math.Float64bits(v)))
+}
+
+func (m *OperandStack) PopDouble() float64 {
+	return math.Float64frombits(uint64(m.PopLong()))
+}
+
+func (m *OperandStack) PushRef(v *rt.Object) {
+	m.Push(rt.Slot{Ref: v})
+}
+
+func (m *OperandStack) PopRef() *rt.Object {
+	return m.Pop().Ref
+}
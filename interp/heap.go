@@ -0,0 +1,361 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wucongyou/go-jvm/bytecode"
+	"github.com/wucongyou/go-jvm/loader"
+	"github.com/wucongyou/go-jvm/rt"
+)
+
+// exceptions thrown by object and array instructions
+const (
+	NullPointerException           = "java/lang/NullPointerException"
+	ArrayIndexOutOfBoundsException = "java/lang/ArrayIndexOutOfBoundsException"
+	NegativeArraySizeException     = "java/lang/NegativeArraySizeException"
+	ArrayStoreException            = "java/lang/ArrayStoreException"
+	InstantiationError             = "java/lang/InstantiationError"
+	ExceptionInInitializerError    = "java/lang/ExceptionInInitializerError"
+)
+
+var (
+	// _arrayTypes array classes of the atype of newarray.
+	_arrayTypes = map[int32]string{
+		bytecode.TBoolean: "[Z",
+		bytecode.TChar:    "[C",
+		bytecode.TFloat:   "[F",
+		bytecode.TDouble:  "[D",
+		bytecode.TByte:    "[B",
+		bytecode.TShort:   "[S",
+		bytecode.TInt:     "[I",
+		bytecode.TLong:    "[J",
+	}
+)
+
+// linkError throws the Java error of a failed loading, linking or
+// resolution, other errors are returned as they are.
+func (t *Thread) linkError(err error) error {
+	var le *loader.Error
+	if !errors.As(err, &le) {
+		return err
+	}
+	msg := javaName(le.Class)
+	switch {
+	case errors.Is(le.Err, rt.ErrInitialization):
+		msg = "Could not initialize class " + msg
+	case le.Err != nil && !errors.Is(le.Err, loader.ErrNotFound):
+		msg += ": " + le.Err.Error()
+	}
+	return t.throw(le.Kind, msg)
+}
+
+// initialize initializes c if it isn't (JVMS 5.5): its superclass first,
+// then its static fields with a ConstantValue, then <clinit>. An exception
+// of <clinit> other than an Error is thrown as an
+// ExceptionInInitializerError.
+func (t *Thread) initialize(c *rt.Class) (err error) {
+	run, err := c.StartInit(t)
+	if err != nil {
+		return t.linkError(err)
+	}
+	if !run {
+		return
+	}
+	defer func() {
+		c.FinishInit(err != nil)
+	}()
+	if c.Super != nil && !c.IsInterface() {
+		if err = t.initialize(c.Super); err != nil {
+			return
+		}
+	}
+	for _, f := range c.Fields {
+		if !f.IsStatic() || f.ConstantValueIndex == 0 {
+			continue
+		}
+		var v interface{}
+		if v, err = c.ConstantPool.Constant(f.ConstantValueIndex); err != nil {
+			return t.linkError(err)
+		}
+		switch v := v.(type) {
+		case int32:
+			c.StaticVars.SetInt(f.Slot, v)
+		case float32:
+			c.StaticVars.SetFloat(f.Slot, v)
+		case int64:
+			c.StaticVars.SetLong(f.Slot, v)
+		case float64:
+			c.StaticVars.SetDouble(f.Slot, v)
+		}
+		// String constants need java/lang/String instances, which aren't
+		// created yet, the field stays null
+	}
+	m := c.Method("<clinit>", "()V")
+	if m == nil || m.Code == nil {
+		return
+	}
+	base := t.depth()
+	f := newFrame(m)
+	f.entry = true
+	if err = t.push(f); err == nil {
+		err = t.run(base)
+	}
+	if err != nil {
+		t.unwind(base)
+		if ex, ok := err.(*Exception); ok && !isError(ex.Object.Class) {
+			err = t.throw(ExceptionInInitializerError, ex.Error())
+		}
+	}
+	return
+}
+
+// isError reports whether c is java/lang/Error or a subclass.
+func isError(c *rt.Class) bool {
+	for ; c != nil; c = c.Super {
+		if c.Name == "java/lang/Error" {
+			return true
+		}
+	}
+	return false
+}
+
+// new creates an instance of the class at index i of the constant pool.
+func (t *Thread) new(f *Frame, i uint16) (err error) {
+	c, err := f.Method.Class.ConstantPool.Class(i)
+	if err != nil {
+		return t.linkError(err)
+	}
+	if c.IsInterface() || c.IsAbstract() {
+		return t.throw(InstantiationError, javaName(c.Name))
+	}
+	if err = t.initialize(c); err != nil {
+		return
+	}
+	f.Stack.PushRef(rt.NewObject(c))
+	return
+}
+
+// field executes getstatic, putstatic, getfield or putfield on the field at
+// index i of the constant pool.
+func (t *Thread) field(f *Frame, o bytecode.Opcode, i uint16) (err error) {
+	cur := f.Method.Class
+	fd, err := cur.ConstantPool.Field(i)
+	if err != nil {
+		return t.linkError(err)
+	}
+	static := o == bytecode.Getstatic || o == bytecode.Putstatic
+	put := o == bytecode.Putstatic || o == bytecode.Putfield
+	kind, init := "non-static", "<init>"
+	if static {
+		kind, init = "static", "<clinit>"
+	}
+	if fd.IsStatic() != static {
+		return t.throw(loader.IncompatibleClassChangeError, fmt.Sprintf("expected %s field %s.%s", kind, javaName(fd.Class.Name), fd.Name))
+	}
+	// a final field is only set by the initialization of its class or
+	// instance
+	if put && fd.IsFinal() && (fd.Class != cur || f.Method.Name != init) {
+		return t.throw(rt.IllegalAccessError, fmt.Sprintf("update to final field %s.%s from %s", javaName(fd.Class.Name), fd.Name, f.Method))
+	}
+	wide := fd.Type.Slots() == 2
+	s := f.Stack
+	var vars rt.Slots
+	var v rt.Slot
+	if put {
+		if wide {
+			v = rt.Slot{Num: s.PopLong()}
+		} else {
+			v = s.Pop()
+		}
+	}
+	if static {
+		if err = t.initialize(fd.Class); err != nil {
+			return
+		}
+		vars = fd.Class.StaticVars
+	} else {
+		obj := s.PopRef()
+		if obj == nil {
+			verb := "read"
+			if put {
+				verb = "assign"
+			}
+			return t.throw(NullPointerException, fmt.Sprintf("Cannot %s field %q because value is null", verb, fd.Name))
+		}
+		vars = obj.Fields
+	}
+	switch {
+	case put && wide:
+		vars.SetLong(fd.Slot, v.Num)
+	case put:
+		vars[fd.Slot] = v
+	case wide:
+		s.PushLong(vars.Long(fd.Slot))
+	default:
+		s.Push(vars[fd.Slot])
+	}
+	return
+}
+
+// newArray pushes a new array of the array class name with n elements.
+func (t *Thread) newArray(f *Frame, name string, n int32) (err error) {
+	if n < 0 {
+		return t.throw(NegativeArraySizeException, fmt.Sprint(n))
+	}
+	c, err := t.linker.Class(name)
+	if err != nil {
+		return t.linkError(err)
+	}
+	f.Stack.PushRef(rt.NewArray(c, int(n)))
+	return
+}
+
+// newarray pushes a new array of the primitive type atype.
+func (t *Thread) newarray(f *Frame, atype int32) error {
+	name, ok := _arrayTypes[atype]
+	if !ok {
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("invalid array type %d", atype)}
+	}
+	return t.newArray(f, name, f.Stack.PopInt())
+}
+
+// anewarray pushes a new array of the class at index i of the constant pool.
+func (t *Thread) anewarray(f *Frame, i uint16) (err error) {
+	c, err := f.Method.Class.ConstantPool.Class(i)
+	if err == nil {
+		c, err = c.ArrayClass()
+	}
+	if err != nil {
+		return t.linkError(err)
+	}
+	return t.newArray(f, c.Name, f.Stack.PopInt())
+}
+
+// multianewarray pushes a new array of the array class at index i of the
+// constant pool, the counts of the first dims dimensions are on the stack.
+func (t *Thread) multianewarray(f *Frame, i uint16, dims int32) (err error) {
+	c, err := f.Method.Class.ConstantPool.Class(i)
+	if err != nil {
+		return t.linkError(err)
+	}
+	counts := make([]int32, dims)
+	for d := dims - 1; d >= 0; d-- {
+		counts[d] = f.Stack.PopInt()
+	}
+	for _, n := range counts {
+		if n < 0 {
+			return t.throw(NegativeArraySizeException, fmt.Sprint(n))
+		}
+	}
+	f.Stack.PushRef(multiArray(c, counts))
+	return
+}
+
+// multiArray creates an array of c with counts[0] elements, each an array
+// of the component class with counts[1] elements and so on.
+func multiArray(c *rt.Class, counts []int32) (res *rt.Object) {
+	res = rt.NewArray(c, int(counts[0]))
+	if len(counts) == 1 {
+		return
+	}
+	es := res.Array.([]*rt.Object)
+	for i := range es {
+		es[i] = multiArray(c.Component, counts[1:])
+	}
+	return
+}
+
+// notArray returns the error of an array instruction of f on an object that
+// isn't an array, which only unverified bytecode gets to.
+func notArray(f *Frame, a *rt.Object) error {
+	return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("%s is not an array", a.Class.Name)}
+}
+
+// checkIndex throws a NullPointerException for a null array and an
+// ArrayIndexOutOfBoundsException for an index out of its bounds.
+func (t *Thread) checkIndex(f *Frame, a *rt.Object, i int32, store bool) error {
+	if a == nil {
+		verb := "load from"
+		if store {
+			verb = "store to"
+		}
+		return t.throw(NullPointerException, fmt.Sprintf("Cannot %s array because value is null", verb))
+	}
+	if a.Array == nil {
+		return notArray(f, a)
+	}
+	if n := a.ArrayLength(); i < 0 || int(i) >= n {
+		return t.throw(ArrayIndexOutOfBoundsException, fmt.Sprintf("Index %d out of bounds for length %d", i, n))
+	}
+	return nil
+}
+
+// arrayLoad executes the <t>aload instruction o.
+func (t *Thread) arrayLoad(f *Frame, o bytecode.Opcode) (err error) {
+	s := f.Stack
+	i, a := s.PopInt(), s.PopRef()
+	if err = t.checkIndex(f, a, i, false); err != nil {
+		return
+	}
+	switch o {
+	case bytecode.Iaload:
+		s.PushInt(a.Array.([]int32)[i])
+	case bytecode.Laload:
+		s.PushLong(a.Array.([]int64)[i])
+	case bytecode.Faload:
+		s.PushFloat(a.Array.([]float32)[i])
+	case bytecode.Daload:
+		s.PushDouble(a.Array.([]float64)[i])
+	case bytecode.Aaload:
+		s.PushRef(a.Array.([]*rt.Object)[i])
+	case bytecode.Baload:
+		s.PushInt(int32(a.Array.([]int8)[i]))
+	case bytecode.Caload:
+		s.PushInt(int32(a.Array.([]uint16)[i]))
+	case bytecode.Saload:
+		s.PushInt(int32(a.Array.([]int16)[i]))
+	}
+	return
+}
+
+// arrayStore executes the <t>astore instruction o.
+func (t *Thread) arrayStore(f *Frame, o bytecode.Opcode) (err error) {
+	s := f.Stack
+	var v rt.Slot
+	if o == bytecode.Lastore || o == bytecode.Dastore {
+		v = rt.Slot{Num: s.PopLong()}
+	} else {
+		v = s.Pop()
+	}
+	i, a := s.PopInt(), s.PopRef()
+	if err = t.checkIndex(f, a, i, true); err != nil {
+		return
+	}
+	switch o {
+	case bytecode.Iastore:
+		a.Array.([]int32)[i] = int32(v.Num)
+	case bytecode.Lastore:
+		a.Array.([]int64)[i] = v.Num
+	case bytecode.Fastore:
+		a.Array.([]float32)[i] = rt.Slots{v}.Float(0)
+	case bytecode.Dastore:
+		a.Array.([]float64)[i] = rt.Slots{v}.Double(0)
+	case bytecode.Aastore:
+		if v.Ref != nil && !v.Ref.Class.IsAssignableTo(a.Class.Component) {
+			return t.throw(ArrayStoreException, javaName(v.Ref.Class.Name))
+		}
+		a.Array.([]*rt.Object)[i] = v.Ref
+	case bytecode.Bastore:
+		// a boolean is the lowest bit of the int
+		if a.Class.Name == "[Z" {
+			v.Num &= 1
+		}
+		a.Array.([]int8)[i] = int8(v.Num)
+	case bytecode.Castore:
+		a.Array.([]uint16)[i] = uint16(v.Num)
+	case bytecode.Sastore:
+		a.Array.([]int16)[i] = int16(v.Num)
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package interp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/internal/classtest"
+)
+
+// op returns the instruction o with the constant pool index i.
+func op(o byte, i uint16, rest ...byte) []byte {
+	return append([]byte{o, byte(i >> 8), byte(i)}, rest...)
+}
+
+func code(parts ...[]byte) (res []byte) {
+	for _, p := range parts {
+		res = append(res, p...)
+	}
+	return
+}
+
+func TestHeap(t *testing.T) {
+	p := classtest.New("P", "java/lang/Object", class.AccPublic|class.AccSuper)
+	p.Field(_static, "counter", "I", p.Integer(7))
+	p.Field(_static, "big", "J", 0)
+	p.Field(class.AccPublic, "x", "I", 0)
+	p.Field(class.AccPublic, "y", "J", 0)
+	// big = (1L << 40) + 3;
+	p.Method(class.AccStatic, "<clinit>", "()V", 2, 0, code(op(0x14, p.Long(1<<40+3)), op(0xb3, p.FieldRef("P", "big", "J")), []byte{0xb1}))
+
+	// static { int i = 1 / 0; }
+	q := classtest.New("Q", "java/lang/Object", class.AccPublic|class.AccSuper)
+	q.Field(_static, "v", "I", 0)
+	q.Method(class.AccStatic, "<clinit>", "()V", 2, 0, []byte{0x04, 0x03, 0x6c, 0x57, 0xb1})
+
+	b := classtest.New("T", "java/lang/Object", class.AccPublic|class.AccSuper)
+	x, y := b.FieldRef("P", "x", "I"), b.FieldRef("P", "y", "J")
+	// P o = new P(); o.x = 5; o.y = P.big; return (int) o.y + o.x + P.counter;
+	b.Method(_static, "fields", "()I", 3, 1, code(
+		op(0xbb, b.Class("P"), 0x4b, 0x2a, 0x10, 5), op(0xb5, x, 0x2a),
+		op(0xb2, b.FieldRef("P", "big", "J")), op(0xb5, y, 0x2a),
+		op(0xb4, y, 0x88, 0x2a), op(0xb4, x, 0x60),
+		op(0xb2, b.FieldRef("P", "counter", "I"), 0x60, 0xac),
+	))
+	// int[] a = new int[3]; a[1] = 42; return a[1] + a.length;
+	b.Method(_static, "ints", "()I", 3, 1, []byte{0x06, 0xbc, 10, 0x4b, 0x2a, 0x04, 0x10, 42, 0x4f, 0x2a, 0x04, 0x2e, 0x2a, 0xbe, 0x60, 0xac})
+	// boolean[] a = new boolean[1]; a[0] = 2; return a[0];
+	b.Method(_static, "booleans", "()I", 4, 0, []byte{0x04, 0xbc, 4, 0x59, 0x03, 0x05, 0x54, 0x03, 0x33, 0xac})
+	// int[][] a = new int[2][3]; return a[1].length * a.length;
+	b.Method(_static, "multi", "()I", 2, 1, code([]byte{0x05, 0x06}, op(0xc5, b.Class("[[I"), 2, 0x4b, 0x2a, 0x04, 0x32, 0xbe, 0x2a, 0xbe, 0x68, 0xac)))
+	// Object[] a = new Object[1]; a[0] = new int[0]; return a.length;
+	b.Method(_static, "objects", "()I", 4, 0, code([]byte{0x04}, op(0xbd, b.Class("java/lang/Object"), 0x59, 0x03, 0x03, 0xbc, 10, 0x53, 0xbe, 0xac)))
+	// try { return new int[-1].length; } catch (NegativeArraySizeException e) { return 1; }
+	b.Method(_static, "caught", "()I", 1, 0, []byte{0x02, 0xbc, 10, 0xbe, 0xac, 0x57, 0x04, 0xac},
+		&class.ExceptionTableEntry{StartPc: 0, EndPc: 5, HandlerPc: 5, CatchType: b.Class("java/lang/NegativeArraySizeException")})
+	b.Method(_static, "npe", "()I", 1, 0, code([]byte{0x01}, op(0xb4, x, 0xac)))
+	b.Method(_static, "length", "()I", 1, 0, []byte{0x01, 0xbe, 0xac})
+	b.Method(_static, "aioobe", "()I", 2, 0, []byte{0x04, 0xbc, 10, 0x04, 0x2e, 0xac})
+	b.Method(_static, "negative", "()I", 1, 0, []byte{0x02, 0xbc, 10, 0xbe, 0xac})
+	// new P[1][0] = new Object();
+	b.Method(_static, "store", "()I", 3, 0, code([]byte{0x04}, op(0xbd, b.Class("P"), 0x03), op(0xbb, b.Class("java/lang/Object"), 0x53, 0x03, 0xac)))
+	b.Method(_static, "init", "()I", 1, 0, op(0xb2, b.FieldRef("Q", "v", "I"), 0xac))
+	// unverified: new Object().length, new Object()[0]
+	b.Method(_static, "length2", "()I", 2, 0, op(0xbb, b.Class("java/lang/Object"), 0xbe, 0xac))
+	b.Method(_static, "load", "()I", 2, 0, op(0xbb, b.Class("java/lang/Object"), 0x03, 0x2e, 0xac))
+	// newarray with atype 3, which isn't a type
+	b.Method(_static, "atype", "()I", 1, 0, []byte{0x04, 0xbc, 3, 0xbe, 0xac})
+
+	c, err := newLinker(p, q, b).Class("T")
+	if err != nil {
+		t.Errorf("failed to link, error(%v)", err)
+		t.FailNow()
+	}
+	th := NewThread(c.Linker())
+	for _, e := range []struct {
+		name string
+		res  int32
+	}{
+		{"fields", 15},
+		{"ints", 45},
+		{"booleans", 0},
+		{"multi", 6},
+		{"objects", 1},
+		{"caught", 1},
+	} {
+		res, err := th.Invoke(c.Method(e.name, "()I"))
+		if err != nil {
+			t.Errorf("failed to invoke %s, error(%v)", e.name, err)
+			continue
+		}
+		if int32(res.Num) != e.res {
+			t.Errorf("%s: expected %d, got %d", e.name, e.res, int32(res.Num))
+		}
+	}
+
+	for _, e := range []struct {
+		name, ex string
+	}{
+		{"npe", `java.lang.NullPointerException: Cannot read field "x" because value is null`},
+		{"length", "java.lang.NullPointerException: Cannot read the array length because value is null"},
+		{"aioobe", "java.lang.ArrayIndexOutOfBoundsException: Index 1 out of bounds for length 1"},
+		{"negative", "java.lang.NegativeArraySizeException: -1"},
+		{"store", "java.lang.ArrayStoreException: java.lang.Object"},
+		{"init", "java.lang.ExceptionInInitializerError: java.lang.ArithmeticException: / by zero"},
+		{"init", "java.lang.NoClassDefFoundError: Could not initialize class Q"},
+	} {
+		_, err := th.Invoke(c.Method(e.name, "()I"))
+		var ex *Exception
+		if !errors.As(err, &ex) || ex.Error() != e.ex {
+			t.Errorf("%s: expected %s, got %v", e.name, e.ex, err)
+		}
+	}
+
+	var ie *Error
+	for _, name := range []string{"length2", "load"} {
+		_, err := th.Invoke(c.Method(name, "()I"))
+		if !errors.As(err, &ie) || ie.Err.Error() != "java/lang/Object is not an array" {
+			t.Errorf("%s: expected not an array error, got %v", name, err)
+		}
+	}
+	if _, err := th.Invoke(c.Method("atype", "()I")); !errors.As(err, &ie) || ie.Err.Error() != "invalid array type 3" {
+		t.Errorf("expected invalid array type error, got %v", err)
+	}
+}
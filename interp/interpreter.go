@@ -0,0 +1,477 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/wucongyou/go-jvm/bytecode"
+	"github.com/wucongyou/go-jvm/loader"
+	"github.com/wucongyou/go-jvm/rt"
+)
+
+// execute executes in on f and moves the pc of f on, an exception thrown by
+// in is returned as an *Exception with the pc still at in.
+func (t *Thread) execute(f *Frame, in *bytecode.Instruction) (err error) {
+	s, l := f.Stack, f.Locals
+	next := f.PC + in.Length
+	var op int32
+	if len(in.Operands) > 0 {
+		op = in.Operands[0]
+	}
+	switch o := in.Opcode; o {
+	// constants
+	case bytecode.Nop:
+	case bytecode.AconstNull:
+		s.PushRef(nil)
+	case bytecode.IconstM1, bytecode.Iconst0, bytecode.Iconst1, bytecode.Iconst2,
+		bytecode.Iconst3, bytecode.Iconst4, bytecode.Iconst5:
+		s.PushInt(int32(o) - int32(bytecode.Iconst0))
+	case bytecode.Lconst0, bytecode.Lconst1:
+		s.PushLong(int64(o - bytecode.Lconst0))
+	case bytecode.Fconst0, bytecode.Fconst1, bytecode.Fconst2:
+		s.PushFloat(float32(o - bytecode.Fconst0))
+	case bytecode.Dconst0, bytecode.Dconst1:
+		s.PushDouble(float64(o - bytecode.Dconst0))
+	case bytecode.Bipush, bytecode.Sipush:
+		s.PushInt(op)
+	case bytecode.Ldc, bytecode.LdcW, bytecode.Ldc2W:
+		if err = t.ldc(f, uint16(op)); err != nil {
+			return
+		}
+
+	// loads
+	case bytecode.Iload, bytecode.Fload, bytecode.Aload:
+		s.Push(l[op])
+	case bytecode.Lload, bytecode.Dload:
+		s.PushLong(l.Long(int(op)))
+	case bytecode.Iload0, bytecode.Iload1, bytecode.Iload2, bytecode.Iload3:
+		s.Push(l[o-bytecode.Iload0])
+	case bytecode.Fload0, bytecode.Fload1, bytecode.Fload2, bytecode.Fload3:
+		s.Push(l[o-bytecode.Fload0])
+	case bytecode.Aload0, bytecode.Aload1, bytecode.Aload2, bytecode.Aload3:
+		s.Push(l[o-bytecode.Aload0])
+	case bytecode.Lload0, bytecode.Lload1, bytecode.Lload2, bytecode.Lload3:
+		s.PushLong(l.Long(int(o - bytecode.Lload0)))
+	case bytecode.Dload0, bytecode.Dload1, bytecode.Dload2, bytecode.Dload3:
+		s.PushLong(l.Long(int(o - bytecode.Dload0)))
+
+	// stores
+	case bytecode.Istore, bytecode.Fstore, bytecode.Astore:
+		l[op] = s.Pop()
+	case bytecode.Lstore, bytecode.Dstore:
+		l.SetLong(int(op), s.PopLong())
+	case bytecode.Istore0, bytecode.Istore1, bytecode.Istore2, bytecode.Istore3:
+		l[o-bytecode.Istore0] = s.Pop()
+	case bytecode.Fstore0, bytecode.Fstore1, bytecode.Fstore2, bytecode.Fstore3:
+		l[o-bytecode.Fstore0] = s.Pop()
+	case bytecode.Astore0, bytecode.Astore1, bytecode.Astore2, bytecode.Astore3:
+		l[o-bytecode.Astore0] = s.Pop()
+	case bytecode.Lstore0, bytecode.Lstore1, bytecode.Lstore2, bytecode.Lstore3:
+		l.SetLong(int(o-bytecode.Lstore0), s.PopLong())
+	case bytecode.Dstore0, bytecode.Dstore1, bytecode.Dstore2, bytecode.Dstore3:
+		l.SetLong(int(o-bytecode.Dstore0), s.PopLong())
+
+	// stack, a long or double is two slots for pop2 and the dup2 forms
+	case bytecode.Pop:
+		s.Pop()
+	case bytecode.Pop2:
+		s.Pop()
+		s.Pop()
+	case bytecode.Dup:
+		s.Push(s.Peek(0))
+	case bytecode.DupX1:
+		v1, v2 := s.Pop(), s.Pop()
+		push(s, v1, v2, v1)
+	case bytecode.DupX2:
+		v1, v2, v3 := s.Pop(), s.Pop(), s.Pop()
+		push(s, v1, v3, v2, v1)
+	case bytecode.Dup2:
+		v1, v2 := s.Pop(), s.Pop()
+		push(s, v2, v1, v2, v1)
+	case bytecode.Dup2X1:
+		v1, v2, v3 := s.Pop(), s.Pop(), s.Pop()
+		push(s, v2, v1, v3, v2, v1)
+	case bytecode.Dup2X2:
+		v1, v2, v3, v4 := s.Pop(), s.Pop(), s.Pop(), s.Pop()
+		push(s, v2, v1, v4, v3, v2, v1)
+	case bytecode.Swap:
+		v1, v2 := s.Pop(), s.Pop()
+		push(s, v1, v2)
+
+	// int arithmetic wraps around, division rounds towards zero
+	case bytecode.Iadd:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 + v2)
+	case bytecode.Isub:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 - v2)
+	case bytecode.Imul:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 * v2)
+	case bytecode.Idiv, bytecode.Irem:
+		v2, v1 := s.PopInt(), s.PopInt()
+		if v2 == 0 {
+			return t.throw(ArithmeticException, "/ by zero")
+		}
+		// Go defines MinInt32 / -1 as MinInt32 and the remainder as 0 too
+		if o == bytecode.Idiv {
+			s.PushInt(v1 / v2)
+		} else {
+			s.PushInt(v1 % v2)
+		}
+	case bytecode.Ineg:
+		s.PushInt(-s.PopInt())
+	case bytecode.Ishl:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 << uint(v2&0x1f))
+	case bytecode.Ishr:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 >> uint(v2&0x1f))
+	case bytecode.Iushr:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(int32(uint32(v1) >> uint(v2&0x1f)))
+	case bytecode.Iand:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 & v2)
+	case bytecode.Ior:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 | v2)
+	case bytecode.Ixor:
+		v2, v1 := s.PopInt(), s.PopInt()
+		s.PushInt(v1 ^ v2)
+	case bytecode.Iinc:
+		l.SetInt(int(op), l.Int(int(op))+in.Operands[1])
+
+	// long arithmetic, the shift distance is an int
+	case bytecode.Ladd:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 + v2)
+	case bytecode.Lsub:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 - v2)
+	case bytecode.Lmul:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 * v2)
+	case bytecode.Ldiv, bytecode.Lrem:
+		v2, v1 := s.PopLong(), s.PopLong()
+		if v2 == 0 {
+			return t.throw(ArithmeticException, "/ by zero")
+		}
+		if o == bytecode.Ldiv {
+			s.PushLong(v1 / v2)
+		} else {
+			s.PushLong(v1 % v2)
+		}
+	case bytecode.Lneg:
+		s.PushLong(-s.PopLong())
+	case bytecode.Lshl:
+		v2, v1 := s.PopInt(), s.PopLong()
+		s.PushLong(v1 << uint(v2&0x3f))
+	case bytecode.Lshr:
+		v2, v1 := s.PopInt(), s.PopLong()
+		s.PushLong(v1 >> uint(v2&0x3f))
+	case bytecode.Lushr:
+		v2, v1 := s.PopInt(), s.PopLong()
+		s.PushLong(int64(uint64(v1) >> uint(v2&0x3f)))
+	case bytecode.Land:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 & v2)
+	case bytecode.Lor:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 | v2)
+	case bytecode.Lxor:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushLong(v1 ^ v2)
+
+	// float and double arithmetic is IEEE 754, the remainder truncates like
+	// fmod
+	case bytecode.Fadd:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushFloat(v1 + v2)
+	case bytecode.Fsub:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushFloat(v1 - v2)
+	case bytecode.Fmul:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushFloat(v1 * v2)
+	case bytecode.Fdiv:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushFloat(v1 / v2)
+	case bytecode.Frem:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushFloat(float32(math.Mod(float64(v1), float64(v2))))
+	case bytecode.Fneg:
+		s.PushFloat(-s.PopFloat())
+	case bytecode.Dadd:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushDouble(v1 + v2)
+	case bytecode.Dsub:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushDouble(v1 - v2)
+	case bytecode.Dmul:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushDouble(v1 * v2)
+	case bytecode.Ddiv:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushDouble(v1 / v2)
+	case bytecode.Drem:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushDouble(math.Mod(v1, v2))
+	case bytecode.Dneg:
+		s.PushDouble(-s.PopDouble())
+
+	// conversions
+	case bytecode.I2l:
+		s.PushLong(int64(s.PopInt()))
+	case bytecode.I2f:
+		s.PushFloat(float32(s.PopInt()))
+	case bytecode.I2d:
+		s.PushDouble(float64(s.PopInt()))
+	case bytecode.L2i:
+		s.PushInt(int32(s.PopLong()))
+	case bytecode.L2f:
+		s.PushFloat(float32(s.PopLong()))
+	case bytecode.L2d:
+		s.PushDouble(float64(s.PopLong()))
+	case bytecode.F2i:
+		s.PushInt(d2i(float64(s.PopFloat())))
+	case bytecode.F2l:
+		s.PushLong(d2l(float64(s.PopFloat())))
+	case bytecode.F2d:
+		s.PushDouble(float64(s.PopFloat()))
+	case bytecode.D2i:
+		s.PushInt(d2i(s.PopDouble()))
+	case bytecode.D2l:
+		s.PushLong(d2l(s.PopDouble()))
+	case bytecode.D2f:
+		s.PushFloat(float32(s.PopDouble()))
+	case bytecode.I2b:
+		s.PushInt(int32(int8(s.PopInt())))
+	case bytecode.I2c:
+		s.PushInt(int32(uint16(s.PopInt())))
+	case bytecode.I2s:
+		s.PushInt(int32(int16(s.PopInt())))
+
+	// comparisons, a NaN operand gives -1 for the l forms and 1 for the g
+	// forms
+	case bytecode.Lcmp:
+		v2, v1 := s.PopLong(), s.PopLong()
+		s.PushInt(compare(v1 > v2, v1 < v2))
+	case bytecode.Fcmpl, bytecode.Fcmpg:
+		v2, v1 := s.PopFloat(), s.PopFloat()
+		s.PushInt(fcompare(float64(v1), float64(v2), o == bytecode.Fcmpg))
+	case bytecode.Dcmpl, bytecode.Dcmpg:
+		v2, v1 := s.PopDouble(), s.PopDouble()
+		s.PushInt(fcompare(v1, v2, o == bytecode.Dcmpg))
+	case bytecode.Ifeq, bytecode.Ifne, bytecode.Iflt, bytecode.Ifge, bytecode.Ifgt, bytecode.Ifle:
+		if cond(o-bytecode.Ifeq, s.PopInt(), 0) {
+			next = in.Branch()
+		}
+	case bytecode.IfIcmpeq, bytecode.IfIcmpne, bytecode.IfIcmplt, bytecode.IfIcmpge, bytecode.IfIcmpgt, bytecode.IfIcmple:
+		v2, v1 := s.PopInt(), s.PopInt()
+		if cond(o-bytecode.IfIcmpeq, v1, v2) {
+			next = in.Branch()
+		}
+	case bytecode.IfAcmpeq, bytecode.IfAcmpne:
+		v2, v1 := s.PopRef(), s.PopRef()
+		if (v1 == v2) == (o == bytecode.IfAcmpeq) {
+			next = in.Branch()
+		}
+	case bytecode.Ifnull, bytecode.Ifnonnull:
+		if (s.PopRef() == nil) == (o == bytecode.Ifnull) {
+			next = in.Branch()
+		}
+
+	// control transfer
+	case bytecode.Goto, bytecode.GotoW:
+		next = in.Branch()
+	case bytecode.Jsr, bytecode.JsrW:
+		// the returnAddress is the offset of the next instruction
+		s.Push(rt.Slot{Num: int64(next)})
+		next = in.Branch()
+	case bytecode.Ret:
+		next = int(l[op].Num)
+	case bytecode.Tableswitch, bytecode.Lookupswitch:
+		next = f.PC + int(in.Switch.Default)
+		key := s.PopInt()
+		for i, k := range in.Switch.Keys {
+			if k == key {
+				next = f.PC + int(in.Switch.Offsets[i])
+				break
+			}
+		}
+	case bytecode.Ireturn, bytecode.Freturn, bytecode.Areturn:
+		t.ret(s.Pop(), false)
+		return
+	case bytecode.Lreturn, bytecode.Dreturn:
+		t.ret(rt.Slot{Num: s.PopLong()}, true)
+		return
+	case bytecode.Return:
+		t.ret(rt.Slot{}, false)
+		return
+
+	// objects and arrays
+	case bytecode.New:
+		if err = t.new(f, uint16(op)); err != nil {
+			return
+		}
+	case bytecode.Getstatic, bytecode.Putstatic, bytecode.Getfield, bytecode.Putfield:
+		if err = t.field(f, o, uint16(op)); err != nil {
+			return
+		}
+	case bytecode.Newarray:
+		if err = t.newarray(f, op); err != nil {
+			return
+		}
+	case bytecode.Anewarray:
+		if err = t.anewarray(f, uint16(op)); err != nil {
+			return
+		}
+	case bytecode.Multianewarray:
+		if err = t.multianewarray(f, uint16(op), in.Operands[1]); err != nil {
+			return
+		}
+	case bytecode.Arraylength:
+		a := s.PopRef()
+		if a == nil {
+			return t.throw(NullPointerException, "Cannot read the array length because value is null")
+		}
+		if a.Array == nil {
+			return notArray(f, a)
+		}
+		s.PushInt(int32(a.ArrayLength()))
+	case bytecode.Iaload, bytecode.Laload, bytecode.Faload, bytecode.Daload,
+		bytecode.Aaload, bytecode.Baload, bytecode.Caload, bytecode.Saload:
+		if err = t.arrayLoad(f, o); err != nil {
+			return
+		}
+	case bytecode.Iastore, bytecode.Lastore, bytecode.Fastore, bytecode.Dastore,
+		bytecode.Aastore, bytecode.Bastore, bytecode.Castore, bytecode.Sastore:
+		if err = t.arrayStore(f, o); err != nil {
+			return
+		}
+
+	default:
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("unsupported instruction %s", o)}
+	}
+	t.jump(f, next)
+	return
+}
+
+// push pushes the slots in order.
+func push(s *OperandStack, vs ...rt.Slot) {
+	for _, v := range vs {
+		s.Push(v)
+	}
+}
+
+// ret pops the current frame and passes v to the invoker, in two slots if
+// wide.
+func (t *Thread) ret(v rt.Slot, wide bool) {
+	f := t.pop()
+	if f.entry {
+		t.result = v
+		return
+	}
+	s := t.top().Stack
+	if wide {
+		s.PushLong(v.Num)
+	} else if f.Method.Type.Return.Slots() > 0 {
+		s.Push(v)
+	}
+}
+
+// ldc pushes the constant at index i of the constant pool of f.
+func (t *Thread) ldc(f *Frame, i uint16) (err error) {
+	v, err := f.Method.Class.ConstantPool.Constant(i)
+	if err != nil {
+		var le *loader.Error
+		if errors.As(err, &le) {
+			return t.linkError(err)
+		}
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: err}
+	}
+	s := f.Stack
+	switch v := v.(type) {
+	case int32:
+		s.PushInt(v)
+	case float32:
+		s.PushFloat(v)
+	case int64:
+		s.PushLong(v)
+	case float64:
+		s.PushDouble(v)
+	case string:
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("String constants not supported yet")}
+	case *rt.Class:
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("Class constants not supported yet")}
+	default:
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: fmt.Errorf("unsupported constant %T", v)}
+	}
+	return
+}
+
+// cond evaluates the condition of the if<cond> at offset c from ifeq: eq,
+// ne, lt, ge, gt, le.
+func cond(c bytecode.Opcode, v1, v2 int32) bool {
+	switch c {
+	case 0:
+		return v1 == v2
+	case 1:
+		return v1 != v2
+	case 2:
+		return v1 < v2
+	case 3:
+		return v1 >= v2
+	case 4:
+		return v1 > v2
+	}
+	return v1 <= v2
+}
+
+func compare(gt, lt bool) int32 {
+	switch {
+	case gt:
+		return 1
+	case lt:
+		return -1
+	}
+	return 0
+}
+
+func fcompare(v1, v2 float64, nanGreater bool) int32 {
+	if math.IsNaN(v1) || math.IsNaN(v2) {
+		if nanGreater {
+			return 1
+		}
+		return -1
+	}
+	return compare(v1 > v2, v1 < v2)
+}
+
+// d2i converts like Java: NaN to 0, out of range values to the nearest
+// bound, Go leaves them undefined.
+func d2i(v float64) int32 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v >= math.MaxInt32:
+		return math.MaxInt32
+	case v <= math.MinInt32:
+		return math.MinInt32
+	}
+	return int32(v)
+}
+
+func d2l(v float64) int64 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v >= math.MaxInt64:
+		return math.MaxInt64
+	case v <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(v)
+}
@@ -0,0 +1,224 @@
+package interp
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/internal/classtest"
+	"github.com/wucongyou/go-jvm/loader"
+	"github.com/wucongyou/go-jvm/rt"
+)
+
+const _static = class.AccPublic | class.AccStatic
+
+// newLinker returns a linker of java/lang/Object, the interfaces of arrays,
+// the exceptions thrown by the interpreter and the classes bs.
+func newLinker(bs ...*classtest.Builder) *rt.Linker {
+	bs = append([]*classtest.Builder{classtest.Object(),
+		classtest.New("java/lang/Throwable", "java/lang/Object", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/Exception", "java/lang/Throwable", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/RuntimeException", "java/lang/Exception", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/Error", "java/lang/Throwable", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/StackOverflowError", "java/lang/Error", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/ArithmeticException", "java/lang/RuntimeException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/NullPointerException", "java/lang/RuntimeException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/IndexOutOfBoundsException", "java/lang/RuntimeException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/ArrayIndexOutOfBoundsException", "java/lang/IndexOutOfBoundsException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/NegativeArraySizeException", "java/lang/RuntimeException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/ArrayStoreException", "java/lang/RuntimeException", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/LinkageError", "java/lang/Error", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/NoClassDefFoundError", "java/lang/LinkageError", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/ExceptionInInitializerError", "java/lang/LinkageError", class.AccPublic|class.AccSuper),
+		classtest.New("java/lang/Cloneable", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract),
+		classtest.New("java/io/Serializable", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract),
+	}, bs...)
+	return rt.NewLinker(loader.New(loader.Classpath{loader.MemoryEntry(classtest.Classes(bs...))}))
+}
+
+func ints(vs ...int32) []rt.Slot {
+	res := make([]rt.Slot, len(vs))
+	for i, v := range vs {
+		res[i].Num = int64(v)
+	}
+	return res
+}
+
+func u4(v int32) []byte {
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func TestInterpreter(t *testing.T) {
+	b := classtest.New("T", "java/lang/Object", class.AccPublic|class.AccSuper)
+	b.Method(_static, "add", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x60, 0xac})
+	b.Method(_static, "div", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x6c, 0xac})
+	b.Method(_static, "rem", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x70, 0xac})
+	b.Method(_static, "shl", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x78, 0xac})
+	b.Method(_static, "ushr", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x7c, 0xac})
+	b.Method(_static, "lshl", "(JI)J", 3, 3, []byte{0x1e, 0x1c, 0x79, 0xad})
+	b.Method(_static, "fcmpl", "(FF)I", 2, 2, []byte{0x22, 0x23, 0x95, 0xac})
+	b.Method(_static, "fcmpg", "(FF)I", 2, 2, []byte{0x22, 0x23, 0x96, 0xac})
+	b.Method(_static, "d2i", "(D)I", 2, 2, []byte{0x26, 0x8e, 0xac})
+	b.Method(_static, "i2b", "(I)I", 1, 1, []byte{0x1a, 0x91, 0xac})
+	b.Method(_static, "i2c", "(I)I", 1, 1, []byte{0x1a, 0x92, 0xac})
+	// int s = 0; for (int i = 1; i <= n; i++) s += i; return s;
+	b.Method(_static, "sum", "(I)I", 2, 3, []byte{
+		0x03, 0x3c, 0x04, 0x3d, 0x1c, 0x1a, 0xa3, 0, 13,
+		0x1b, 0x1c, 0x60, 0x3c, 0x84, 2, 1, 0xa7, 0xff, 0xf4,
+		0x1b, 0xac,
+	})
+	// switch (n) { case 1: return 10; case 2: return 20; case 3: return 30; } return -1;
+	table := append([]byte{0x1a, 0xaa, 0, 0}, u4(36)...)
+	table = append(table, u4(1)...)
+	table = append(table, u4(3)...)
+	for _, o := range []int32{27, 30, 33} {
+		table = append(table, u4(o)...)
+	}
+	table = append(table, 0x10, 10, 0xac, 0x10, 20, 0xac, 0x10, 30, 0xac, 0x02, 0xac)
+	b.Method(_static, "table", "(I)I", 1, 1, table)
+	// switch (n) { case -5: return 1; case 1000: return 2; } return 0;
+	lookup := append([]byte{0x1a, 0xab, 0, 0}, u4(31)...)
+	lookup = append(lookup, u4(2)...)
+	for _, o := range []int32{-5, 27, 1000, 29} {
+		lookup = append(lookup, u4(o)...)
+	}
+	lookup = append(lookup, 0x04, 0xac, 0x05, 0xac, 0x03, 0xac)
+	b.Method(_static, "lookup", "(I)I", 1, 1, lookup)
+	// try { return a / b; } catch (ArithmeticException e) { return -1; }
+	b.Method(_static, "safeDiv", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x6c, 0xac, 0x57, 0x02, 0xac},
+		&class.ExceptionTableEntry{StartPc: 0, EndPc: 4, HandlerPc: 4, CatchType: b.Class("java/lang/ArithmeticException")})
+	// the catch type p/Missing doesn't resolve, its NoClassDefFoundError is
+	// caught by the next handler
+	b.Method(_static, "missingCatch", "(II)I", 2, 2, []byte{0x1a, 0x1b, 0x6c, 0xac, 0x57, 0x10, 0xfe, 0xac},
+		&class.ExceptionTableEntry{StartPc: 0, EndPc: 4, HandlerPc: 4, CatchType: b.Class("p/Missing")},
+		&class.ExceptionTableEntry{StartPc: 0, EndPc: 4, HandlerPc: 4, CatchType: b.Class("java/lang/NoClassDefFoundError")})
+	// 1L<<40 twice, dup2 copies both slots of the long
+	l := b.Long(1 << 40)
+	b.Method(_static, "long", "()J", 4, 0, []byte{0x14, byte(l >> 8), byte(l), 0x5c, 0x61, 0xad})
+	// x = 0; jsr add5; return x; add5: x += 5; ret
+	b.Method(_static, "jsr", "()I", 1, 2, []byte{0x03, 0x3b, 0xa8, 0, 6, 0x1a, 0xac, 0x00, 0x4c, 0x84, 0, 5, 0xa9, 1})
+	// a b -> b a b -> b a-b -> a-b b -> a-b-b
+	b.Method(_static, "dupx1", "(II)I", 3, 2, []byte{0x1a, 0x1b, 0x5a, 0x64, 0x5f, 0x64, 0xac})
+	b.Method(_static, "underflow", "()I", 2, 0, []byte{0x60, 0xac})
+	missing, str := b.Class("p/Missing"), b.String("s")
+	b.Method(_static, "ldcMissing", "()I", 1, 0, []byte{0x13, byte(missing >> 8), byte(missing), 0x57, 0x03, 0xac})
+	b.Method(_static, "ldcString", "()I", 1, 0, []byte{0x13, byte(str >> 8), byte(str), 0x57, 0x03, 0xac})
+
+	c, err := newLinker(b).Class("T")
+	if err != nil {
+		t.Errorf("failed to link, error(%v)", err)
+		t.FailNow()
+	}
+	th := NewThread(c.Linker())
+	nan, f1 := rt.Slot{Num: int64(math.Float32bits(float32(math.NaN())))}, rt.Slot{Num: int64(math.Float32bits(1))}
+	double := func(v float64) []rt.Slot {
+		return []rt.Slot{{Num: int64(math.Float64bits(v))}, {}}
+	}
+	for _, e := range []struct {
+		name string
+		args []rt.Slot
+		res  int64
+	}{
+		{"add", ints(math.MaxInt32, 1), math.MinInt32},
+		{"div", ints(math.MinInt32, -1), math.MinInt32},
+		{"div", ints(7, -2), -3},
+		{"rem", ints(-7, 2), -1},
+		{"rem", ints(math.MinInt32, -1), 0},
+		{"shl", ints(1, 33), 2},
+		{"ushr", ints(-1, 28), 15},
+		{"lshl", append([]rt.Slot{{Num: 1}, {}}, ints(65)...), 2},
+		{"fcmpl", []rt.Slot{nan, f1}, -1},
+		{"fcmpg", []rt.Slot{nan, f1}, 1},
+		{"fcmpl", []rt.Slot{f1, f1}, 0},
+		{"d2i", double(math.NaN()), 0},
+		{"d2i", double(1e20), math.MaxInt32},
+		{"d2i", double(-1e20), math.MinInt32},
+		{"d2i", double(-2.9), -2},
+		{"i2b", ints(200), -56},
+		{"i2c", ints(-1), 0xFFFF},
+		{"sum", ints(10), 55},
+		{"table", ints(2), 20},
+		{"table", ints(4), -1},
+		{"lookup", ints(1000), 2},
+		{"lookup", ints(7), 0},
+		{"safeDiv", ints(1, 0), -1},
+		{"missingCatch", ints(1, 0), -2},
+		{"long", nil, 1 << 41},
+		{"jsr", nil, 5},
+		{"dupx1", ints(10, 3), 4},
+	} {
+		var m *rt.Method
+		for _, f := range c.Methods {
+			if f.Name == e.name {
+				m = f
+			}
+		}
+		res, err := th.Invoke(m, e.args...)
+		if err != nil {
+			t.Errorf("failed to invoke %s, error(%v)", e.name, err)
+			continue
+		}
+		if m.Type.Return.Slots() == 1 {
+			res.Num = int64(int32(res.Num))
+		}
+		if res.Num != e.res {
+			t.Errorf("%s%v: expected %d, got %d", e.name, e.args, e.res, res.Num)
+		}
+	}
+
+	_, err = th.Invoke(c.Method("div", "(II)I"), ints(1, 0)...)
+	var ex *Exception
+	if !errors.As(err, &ex) || ex.Error() != "java.lang.ArithmeticException: / by zero" ||
+		len(ex.Trace) != 1 || ex.Trace[0].Method != "div" {
+		t.Errorf("expected ArithmeticException, got %v", err)
+	}
+	if len(th.StackTrace()) != 0 {
+		t.Errorf("expected an empty stack after the invocation")
+	}
+	var ie *Error
+	if _, err = th.Invoke(c.Method("underflow", "()I")); !errors.As(err, &ie) || ie.Err != errStackUnderflow {
+		t.Errorf("expected stack underflow, got %v", err)
+	}
+	if _, err = th.Invoke(c.Method("ldcMissing", "()I")); !errors.As(err, &ex) || ex.Error() != "java.lang.NoClassDefFoundError: p.Missing" {
+		t.Errorf("expected NoClassDefFoundError, got %v", err)
+	}
+	if _, err = th.Invoke(c.Method("ldcString", "()I")); !errors.As(err, &ie) || ie.Err.Error() != "String constants not supported yet" {
+		t.Errorf("expected unsupported String constant, got %v", err)
+	}
+	th.MaxDepth = 0
+	if _, err = th.Invoke(c.Method("sum", "(I)I"), ints(1)...); !errors.As(err, &ex) || ex.Object.Class.Name != StackOverflowError {
+		t.Errorf("expected StackOverflowError, got %v", err)
+	}
+}
+
+func TestStackTraceConcurrent(t *testing.T) {
+	b := classtest.New("T", "java/lang/Object", class.AccPublic|class.AccSuper)
+	// for (int i = 0; i < n; i++); return n;
+	b.Method(_static, "loop", "(I)I", 2, 2, []byte{0x03, 0x3c, 0x1b, 0x1a, 0xa2, 0, 9, 0x84, 1, 1, 0xa7, 0xff, 0xf8, 0x1a, 0xac})
+	c, err := newLinker(b).Class("T")
+	if err != nil {
+		t.Errorf("failed to link, error(%v)", err)
+		t.FailNow()
+	}
+	th := NewThread(c.Linker())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if res, err := th.Invoke(c.Method("loop", "(I)I"), ints(20000)...); err != nil || res.Num != 20000 {
+			t.Errorf("unexpected result %d, error(%v)", res.Num, err)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			for _, e := range th.StackTrace() {
+				if e.Method != "loop" {
+					t.Errorf("unexpected stack element %v", e)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package interp interprets the bytecode of linked methods on Java threads
+// (JVMS 2.5.2, 6.5).
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wucongyou/go-jvm/bytecode"
+	"github.com/wucongyou/go-jvm/rt"
+)
+
+const (
+	// DefaultMaxDepth frames a thread holds before a StackOverflowError.
+	DefaultMaxDepth = 1024
+)
+
+// exceptions thrown by the interpreter
+const (
+	ArithmeticException = "java/lang/ArithmeticException"
+	StackOverflowError  = "java/lang/StackOverflowError"
+)
+
+var (
+	errStackOverflow  = errors.New("operand stack overflow")
+	errStackUnderflow = errors.New("operand stack underflow")
+	// ErrRunning returned by Invoke on a thread that is already running.
+	ErrRunning = errors.New("interp: thread is already running")
+)
+
+// Error failure of the interpreter that isn't a Java exception, e.g. an
+// invalid instruction of unverified bytecode.
+type Error struct {
+	Method string
+	PC     int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("interp: %s pc %d: %v", e.Method, e.PC, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StackElement frame of a stack trace, Line is -1 if unknown.
+type StackElement struct {
+	Class  string
+	Method string
+	File   string
+	Line   int
+}
+
+func (m StackElement) String() string {
+	loc := "Unknown Source"
+	if m.File != "" {
+		loc = m.File
+		if m.Line >= 0 {
+			loc = fmt.Sprintf("%s:%d", m.File, m.Line)
+		}
+	}
+	return fmt.Sprintf("%s.%s(%s)", javaName(m.Class), m.Method, loc)
+}
+
+// javaName binary name of the internal name of a class (JLS 13.1).
+func javaName(name string) string {
+	return strings.Replace(name, "/", ".", -1)
+}
+
+// Exception Java exception thrown out of Thread.Invoke, Message is the
+// detail message of an exception thrown by the interpreter itself.
+type Exception struct {
+	Object  *rt.Object
+	Message string
+	Trace   []StackElement
+}
+
+func (e *Exception) Error() string {
+	n := javaName(e.Object.Class.Name)
+	if e.Message == "" {
+		return n
+	}
+	return n + ": " + e.Message
+}
+
+// Thread Java thread with its JVM stack of frames. A thread runs one
+// invocation at a time, StackTrace may be called from any goroutine.
+type Thread struct {
+	// MaxDepth frames the stack holds before a StackOverflowError.
+	MaxDepth int
+
+	linker  *rt.Linker
+	running int32
+	mu      sync.Mutex
+	frames  []*Frame
+	// result value returned by the entry frame.
+	result rt.Slot
+}
+
+// NewThread returns a thread running the methods of classes of l.
+func NewThread(l *rt.Linker) *Thread {
+	return &Thread{MaxDepth: DefaultMaxDepth, linker: l}
+}
+
+// Linker returns the linker of the thread.
+func (t *Thread) Linker() *rt.Linker {
+	return t.linker
+}
+
+// Invoke invokes m with the argument slots, this first for an instance
+// method, a long or double taking two. It returns the result, a long or
+// double in the single slot, or an *Exception if a Java exception
+// completes the invocation abruptly. The class of a static method is
+// initialized first.
+func (t *Thread) Invoke(m *rt.Method, args ...rt.Slot) (res rt.Slot, err error) {
+	if !atomic.CompareAndSwapInt32(&t.running, 0, 1) {
+		return res, ErrRunning
+	}
+	defer atomic.StoreInt32(&t.running, 0)
+	if len(args) != m.ArgSlots {
+		return res, fmt.Errorf("interp: %s takes %d argument slots, got %d", m, m.ArgSlots, len(args))
+	}
+	if m.Code == nil {
+		return res, fmt.Errorf("interp: %s has no code", m)
+	}
+	if m.IsStatic() {
+		if err = t.initialize(m.Class); err != nil {
+			return
+		}
+	}
+	base := t.depth()
+	f := newFrame(m)
+	f.entry = true
+	copy(f.Locals, args)
+	if err = t.push(f); err != nil {
+		return
+	}
+	if err = t.run(base); err != nil {
+		t.unwind(base)
+		return
+	}
+	res, t.result = t.result, rt.Slot{}
+	return
+}
+
+func (t *Thread) depth() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.frames)
+}
+
+func (t *Thread) top() *Frame {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.frames[len(t.frames)-1]
+}
+
+// push pushes f, throwing a StackOverflowError if the stack is full.
+func (t *Thread) push(f *Frame) error {
+	t.mu.Lock()
+	if len(t.frames) >= t.MaxDepth {
+		t.mu.Unlock()
+		return t.throw(StackOverflowError, "")
+	}
+	t.frames = append(t.frames, f)
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *Thread) pop() (f *Frame) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f = t.frames[len(t.frames)-1]
+	t.frames[len(t.frames)-1] = nil
+	t.frames = t.frames[:len(t.frames)-1]
+	return
+}
+
+// jump moves the pc of f to pc, under the lock StackTrace reads it with.
+func (t *Thread) jump(f *Frame, pc int) {
+	t.mu.Lock()
+	f.PC = pc
+	t.mu.Unlock()
+}
+
+// unwind pops the frames above base.
+func (t *Thread) unwind(base int) {
+	for t.depth() > base {
+		t.pop()
+	}
+}
+
+// StackTrace returns the frames of the thread, the current one first.
+func (t *Thread) StackTrace() []StackElement {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stackTrace()
+}
+
+func (t *Thread) stackTrace() (res []StackElement) {
+	res = make([]StackElement, 0, len(t.frames))
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		f := t.frames[i]
+		m := f.Method
+		res = append(res, StackElement{Class: m.Class.Name, Method: m.Name, File: m.Class.SourceFile, Line: m.LineNumber(f.PC)})
+	}
+	return
+}
+
+// throw returns a new exception of the class name to throw, or the error
+// of linking the class.
+func (t *Thread) throw(name, msg string) error {
+	c, err := t.linker.Class(name)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	trace := t.stackTrace()
+	t.mu.Unlock()
+	return &Exception{Object: rt.NewObject(c), Message: msg, Trace: trace}
+}
+
+// run executes the frames above base until the entry frame returns.
+func (t *Thread) run(base int) (err error) {
+	for t.depth() > base {
+		f := t.top()
+		if err = t.step(f); err == nil {
+			continue
+		}
+		ex, ok := err.(*Exception)
+		if !ok {
+			return
+		}
+		if err = t.handle(ex, base); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// step executes the instruction at the pc of f, a Go panic of invalid
+// bytecode is returned as an *Error.
+func (t *Thread) step(f *Frame) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if _, rte := r.(runtime.Error); !ok || (!rte && e != errStackOverflow && e != errStackUnderflow) {
+				panic(r)
+			}
+			err = &Error{Method: f.Method.String(), PC: f.PC, Err: e}
+		}
+	}()
+	var in *bytecode.Instruction
+	if in, err = bytecode.DecodeAt(f.Method.Code, f.PC); err != nil {
+		return &Error{Method: f.Method.String(), PC: f.PC, Err: err}
+	}
+	return t.execute(f, in)
+}
+
+// handle looks for a handler of ex in the frames above base from the top,
+// popping the frames without one (JVMS 2.10). A catch type that fails to
+// resolve throws its linkage error in place of ex, looked for in the
+// remaining handlers. It returns nil if a handler was found, then the top
+// frame continues at it, the exception or error to return otherwise.
+func (t *Thread) handle(ex *Exception, base int) error {
+	for t.depth() > base {
+		f := t.top()
+		for _, h := range f.Method.ExceptionTable {
+			if f.PC < int(h.StartPc) || f.PC >= int(h.EndPc) {
+				continue
+			}
+			if h.CatchType != 0 {
+				c, err := f.Method.Class.ConstantPool.Class(h.CatchType)
+				if err != nil {
+					err = t.linkError(err)
+					e, ok := err.(*Exception)
+					if !ok {
+						return err
+					}
+					ex = e
+					continue
+				}
+				if ex.Object.Class != c && !ex.Object.Class.IsSubclassOf(c) {
+					continue
+				}
+			}
+			f.Stack.Clear()
+			f.Stack.PushRef(ex.Object)
+			t.jump(f, int(h.HandlerPc))
+			return nil
+		}
+		t.pop()
+	}
+	return ex
+}
@@ -0,0 +1,238 @@
+// Package loader finds class files on a classpath and loads them like the
+// bootstrap class loader (JVMS 5.3.1).
+package loader
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNotFound returned by Entry.ReadClass when the entry doesn't hold the
+	// class.
+	ErrNotFound = errors.New("class not found")
+)
+
+// Entry a classpath entry, a directory tree or an archive of class files.
+type Entry interface {
+	// ReadClass returns the class file of the binary name in internal form,
+	// e.g. java/lang/String, ErrNotFound if the entry doesn't hold it.
+	ReadClass(name string) ([]byte, error)
+	// Close releases the resources held by the entry.
+	Close() error
+	String() string
+}
+
+// NewEntry returns the classpath entry of path, a .jar or .zip file is read
+// as an archive, anything else as a directory.
+func NewEntry(path string) (res Entry, err error) {
+	if isArchive(path) {
+		return openZip(path)
+	}
+	var fi os.FileInfo
+	if fi, err = os.Stat(path); err != nil {
+		return
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("loader: %s is neither a directory nor a jar or zip file", path)
+	}
+	return &dirEntry{dir: path}, nil
+}
+
+func isArchive(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".jar" || ext == ".zip"
+}
+
+// validName reports whether name can be mapped to a file of an entry, it
+// rejects names that would escape the entry like ../Foo.
+func validName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return false
+	}
+	for _, s := range strings.Split(name, "/") {
+		if s == "" || s == "." || s == ".." || strings.ContainsAny(s, "\\\x00") {
+			return false
+		}
+	}
+	return true
+}
+
+// dirEntry directory holding class files in package subdirectories.
+type dirEntry struct {
+	dir string
+}
+
+func (m *dirEntry) ReadClass(name string) (res []byte, err error) {
+	if !validName(name) {
+		return nil, ErrNotFound
+	}
+	res, err = ioutil.ReadFile(filepath.Join(m.dir, filepath.FromSlash(name)+".class"))
+	if os.IsNotExist(err) {
+		err = ErrNotFound
+	}
+	return
+}
+
+func (m *dirEntry) Close() error {
+	return nil
+}
+
+func (m *dirEntry) String() string {
+	return m.dir
+}
+
+// zipEntry jar or zip file, the central directory is read once when opened.
+type zipEntry struct {
+	path  string
+	r     *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+func openZip(path string) (res *zipEntry, err error) {
+	var r *zip.ReadCloser
+	if r, err = zip.OpenReader(path); err != nil {
+		return
+	}
+	res = &zipEntry{path: path, r: r, files: make(map[string]*zip.File, len(r.File))}
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, ".class") {
+			res.files[strings.TrimSuffix(f.Name, ".class")] = f
+		}
+	}
+	return
+}
+
+func (m *zipEntry) ReadClass(name string) (res []byte, err error) {
+	f, ok := m.files[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	if res, err = ioutil.ReadAll(rc); err != nil {
+		err = fmt.Errorf("loader: %s!%s: %v", m.path, f.Name, err)
+	}
+	return
+}
+
+func (m *zipEntry) Close() error {
+	return m.r.Close()
+}
+
+func (m *zipEntry) String() string {
+	return m.path
+}
+
+// Classpath entries searched in order, the first one holding a class wins.
+type Classpath []Entry
+
+// ParseClasspath opens the entries of a classpath separated by
+// os.PathListSeparator. An entry dir/* stands for all jar files in dir, in
+// lexical order. Like java, entries that don't exist are ignored.
+func ParseClasspath(s string) (res Classpath, err error) {
+	for _, p := range filepath.SplitList(s) {
+		var paths []string
+		if p == "" {
+			continue
+		} else if p == "*" || strings.HasSuffix(p, string(filepath.Separator)+"*") {
+			if paths, err = jars(strings.TrimSuffix(p, "*")); err != nil {
+				res.Close()
+				return nil, err
+			}
+		} else {
+			paths = []string{p}
+		}
+		for _, path := range paths {
+			var e Entry
+			if e, err = NewEntry(path); err != nil {
+				if os.IsNotExist(err) {
+					err = nil
+					continue
+				}
+				res.Close()
+				return nil, err
+			}
+			res = append(res, e)
+		}
+	}
+	return
+}
+
+// jars returns the jar files in dir, the current directory if empty.
+func jars(dir string) (res []string, err error) {
+	if dir == "" {
+		dir = "."
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() && strings.EqualFold(filepath.Ext(fi.Name()), ".jar") {
+			res = append(res, filepath.Join(dir, fi.Name()))
+		}
+	}
+	sort.Strings(res)
+	return
+}
+
+// ReadClass returns the class file of name from the first entry holding it,
+// together with that entry.
+func (m Classpath) ReadClass(name string) (res []byte, e Entry, err error) {
+	for _, e = range m {
+		if res, err = e.ReadClass(name); err != ErrNotFound {
+			return
+		}
+	}
+	return nil, nil, ErrNotFound
+}
+
+// Close closes all entries and returns the first error.
+func (m Classpath) Close() (err error) {
+	for _, e := range m {
+		if cerr := e.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+func (m Classpath) String() string {
+	ss := make([]string, len(m))
+	for i, e := range m {
+		ss[i] = e.String()
+	}
+	return strings.Join(ss, string(os.PathListSeparator))
+}
+
+// MemoryEntry classpath entry of class files held in memory, by binary name
+// in internal form.
+type MemoryEntry map[string][]byte
+
+func (m MemoryEntry) ReadClass(name string) ([]byte, error) {
+	if b, ok := m[name]; ok {
+		return b, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m MemoryEntry) Close() error {
+	return nil
+}
+
+func (m MemoryEntry) String() string {
+	return "memory"
+}
@@ -0,0 +1,433 @@
+package loader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	_imageMagic      = 0xCAFEDADA
+	_imageMajor      = 1
+	_imageHeaderSize = 7 * 4
+	// _hashMultiplier FNV prime the image strings are hashed with.
+	_hashMultiplier = 0x01000193
+
+	_compressedMagic      = 0xCAFEFAFA
+	_compressedHeaderSize = 4 + 8 + 8 + 4 + 4 + 1
+)
+
+// kinds of location attributes.
+const (
+	_attrEnd = iota
+	_attrModule
+	_attrParent
+	_attrBase
+	_attrExtension
+	_attrOffset
+	_attrCompressed
+	_attrUncompressed
+	_attrCount
+)
+
+var (
+	ErrImageFormat = errors.New("invalid jimage file")
+)
+
+// ImageHeader header of a jimage file.
+type ImageHeader struct {
+	Magic         uint32
+	MajorVersion  uint16
+	MinorVersion  uint16
+	Flags         uint32
+	ResourceCount uint32
+	TableLength   uint32
+	LocationsSize uint32
+	StringsSize   uint32
+}
+
+// Location resource of a jimage, its name is /Module/Parent/Base.Extension
+// without the empty parts.
+type Location struct {
+	Module    string
+	Parent    string
+	Base      string
+	Extension string
+	// Offset of the content from the end of the index.
+	Offset uint64
+	// CompressedSize size of the stored content, 0 if not compressed.
+	CompressedSize   uint64
+	UncompressedSize uint64
+}
+
+// Name returns the full name of the resource, e.g.
+// /java.base/java/lang/Object.class.
+func (m *Location) Name() string {
+	b := new(strings.Builder)
+	if m.Module != "" {
+		b.WriteString("/" + m.Module + "/")
+	}
+	if m.Parent != "" {
+		b.WriteString(m.Parent + "/")
+	}
+	b.WriteString(m.Base)
+	if m.Extension != "" {
+		b.WriteString("." + m.Extension)
+	}
+	return b.String()
+}
+
+// Image jimage file like lib/modules of a JDK 9+ home, resources are found
+// through the perfect hash table of the index.
+type Image struct {
+	Header ImageHeader
+
+	path      string
+	f         *os.File
+	order     binary.ByteOrder
+	redirect  []int32
+	offsets   []uint32
+	locations []byte
+	strings   []byte
+	// indexSize start of the resources in the file.
+	indexSize int64
+	size      int64
+
+	once sync.Once
+	// packages modules holding classes of each package, e.g. java/lang.
+	packages map[string][]string
+	// packagesErr error of reading the locations for packages.
+	packagesErr error
+}
+
+// OpenImage opens the jimage file path and reads its index.
+func OpenImage(path string) (res *Image, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	res = &Image{path: path, f: f}
+	if err = res.readIndex(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("loader: %s: %w", path, err)
+	}
+	return
+}
+
+func (m *Image) readIndex() (err error) {
+	b := make([]byte, _imageHeaderSize)
+	if _, err = m.f.ReadAt(b, 0); err != nil {
+		return ErrImageFormat
+	}
+	// the image is written in the byte order of the platform that built it
+	switch {
+	case binary.LittleEndian.Uint32(b) == _imageMagic:
+		m.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(b) == _imageMagic:
+		m.order = binary.BigEndian
+	default:
+		return ErrImageFormat
+	}
+	h := &m.Header
+	h.Magic = _imageMagic
+	v := m.order.Uint32(b[4:])
+	h.MajorVersion, h.MinorVersion = uint16(v>>16), uint16(v)
+	h.Flags = m.order.Uint32(b[8:])
+	h.ResourceCount = m.order.Uint32(b[12:])
+	h.TableLength = m.order.Uint32(b[16:])
+	h.LocationsSize = m.order.Uint32(b[20:])
+	h.StringsSize = m.order.Uint32(b[24:])
+	if h.MajorVersion != _imageMajor {
+		return fmt.Errorf("unsupported jimage version %d.%d", h.MajorVersion, h.MinorVersion)
+	}
+
+	m.indexSize = _imageHeaderSize + int64(h.TableLength)*8 + int64(h.LocationsSize) + int64(h.StringsSize)
+	var fi os.FileInfo
+	if fi, err = m.f.Stat(); err != nil {
+		return
+	}
+	if m.size = fi.Size(); m.indexSize > m.size {
+		return ErrImageFormat
+	}
+	b = make([]byte, m.indexSize-_imageHeaderSize)
+	if _, err = m.f.ReadAt(b, _imageHeaderSize); err != nil {
+		return
+	}
+	m.redirect = make([]int32, h.TableLength)
+	m.offsets = make([]uint32, h.TableLength)
+	for i := range m.redirect {
+		m.redirect[i] = int32(m.order.Uint32(b[i*4:]))
+	}
+	b = b[h.TableLength*4:]
+	for i := range m.offsets {
+		m.offsets[i] = m.order.Uint32(b[i*4:])
+	}
+	b = b[h.TableLength*4:]
+	m.locations, m.strings = b[:h.LocationsSize], b[h.LocationsSize:]
+	return
+}
+
+// imageHash hashes the bytes of s with the seed like the jimage builder.
+func imageHash(s string, seed int32) int32 {
+	for i := 0; i < len(s); i++ {
+		seed = (seed * _hashMultiplier) ^ int32(s[i])
+	}
+	return seed & 0x7FFFFFFF
+}
+
+// Find returns the location of the resource name, e.g.
+// /java.base/java/lang/Object.class, nil if the image doesn't hold it.
+func (m *Image) Find(name string) (res *Location, err error) {
+	n := int32(len(m.redirect))
+	if n == 0 {
+		return
+	}
+	i := imageHash(name, _hashMultiplier) % n
+	switch r := m.redirect[i]; {
+	case r < 0:
+		i = -1 - r
+	case r > 0:
+		i = imageHash(name, r) % n
+	default:
+		return
+	}
+	if i >= n {
+		return nil, ErrImageFormat
+	}
+	if res, err = m.location(m.offsets[i]); err != nil {
+		return
+	}
+	if res.Name() != name {
+		return nil, nil
+	}
+	return
+}
+
+// location decodes the attributes at offset of the locations: a byte kind<<3
+// | length-1 followed by a big-endian value of length bytes, until kind 0.
+func (m *Image) location(off uint32) (res *Location, err error) {
+	var attrs [_attrCount]uint64
+	b := m.locations
+	for i := int(off); ; {
+		if i >= len(b) {
+			return nil, ErrImageFormat
+		}
+		kind, n := int(b[i]>>3), int(b[i]&7)+1
+		if kind == _attrEnd {
+			break
+		}
+		if kind >= _attrCount || i+1+n > len(b) {
+			return nil, ErrImageFormat
+		}
+		var v uint64
+		for _, c := range b[i+1 : i+1+n] {
+			v = v<<8 | uint64(c)
+		}
+		attrs[kind] = v
+		i += 1 + n
+	}
+	res = &Location{
+		Offset:           attrs[_attrOffset],
+		CompressedSize:   attrs[_attrCompressed],
+		UncompressedSize: attrs[_attrUncompressed],
+	}
+	for _, s := range []struct {
+		kind int
+		p    *string
+	}{
+		{_attrModule, &res.Module},
+		{_attrParent, &res.Parent},
+		{_attrBase, &res.Base},
+		{_attrExtension, &res.Extension},
+	} {
+		if *s.p, err = m.string(attrs[s.kind]); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// string returns the NUL-terminated string at off of the strings.
+func (m *Image) string(off uint64) (string, error) {
+	if off >= uint64(len(m.strings)) {
+		return "", ErrImageFormat
+	}
+	b := m.strings[off:]
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", ErrImageFormat
+	}
+	return string(b[:i]), nil
+}
+
+// Resource returns the uncompressed content of a location.
+func (m *Image) Resource(l *Location) (res []byte, err error) {
+	size := l.UncompressedSize
+	if l.CompressedSize != 0 {
+		size = l.CompressedSize
+	}
+	if l.Offset > uint64(m.size-m.indexSize) || size > uint64(m.size-m.indexSize)-l.Offset {
+		return nil, ErrImageFormat
+	}
+	res = make([]byte, size)
+	if _, err = m.f.ReadAt(res, m.indexSize+int64(l.Offset)); err != nil {
+		if err == io.EOF {
+			err = ErrImageFormat
+		}
+		return nil, err
+	}
+	if l.CompressedSize == 0 {
+		return
+	}
+	if res, err = m.decompress(res); err != nil {
+		return nil, fmt.Errorf("loader: %s: %s: %w", m.path, l.Name(), err)
+	}
+	if uint64(len(res)) != l.UncompressedSize {
+		return nil, fmt.Errorf("loader: %s: %s: uncompressed size %d, expected %d", m.path, l.Name(), len(res), l.UncompressedSize)
+	}
+	return
+}
+
+// decompress undoes the compression of a resource, each compression adds a
+// header, the last one applied comes first.
+func (m *Image) decompress(b []byte) (res []byte, err error) {
+	for len(b) >= _compressedHeaderSize && m.order.Uint32(b) == _compressedMagic {
+		size := m.order.Uint64(b[4:])
+		usize := m.order.Uint64(b[12:])
+		var name string
+		if name, err = m.string(uint64(m.order.Uint32(b[20:]))); err != nil {
+			return
+		}
+		if size > uint64(len(b)-_compressedHeaderSize) {
+			return nil, ErrImageFormat
+		}
+		data := b[_compressedHeaderSize : _compressedHeaderSize+size]
+		switch name {
+		case "zip":
+			var r io.ReadCloser
+			if r, err = zlib.NewReader(bytes.NewReader(data)); err != nil {
+				return
+			}
+			b, err = ioutil.ReadAll(io.LimitReader(r, int64(usize)+1))
+			r.Close()
+			if err != nil {
+				return
+			}
+		default:
+			return nil, fmt.Errorf("unsupported decompressor %q", name)
+		}
+		if uint64(len(b)) != usize {
+			return nil, fmt.Errorf("decompressed size %d, expected %d", len(b), usize)
+		}
+	}
+	return b, nil
+}
+
+// Locations returns the locations of all resources.
+func (m *Image) Locations() (res []*Location, err error) {
+	res = make([]*Location, 0, len(m.offsets))
+	for _, off := range m.offsets {
+		var l *Location
+		if l, err = m.location(off); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return
+}
+
+// Modules returns the modules holding classes of the package pkg in internal
+// form, e.g. java/lang. The packages are indexed on the first call, an error
+// of it is returned by every call.
+func (m *Image) Modules(pkg string) ([]string, error) {
+	m.once.Do(func() {
+		var ls []*Location
+		if ls, m.packagesErr = m.Locations(); m.packagesErr != nil {
+			return
+		}
+		m.packages = make(map[string][]string)
+		for _, l := range ls {
+			// /packages and /modules hold the directory tree of the image
+			if l.Extension != "class" || l.Module == "" || l.Module == "packages" || l.Module == "modules" {
+				continue
+			}
+			if !contains(m.packages[l.Parent], l.Module) {
+				m.packages[l.Parent] = append(m.packages[l.Parent], l.Module)
+			}
+		}
+	})
+	if m.packagesErr != nil {
+		return nil, m.packagesErr
+	}
+	return m.packages[pkg], nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadClass returns the class file of name from the module holding its
+// package, it makes the image a classpath Entry.
+func (m *Image) ReadClass(name string) (res []byte, err error) {
+	if !validName(name) {
+		return nil, ErrNotFound
+	}
+	pkg := ""
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		pkg = name[:i]
+	}
+	ms, err := m.Modules(pkg)
+	if err != nil {
+		return
+	}
+	for _, mod := range ms {
+		var l *Location
+		if l, err = m.Find("/" + mod + "/" + name + ".class"); err != nil {
+			return
+		}
+		if l != nil {
+			return m.Resource(l)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Image) Close() error {
+	return m.f.Close()
+}
+
+func (m *Image) String() string {
+	return m.path
+}
+
+// OpenJavaHome returns the classpath of the runtime classes of the JDK or JRE
+// at home: lib/modules since JDK 9, the jar files of lib before.
+func OpenJavaHome(home string) (res Classpath, err error) {
+	path := filepath.Join(home, "lib", "modules")
+	if _, err = os.Stat(path); err == nil {
+		var m *Image
+		if m, err = OpenImage(path); err != nil {
+			return
+		}
+		return Classpath{m}, nil
+	}
+	for _, lib := range []string{filepath.Join(home, "jre", "lib"), filepath.Join(home, "lib")} {
+		if _, err = os.Stat(filepath.Join(lib, "rt.jar")); err == nil {
+			return ParseClasspath(filepath.Join(lib, "*"))
+		}
+	}
+	return nil, fmt.Errorf("loader: no runtime classes in %s", home)
+}
@@ -0,0 +1,231 @@
+package loader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/internal/classtest"
+)
+
+type imageResource struct {
+	module, parent, base, ext string
+	content                   []byte
+	compress                  bool
+}
+
+// imageBuilder writes a jimage in little-endian order with a perfect hash
+// table like the jlink image writer.
+type imageBuilder struct {
+	strings *bytes.Buffer
+	offs    map[string]int
+}
+
+func (b *imageBuilder) string(s string) uint64 {
+	if off, ok := b.offs[s]; ok {
+		return uint64(off)
+	}
+	b.offs[s] = b.strings.Len()
+	b.strings.WriteString(s)
+	b.strings.WriteByte(0)
+	return uint64(b.offs[s])
+}
+
+func attribute(b *bytes.Buffer, kind int, v uint64) {
+	n := 1
+	for v>>(8*uint(n)) != 0 {
+		n++
+	}
+	b.WriteByte(byte(kind<<3 | (n - 1)))
+	for i := n - 1; i >= 0; i-- {
+		b.WriteByte(byte(v >> (8 * uint(i))))
+	}
+}
+
+func buildImage(rs []imageResource) []byte {
+	ib := &imageBuilder{strings: new(bytes.Buffer), offs: make(map[string]int)}
+	ib.string("")
+	le := binary.LittleEndian
+	locs, content := new(bytes.Buffer), new(bytes.Buffer)
+	names := make([]string, len(rs))
+	locOffs := make([]uint32, len(rs))
+	for i, r := range rs {
+		data := r.content
+		var csize uint64
+		if r.compress {
+			z := new(bytes.Buffer)
+			zw := zlib.NewWriter(z)
+			zw.Write(r.content)
+			zw.Close()
+			h := make([]byte, _compressedHeaderSize)
+			le.PutUint32(h, _compressedMagic)
+			le.PutUint64(h[4:], uint64(z.Len()))
+			le.PutUint64(h[12:], uint64(len(r.content)))
+			le.PutUint32(h[20:], uint32(ib.string("zip")))
+			h[28] = 1
+			data = append(h, z.Bytes()...)
+			csize = uint64(len(data))
+		}
+		l := &Location{Module: r.module, Parent: r.parent, Base: r.base, Extension: r.ext}
+		names[i] = l.Name()
+		locOffs[i] = uint32(locs.Len())
+		attribute(locs, _attrModule, ib.string(r.module))
+		attribute(locs, _attrParent, ib.string(r.parent))
+		attribute(locs, _attrBase, ib.string(r.base))
+		attribute(locs, _attrExtension, ib.string(r.ext))
+		attribute(locs, _attrOffset, uint64(content.Len()))
+		attribute(locs, _attrCompressed, csize)
+		attribute(locs, _attrUncompressed, uint64(len(r.content)))
+		locs.WriteByte(0)
+		content.Write(data)
+	}
+
+	// perfect hash: a bucket with one name redirects to its slot, a bucket
+	// with several to the seed that spreads them to free slots
+	n := int32(len(rs))
+	redirect, offsets := make([]int32, n), make([]uint32, n)
+	buckets := make([][]int, n)
+	for i, name := range names {
+		h := imageHash(name, _hashMultiplier) % n
+		buckets[h] = append(buckets[h], i)
+	}
+	used := make([]bool, n)
+	for h, bs := range buckets {
+		if len(bs) < 2 {
+			continue
+		}
+	seeds:
+		for seed := int32(1); ; seed++ {
+			slots := make(map[int32]bool)
+			for _, i := range bs {
+				s := imageHash(names[i], seed) % n
+				if used[s] || slots[s] {
+					continue seeds
+				}
+				slots[s] = true
+			}
+			for _, i := range bs {
+				s := imageHash(names[i], seed) % n
+				used[s], offsets[s] = true, locOffs[i]
+			}
+			redirect[h] = seed
+			break
+		}
+	}
+	free := int32(0)
+	for h, bs := range buckets {
+		if len(bs) != 1 {
+			continue
+		}
+		for used[free] {
+			free++
+		}
+		used[free], offsets[free] = true, locOffs[bs[0]]
+		redirect[h] = -1 - free
+	}
+
+	b := new(bytes.Buffer)
+	for _, v := range []uint32{_imageMagic, _imageMajor << 16, 0, uint32(n), uint32(n), uint32(locs.Len()), uint32(ib.strings.Len())} {
+		binary.Write(b, le, v)
+	}
+	binary.Write(b, le, redirect)
+	binary.Write(b, le, offsets)
+	b.Write(locs.Bytes())
+	b.Write(ib.strings.Bytes())
+	b.Write(content.Bytes())
+	return b.Bytes()
+}
+
+func TestImage(t *testing.T) {
+	object := classtest.New("java/lang/Object", "", 0x21).Bytes()
+	str := classtest.New("java/lang/String", "java/lang/Object", 0x31).Bytes()
+	rs := []imageResource{
+		{"java.base", "java/lang", "Object", "class", object, false},
+		{"java.base", "java/lang", "String", "class", str, true},
+		{"java.base", "", "module-info", "class", []byte{0xCA}, false},
+		{"java.sql", "java/sql", "Date", "class", classtest.New("java/sql/Date", "java/lang/Object", 0x21).Bytes(), false},
+		{"java.base", "java/lang", "uniName", "dat", []byte("data"), false},
+		{"packages", "java.lang", "java.base", "", nil, false},
+		{"modules", "java.base/java/lang", "Object", "class", nil, false},
+	}
+	dir, err := ioutil.TempDir("", "jimage")
+	if err != nil {
+		t.Errorf("failed to create temp dir, error(%v)", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+	if err = ioutil.WriteFile(filepath.Join(dir, "lib", "modules"), buildImage(rs), 0644); err != nil {
+		t.Errorf("failed to write image, error(%v)", err)
+		t.FailNow()
+	}
+
+	cp, err := OpenJavaHome(dir)
+	if err != nil {
+		t.Errorf("failed to open java home, error(%v)", err)
+		t.FailNow()
+	}
+	defer cp.Close()
+	img := cp[0].(*Image)
+	if img.Header.ResourceCount != uint32(len(rs)) || img.Header.MajorVersion != 1 {
+		t.Errorf("unexpected header %+v", img.Header)
+	}
+	for _, r := range rs {
+		l, err := img.Find((&Location{Module: r.module, Parent: r.parent, Base: r.base, Extension: r.ext}).Name())
+		if err != nil || l == nil {
+			t.Errorf("failed to find %s, error(%v)", r.base, err)
+			continue
+		}
+		b, err := img.Resource(l)
+		if err != nil || !bytes.Equal(b, r.content) {
+			t.Errorf("unexpected content of %s, error(%v)", l.Name(), err)
+		}
+	}
+	if l, err := img.Find("/java.base/java/lang/Missing.class"); l != nil || err != nil {
+		t.Errorf("expected no location, got %v error(%v)", l, err)
+	}
+
+	l := New(cp)
+	s, err := l.Load("java/lang/String")
+	if err != nil {
+		t.Errorf("failed to load, error(%v)", err)
+		t.FailNow()
+	}
+	if s.Super == nil || s.Super.Name != "java/lang/Object" || s.Source != img {
+		t.Errorf("unexpected class %+v", s)
+	}
+	if _, err = l.Load("java/sql/Date"); err != nil {
+		t.Errorf("failed to load, error(%v)", err)
+	}
+	_, err = l.Load("java/lang/Thread")
+	var le *Error
+	if !errors.As(err, &le) || le.Kind != NoClassDefFoundError || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected class not found, got %v", err)
+	}
+
+	// an index error of the packages is kept for later calls
+	corrupt, err := OpenImage(filepath.Join(dir, "lib", "modules"))
+	if err != nil {
+		t.Errorf("failed to open image, error(%v)", err)
+		t.FailNow()
+	}
+	defer corrupt.Close()
+	corrupt.offsets[0] = uint32(len(corrupt.locations))
+	for i := 0; i < 2; i++ {
+		if _, err = corrupt.ReadClass("java/lang/Object"); err != ErrImageFormat {
+			t.Errorf("expected format error, got %v", err)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad")
+	ioutil.WriteFile(bad, []byte("not an image"), 0644)
+	if _, err = OpenImage(bad); err == nil || !strings.Contains(err.Error(), ErrImageFormat.Error()) {
+		t.Errorf("expected format error, got %v", err)
+	}
+}
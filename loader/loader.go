@@ -0,0 +1,180 @@
+package loader
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wucongyou/go-jvm/class"
+)
+
+// Java errors a failed load results in.
+const (
+	NoClassDefFoundError         = "java/lang/NoClassDefFoundError"
+	ClassFormatError             = "java/lang/ClassFormatError"
+	ClassCircularityError        = "java/lang/ClassCircularityError"
+	IncompatibleClassChangeError = "java/lang/IncompatibleClassChangeError"
+	LinkageError                 = "java/lang/LinkageError"
+	UnsupportedClassVersionError = "java/lang/UnsupportedClassVersionError"
+)
+
+// Error failure to load a class, Kind is the Java error the JVM throws for
+// it, e.g. java/lang/NoClassDefFoundError. Package rt reports failures to
+// link or initialize a class and to resolve a symbolic reference with it
+// too.
+type Error struct {
+	Kind  string
+	Class string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	if e.Class == "" {
+		return fmt.Sprintf("loader: %s: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("loader: %s: %s: %v", e.Kind, e.Class, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Class class loaded by a Loader.
+type Class struct {
+	Name string
+	File *class.ClassFile
+	// Source the entry the class was read from, nil if defined from bytes.
+	Source Entry
+	// Super superclass, nil for java/lang/Object and module-info.
+	Super      *Class
+	Interfaces []*Class
+}
+
+// IsInterface reports whether the class is an interface.
+func (m *Class) IsInterface() bool {
+	return m.File.AccessFlags&class.AccInterface != 0
+}
+
+// Loader bootstrap class loader, loads classes from a classpath and caches
+// them by name. Loading a class loads its superclass and superinterfaces
+// first. A Loader is safe for concurrent use.
+type Loader struct {
+	cp      Classpath
+	mu      sync.Mutex
+	classes map[string]*Class
+	// loading classes whose superclass or superinterfaces are being loaded.
+	loading map[string]bool
+}
+
+// New returns a loader reading classes from cp.
+func New(cp Classpath) *Loader {
+	return &Loader{cp: cp, classes: make(map[string]*Class), loading: make(map[string]bool)}
+}
+
+// Classpath returns the classpath of the loader.
+func (m *Loader) Classpath() Classpath {
+	return m.cp
+}
+
+// Loaded returns the loaded class of name, nil if it hasn't been loaded.
+func (m *Loader) Loaded(name string) *Class {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.classes[name]
+}
+
+// Load returns the class of the binary name in internal form, e.g.
+// java/lang/String, loading it from the classpath if necessary.
+func (m *Loader) Load(name string) (res *Class, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load(name)
+}
+
+// Define defines the class file b like ClassLoader.defineClass, it fails
+// with a LinkageError if a class of the same name is already loaded.
+func (m *Loader) Define(b []byte) (res *Class, err error) {
+	var cf *class.ClassFile
+	if cf, err = class.ParseBytes(b); err != nil {
+		return nil, &Error{Kind: ClassFormatError, Err: err}
+	}
+	var name string
+	if name, err = cf.ClassName(); err != nil {
+		return nil, &Error{Kind: ClassFormatError, Err: err}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.define(name, cf, nil)
+}
+
+func (m *Loader) load(name string) (res *Class, err error) {
+	if res = m.classes[name]; res != nil {
+		return
+	}
+	if m.loading[name] {
+		return nil, &Error{Kind: ClassCircularityError, Class: name, Err: fmt.Errorf("class is its own superclass or superinterface")}
+	}
+	b, e, err := m.cp.ReadClass(name)
+	if err != nil {
+		return nil, &Error{Kind: NoClassDefFoundError, Class: name, Err: err}
+	}
+	cf, err := class.ParseBytes(b)
+	if err != nil {
+		return nil, &Error{Kind: ClassFormatError, Class: name, Err: err}
+	}
+	var n string
+	if n, err = cf.ClassName(); err != nil {
+		return nil, &Error{Kind: ClassFormatError, Class: name, Err: err}
+	}
+	if n != name {
+		return nil, &Error{Kind: NoClassDefFoundError, Class: name, Err: fmt.Errorf("wrong name: %s", n)}
+	}
+	return m.define(name, cf, e)
+}
+
+// define resolves the superclass and superinterfaces of cf and records the
+// class, m.mu is held.
+func (m *Loader) define(name string, cf *class.ClassFile, e Entry) (res *Class, err error) {
+	if m.classes[name] != nil || m.loading[name] {
+		return nil, &Error{Kind: LinkageError, Class: name, Err: fmt.Errorf("duplicate class definition")}
+	}
+	if cf.MajorVersion < class.MinMajorVersion || cf.MajorVersion > class.MaxMajorVersion {
+		return nil, &Error{Kind: UnsupportedClassVersionError, Class: name,
+			Err: fmt.Errorf("class file version %d.%d", cf.MajorVersion, cf.MinorVersion)}
+	}
+	res = &Class{Name: name, File: cf, Source: e}
+	var super string
+	var ins []string
+	if super, err = cf.SuperClassName(); err == nil {
+		ins, err = cf.InterfaceNames()
+	}
+	if err != nil {
+		return nil, &Error{Kind: ClassFormatError, Class: name, Err: err}
+	}
+	if super == "" && name != "java/lang/Object" && cf.AccessFlags&class.AccModule == 0 {
+		return nil, &Error{Kind: ClassFormatError, Class: name, Err: fmt.Errorf("no superclass")}
+	}
+
+	m.loading[name] = true
+	defer delete(m.loading, name)
+	if super != "" {
+		if res.Super, err = m.load(super); err != nil {
+			return nil, err
+		}
+		if res.Super.IsInterface() {
+			return nil, &Error{Kind: IncompatibleClassChangeError, Class: name,
+				Err: fmt.Errorf("superclass %s is an interface", super)}
+		}
+	}
+	res.Interfaces = make([]*Class, len(ins))
+	for i, in := range ins {
+		if res.Interfaces[i], err = m.load(in); err != nil {
+			return nil, err
+		}
+		if !res.Interfaces[i].IsInterface() {
+			return nil, &Error{Kind: IncompatibleClassChangeError, Class: name,
+				Err: fmt.Errorf("%s is not an interface", in)}
+		}
+	}
+	m.classes[name] = res
+	return
+}
@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/internal/classtest"
+)
+
+func writeClass(t *testing.T, dir, name string, b []byte) {
+	path := filepath.Join(dir, filepath.FromSlash(name)+".class")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Errorf("failed to create dir, error(%v)", err)
+		t.FailNow()
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Errorf("failed to write class, error(%v)", err)
+		t.FailNow()
+	}
+}
+
+func writeJar(t *testing.T, path string, classes map[string][]byte) {
+	b := new(bytes.Buffer)
+	w := zip.NewWriter(b)
+	for name, c := range classes {
+		f, err := w.Create(name + ".class")
+		if err == nil {
+			_, err = f.Write(c)
+		}
+		if err != nil {
+			t.Errorf("failed to write jar, error(%v)", err)
+			t.FailNow()
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("failed to close jar, error(%v)", err)
+		t.FailNow()
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Errorf("failed to write jar, error(%v)", err)
+		t.FailNow()
+	}
+}
+
+func TestLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loader")
+	if err != nil {
+		t.Errorf("failed to create temp dir, error(%v)", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	classes := filepath.Join(dir, "classes")
+	lib := filepath.Join(dir, "lib")
+	os.MkdirAll(lib, 0755)
+	writeClass(t, classes, "java/lang/Object", classtest.New("java/lang/Object", "", class.AccPublic).Bytes())
+	writeClass(t, classes, "app/Main", classtest.New("app/Main", "app/Base", class.AccPublic, "app/Api").Bytes())
+	writeClass(t, classes, "app/Wrong", classtest.New("app/Right", "java/lang/Object", class.AccPublic).Bytes())
+	writeClass(t, classes, "app/A", classtest.New("app/A", "app/B", class.AccPublic).Bytes())
+	writeClass(t, classes, "app/B", classtest.New("app/B", "app/A", class.AccPublic).Bytes())
+	writeJar(t, filepath.Join(lib, "base.jar"), map[string][]byte{
+		"app/Base": classtest.New("app/Base", "java/lang/Object", class.AccPublic).Bytes(),
+		// shadowed by classes
+		"app/Main": classtest.New("app/Main", "java/lang/Object", class.AccPublic).Bytes(),
+	})
+	writeJar(t, filepath.Join(lib, "z.jar"), map[string][]byte{
+		"app/Api": classtest.New("app/Api", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract).Bytes(),
+	})
+	sep := string(os.PathListSeparator)
+	cp, err := ParseClasspath(classes + sep + filepath.Join(dir, "missing") + sep + filepath.Join(lib, "*"))
+	if err != nil {
+		t.Errorf("failed to parse classpath, error(%v)", err)
+		t.FailNow()
+	}
+	defer cp.Close()
+	if len(cp) != 3 || !strings.HasSuffix(cp[1].String(), "base.jar") || !strings.HasSuffix(cp[2].String(), "z.jar") {
+		t.Errorf("unexpected classpath %s", cp)
+	}
+
+	l := New(cp)
+	c, err := l.Load("app/Main")
+	if err != nil {
+		t.Errorf("failed to load, error(%v)", err)
+		t.FailNow()
+	}
+	if c.Source != cp[0] || c.Super.Name != "app/Base" || c.Super.Source != cp[1] ||
+		len(c.Interfaces) != 1 || c.Interfaces[0].Name != "app/Api" || c.Super.Super.Name != "java/lang/Object" {
+		t.Errorf("unexpected class %+v", c)
+	}
+	if c2, _ := l.Load("app/Main"); c2 != c || l.Loaded("app/Base") != c.Super {
+		t.Errorf("expected cached classes")
+	}
+
+	for _, e := range []struct {
+		name string
+		kind string
+	}{
+		{"app/Missing", NoClassDefFoundError},
+		{"../app/Main", NoClassDefFoundError},
+		{"app/Wrong", NoClassDefFoundError},
+		{"app/A", ClassCircularityError},
+	} {
+		_, err := l.Load(e.name)
+		var le *Error
+		if !errors.As(err, &le) || le.Kind != e.kind {
+			t.Errorf("expected %s for %s, got %v", e.kind, e.name, err)
+		}
+	}
+	if l.Loaded("app/A") != nil || l.Loaded("app/B") != nil {
+		t.Errorf("expected no classes recorded after a circularity error")
+	}
+
+	_, err = l.Define(classtest.New("app/Base", "java/lang/Object", class.AccPublic).Bytes())
+	var le *Error
+	if !errors.As(err, &le) || le.Kind != LinkageError {
+		t.Errorf("expected duplicate definition, got %v", err)
+	}
+	if c, err = l.Define(classtest.New("app/Impl", "app/Base", class.AccPublic, "app/Api").Bytes()); err != nil || c.Source != nil {
+		t.Errorf("failed to define, error(%v)", err)
+	}
+	_, err = l.Define(classtest.New("app/Bad", "app/Api", class.AccPublic).Bytes())
+	if !errors.As(err, &le) || le.Kind != IncompatibleClassChangeError {
+		t.Errorf("expected incompatible class change, got %v", err)
+	}
+}
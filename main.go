@@ -1,7 +1,67 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/interp"
+	"github.com/wucongyou/go-jvm/loader"
+	"github.com/wucongyou/go-jvm/rt"
+)
 
 func main() {
-	fmt.Println("ok")
+	cp := flag.String("cp", ".", "classpath of the application")
+	home := flag.String("home", os.Getenv("JAVA_HOME"), "JDK home holding the runtime classes")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [-cp classpath] [-home dir] class\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*home, *cp, strings.Replace(flag.Arg(0), ".", "/", -1)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run invokes the main method of the class name, args are not passed yet.
+func run(home, cp, name string) (err error) {
+	var path loader.Classpath
+	if home != "" {
+		if path, err = loader.OpenJavaHome(home); err != nil {
+			return
+		}
+	}
+	app, err := loader.ParseClasspath(cp)
+	if err != nil {
+		path.Close()
+		return
+	}
+	path = append(path, app...)
+	defer path.Close()
+
+	c, err := rt.NewLinker(loader.New(path)).Class(name)
+	if err != nil {
+		return
+	}
+	m := c.Method("main", "([Ljava/lang/String;)V")
+	if m == nil || !m.IsStatic() {
+		return fmt.Errorf("no main method in class %s", name)
+	}
+	_, err = interp.NewThread(c.Linker()).Invoke(m, rt.Slot{})
+	if ex, ok := err.(*interp.Exception); ok {
+		b := new(strings.Builder)
+		fmt.Fprintf(b, "Exception in thread \"main\" %v", ex)
+		for _, e := range ex.Trace {
+			fmt.Fprintf(b, "\n\tat %v", e)
+		}
+		return errors.New(b.String())
+	}
+	return
 }
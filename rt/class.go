@@ -0,0 +1,327 @@
+package rt
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/descriptor"
+	"github.com/wucongyou/go-jvm/loader"
+)
+
+// Class class or interface linked from a class file: its superclass and
+// superinterfaces are linked, fields have slots and static variables are
+// prepared to their default values.
+type Class struct {
+	Name        string
+	AccessFlags class.ClassFlags
+	File        *class.ClassFile
+	// Super superclass, nil for java/lang/Object.
+	Super      *Class
+	Interfaces []*Class
+	// Fields and Methods declared by the class.
+	Fields       []*Field
+	Methods      []*Method
+	ConstantPool *ConstantPool
+	// InstanceSlots slots of an instance, including the fields of the
+	// superclasses.
+	InstanceSlots int
+	StaticSlots   int
+	StaticVars    Slots
+	SourceFile    string
+	// Component component class of an array of references, nil otherwise.
+	Component *Class
+
+	linker  *Linker
+	fields  map[string]*Field
+	methods map[string]*Method
+	init    initLock
+}
+
+var (
+	// ErrInitialization error of a class whose initialization failed before.
+	ErrInitialization = errors.New("could not initialize class")
+)
+
+// states of class initialization (JVMS 5.5)
+const (
+	_uninitialized = iota
+	_initializing
+	_initialized
+	_erroneous
+)
+
+// initLock initialization lock of a class, thread is the thread
+// initializing it.
+type initLock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	state  int
+	thread interface{}
+}
+
+func (m *Class) IsPublic() bool {
+	return m.AccessFlags.Has(class.AccPublic)
+}
+
+func (m *Class) IsInterface() bool {
+	return m.AccessFlags.Has(class.AccInterface)
+}
+
+func (m *Class) IsAbstract() bool {
+	return m.AccessFlags.Has(class.AccAbstract)
+}
+
+func (m *Class) IsArray() bool {
+	return strings.HasPrefix(m.Name, "[")
+}
+
+// Linker returns the linker that linked the class.
+func (m *Class) Linker() *Linker {
+	return m.linker
+}
+
+// PackageName returns the package of the class in internal form, e.g.
+// java/lang, empty for the unnamed package.
+func (m *Class) PackageName() string {
+	if i := strings.LastIndexByte(m.Name, '/'); i >= 0 {
+		return m.Name[:i]
+	}
+	return ""
+}
+
+// SamePackage reports whether m and c are in the same run-time package, all
+// classes of a linker have the same defining loader.
+func (m *Class) SamePackage(c *Class) bool {
+	return m.linker == c.linker && m.PackageName() == c.PackageName()
+}
+
+// IsSubclassOf reports whether c is a superclass of m, directly or not.
+func (m *Class) IsSubclassOf(c *Class) bool {
+	for s := m.Super; s != nil; s = s.Super {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Implements reports whether the interface c is a superinterface of m or of
+// one of its superclasses.
+func (m *Class) Implements(c *Class) bool {
+	for s := m; s != nil; s = s.Super {
+		for _, i := range s.Interfaces {
+			if i == c || i.Implements(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Accessible reports whether the class is accessible from c (JVMS 5.4.4),
+// an array class if its element class is.
+func (m *Class) Accessible(c *Class) bool {
+	if m.IsArray() {
+		e := m
+		for e.Component != nil {
+			e = e.Component
+		}
+		// arrays of primitive types are public
+		return e.IsArray() || e.Accessible(c)
+	}
+	return m.IsPublic() || m.SamePackage(c)
+}
+
+// IsAssignableTo reports whether a reference to an instance of m can be
+// assigned to a variable of type c, like checkcast decides it (JVMS 6.5).
+func (m *Class) IsAssignableTo(c *Class) bool {
+	switch {
+	case m == c:
+		return true
+	case !m.IsArray() && c.IsInterface():
+		return m.Implements(c)
+	case !m.IsArray():
+		return !m.IsInterface() && m.IsSubclassOf(c) || m.IsInterface() && c.Name == "java/lang/Object"
+	case !c.IsArray():
+		// arrays are Objects, Cloneable and Serializable
+		return c.Name == "java/lang/Object" || c.Name == "java/lang/Cloneable" || c.Name == "java/io/Serializable"
+	}
+	// arrays of distinct primitive classes aren't assignable
+	return m.Component != nil && c.Component != nil && m.Component.IsAssignableTo(c.Component)
+}
+
+// StartInit starts the initialization of the class by thread (JVMS 5.5),
+// waiting for another thread initializing it. It reports whether thread has
+// to initialize the class and call FinishInit, not if the class is
+// initialized or being initialized by thread itself. It fails with a
+// NoClassDefFoundError if a previous initialization failed.
+func (m *Class) StartInit(thread interface{}) (run bool, err error) {
+	l := &m.init
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.state == _initializing && l.thread != thread {
+		if l.cond == nil {
+			l.cond = sync.NewCond(&l.mu)
+		}
+		l.cond.Wait()
+	}
+	switch l.state {
+	case _uninitialized:
+		l.state, l.thread = _initializing, thread
+		return true, nil
+	case _erroneous:
+		return false, &loader.Error{Kind: loader.NoClassDefFoundError, Class: m.Name, Err: ErrInitialization}
+	}
+	return false, nil
+}
+
+// FinishInit ends the initialization started by StartInit, failed tells
+// whether it completed abruptly.
+func (m *Class) FinishInit(failed bool) {
+	l := &m.init
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if failed {
+		l.state = _erroneous
+	} else {
+		l.state = _initialized
+	}
+	l.thread = nil
+	if l.cond != nil {
+		l.cond.Broadcast()
+	}
+}
+
+// Field returns the field declared by the class, nil if absent.
+func (m *Class) Field(name, desc string) *Field {
+	return m.fields[name+":"+desc]
+}
+
+// Method returns the method declared by the class, nil if absent.
+func (m *Class) Method(name, desc string) *Method {
+	return m.methods[name+":"+desc]
+}
+
+// FindMethod returns the method declared by the class or the nearest
+// superclass declaring it, nil if absent.
+func (m *Class) FindMethod(name, desc string) *Method {
+	for c := m; c != nil; c = c.Super {
+		if f := c.Method(name, desc); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// member common part of fields and methods, flags is the AccessFlags of
+// the field or method.
+type member struct {
+	Class      *Class
+	Name       string
+	Descriptor string
+	flags      interface {
+		Has(fl uint16) bool
+	}
+}
+
+func (m *member) IsPublic() bool {
+	return m.flags.Has(class.AccPublic)
+}
+
+func (m *member) IsPrivate() bool {
+	return m.flags.Has(class.AccPrivate)
+}
+
+func (m *member) IsProtected() bool {
+	return m.flags.Has(class.AccProtected)
+}
+
+func (m *member) IsStatic() bool {
+	return m.flags.Has(class.AccStatic)
+}
+
+func (m *member) IsFinal() bool {
+	return m.flags.Has(class.AccFinal)
+}
+
+// Accessible reports whether the member is accessible from c (JVMS 5.4.4),
+// private members within their class only, protected ones from subclasses
+// too.
+func (m *member) Accessible(c *Class) bool {
+	switch {
+	case m.IsPublic():
+		return true
+	case m.IsPrivate():
+		return c == m.Class
+	case m.Class.SamePackage(c):
+		return true
+	case m.IsProtected():
+		return c == m.Class || c.IsSubclassOf(m.Class)
+	}
+	return false
+}
+
+// Field field of a linked class, Slot indexes the instance fields of an
+// object or the static variables of the class.
+type Field struct {
+	member
+	AccessFlags class.FieldFlags
+	Type        *descriptor.Type
+	Slot        int
+	// ConstantValueIndex constant pool index of the ConstantValue, 0 if none.
+	ConstantValueIndex uint16
+}
+
+// Method method of a linked class.
+type Method struct {
+	member
+	AccessFlags class.MethodFlags
+	Type        *descriptor.Method
+	// ArgSlots local variable slots taken by the arguments, including this
+	// for an instance method.
+	ArgSlots       int
+	MaxStack       int
+	MaxLocals      int
+	Code           []byte
+	ExceptionTable []*class.ExceptionTableEntry
+	LineNumbers    []*class.LineNumber
+}
+
+func (m *Method) IsAbstract() bool {
+	return m.AccessFlags.Has(class.AccAbstract)
+}
+
+func (m *Method) IsNative() bool {
+	return m.AccessFlags.Has(class.AccNative)
+}
+
+func (m *Method) IsSynchronized() bool {
+	return m.AccessFlags.Has(class.AccSynchronized)
+}
+
+// LineNumber returns the source line of the instruction at pc, -1 if
+// unknown.
+func (m *Method) LineNumber(pc int) int {
+	line := -1
+	start := -1
+	for _, l := range m.LineNumbers {
+		if int(l.StartPc) <= pc && int(l.StartPc) > start {
+			start, line = int(l.StartPc), int(l.LineNumber)
+		}
+	}
+	return line
+}
+
+func (m *Method) String() string {
+	return m.Class.Name + "." + m.Name + m.Descriptor
+}
+
+// ArrayClass returns the class of arrays whose components are of class m.
+func (m *Class) ArrayClass() (*Class, error) {
+	if m.IsArray() {
+		return m.linker.Class("[" + m.Name)
+	}
+	return m.linker.Class("[L" + m.Name + ";")
+}
@@ -0,0 +1,233 @@
+package rt
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/loader"
+)
+
+// resolution result of resolving a constant pool entry, a failed resolution
+// fails the same way on every attempt (JVMS 5.4.3).
+type resolution struct {
+	v   interface{}
+	err error
+}
+
+// ConstantPool run-time constant pool of a class (JVMS 5.1), symbolic
+// references are resolved on first use and the result is cached.
+type ConstantPool struct {
+	class    *Class
+	cp       *class.ConstantPool
+	mu       sync.Mutex
+	resolved []*resolution
+}
+
+func newConstantPool(c *Class, cp *class.ConstantPool) *ConstantPool {
+	return &ConstantPool{class: c, cp: cp, resolved: make([]*resolution, cp.Len())}
+}
+
+// Symbols returns the symbolic constant pool of the class file.
+func (m *ConstantPool) Symbols() *class.ConstantPool {
+	return m.cp
+}
+
+// resolve returns the cached resolution of entry i or resolves it with f.
+func (m *ConstantPool) resolve(i uint16, f func() (interface{}, error)) (interface{}, error) {
+	m.mu.Lock()
+	if int(i) < len(m.resolved) && m.resolved[i] != nil {
+		r := m.resolved[i]
+		m.mu.Unlock()
+		return r.v, r.err
+	}
+	m.mu.Unlock()
+	v, err := f()
+	if int(i) < len(m.resolved) {
+		m.mu.Lock()
+		// another thread may have resolved it meanwhile, the first one wins
+		if r := m.resolved[i]; r != nil {
+			v, err = r.v, r.err
+		} else {
+			m.resolved[i] = &resolution{v: v, err: err}
+		}
+		m.mu.Unlock()
+	}
+	return v, err
+}
+
+// Class resolves the CONSTANT_Class_info at index i (JVMS 5.4.3.1).
+func (m *ConstantPool) Class(i uint16) (*Class, error) {
+	v, err := m.resolve(i, func() (interface{}, error) {
+		name, err := m.cp.Class(i)
+		if err != nil {
+			return nil, err
+		}
+		return m.resolveClass(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Class), nil
+}
+
+func (m *ConstantPool) resolveClass(name string) (res *Class, err error) {
+	if res, err = m.class.linker.Class(name); err != nil {
+		return
+	}
+	if !res.Accessible(m.class) {
+		return nil, &loader.Error{Kind: IllegalAccessError, Class: m.class.Name,
+			Err: fmt.Errorf("cannot access class %s", name)}
+	}
+	return
+}
+
+// Field resolves the CONSTANT_Fieldref_info at index i (JVMS 5.4.3.2).
+func (m *ConstantPool) Field(i uint16) (*Field, error) {
+	v, err := m.resolve(i, func() (interface{}, error) {
+		ref, err := m.cp.MemberRef(i)
+		if err != nil {
+			return nil, err
+		}
+		if !ref.IsField() {
+			return nil, fmt.Errorf("rt: constant pool index %d: not a Fieldref", i)
+		}
+		c, err := m.resolveClass(ref.Class)
+		if err != nil {
+			return nil, err
+		}
+		f := lookupField(c, ref.Name, ref.Descriptor)
+		if f == nil {
+			return nil, &loader.Error{Kind: NoSuchFieldError, Class: c.Name, Err: fmt.Errorf("%s:%s", ref.Name, ref.Descriptor)}
+		}
+		if !f.Accessible(m.class) {
+			return nil, &loader.Error{Kind: IllegalAccessError, Class: m.class.Name,
+				Err: fmt.Errorf("cannot access field %s.%s", f.Class.Name, f.Name)}
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Field), nil
+}
+
+// lookupField looks a field up in c, its superinterfaces, then its
+// superclass.
+func lookupField(c *Class, name, desc string) *Field {
+	if f := c.Field(name, desc); f != nil {
+		return f
+	}
+	for _, in := range c.Interfaces {
+		if f := lookupField(in, name, desc); f != nil {
+			return f
+		}
+	}
+	if c.Super != nil {
+		return lookupField(c.Super, name, desc)
+	}
+	return nil
+}
+
+// Method resolves the CONSTANT_Methodref_info at index i (JVMS 5.4.3.3).
+func (m *ConstantPool) Method(i uint16) (*Method, error) {
+	return m.method(i, false)
+}
+
+// InterfaceMethod resolves the CONSTANT_InterfaceMethodref_info at index i
+// (JVMS 5.4.3.4).
+func (m *ConstantPool) InterfaceMethod(i uint16) (*Method, error) {
+	return m.method(i, true)
+}
+
+func (m *ConstantPool) method(i uint16, inter bool) (*Method, error) {
+	v, err := m.resolve(i, func() (interface{}, error) {
+		ref, err := m.cp.MemberRef(i)
+		if err != nil {
+			return nil, err
+		}
+		if ref.IsField() || ref.IsInterface() != inter {
+			return nil, fmt.Errorf("rt: constant pool index %d: unexpected %s", i, m.cp.Infos()[i].TN())
+		}
+		c, err := m.resolveClass(ref.Class)
+		if err != nil {
+			return nil, err
+		}
+		if c.IsInterface() != inter {
+			return nil, &loader.Error{Kind: loader.IncompatibleClassChangeError, Class: c.Name,
+				Err: fmt.Errorf("method reference to %s through %s", c.Name, ref.Name)}
+		}
+		f := c.FindMethod(ref.Name, ref.Descriptor)
+		if inter && f != nil && f.Class != c && (!f.IsPublic() || f.IsStatic()) {
+			// only public instance methods of java/lang/Object are members
+			// of an interface
+			f = nil
+		}
+		if f == nil {
+			f = lookupInterfaceMethod(c, ref.Name, ref.Descriptor)
+		}
+		if f == nil {
+			return nil, &loader.Error{Kind: NoSuchMethodError, Class: c.Name, Err: fmt.Errorf("%s%s", ref.Name, ref.Descriptor)}
+		}
+		if !f.Accessible(m.class) {
+			return nil, &loader.Error{Kind: IllegalAccessError, Class: m.class.Name,
+				Err: fmt.Errorf("cannot access method %s", f)}
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Method), nil
+}
+
+// lookupInterfaceMethod looks a method up in the superinterfaces of c and
+// of its superclasses, a non-abstract one wins over abstract ones. Private
+// and static interface methods are not inherited.
+func lookupInterfaceMethod(c *Class, name, desc string) (res *Method) {
+	seen := make(map[*Class]bool)
+	var walk func(c *Class)
+	walk = func(c *Class) {
+		for _, in := range c.Interfaces {
+			if seen[in] {
+				continue
+			}
+			seen[in] = true
+			if f := in.Method(name, desc); f != nil && !f.IsPrivate() && !f.IsStatic() {
+				if res == nil || res.IsAbstract() && !f.IsAbstract() {
+					res = f
+				}
+			}
+			walk(in)
+		}
+	}
+	for s := c; s != nil; s = s.Super {
+		walk(s)
+	}
+	return
+}
+
+// Constant returns the loadable constant at index i like ldc pushes it: an
+// int32, float32, int64, float64, a string for a String or the *Class of a
+// Class.
+func (m *ConstantPool) Constant(i uint16) (res interface{}, err error) {
+	var c class.ConstantInfo
+	if c, err = m.cp.Entry(i); err != nil {
+		return
+	}
+	switch u := c.(type) {
+	case *class.IntegerInfo:
+		return u.Int32(), nil
+	case *class.FloatInfo:
+		return u.Float32(), nil
+	case *class.LongInfo:
+		return u.Int64(), nil
+	case *class.DoubleInfo:
+		return u.Float64(), nil
+	case *class.StringInfo:
+		return m.cp.String(i)
+	case *class.ClassInfo:
+		return m.Class(i)
+	}
+	return nil, fmt.Errorf("rt: constant pool index %d: unsupported constant %s", i, c.TN())
+}
@@ -0,0 +1,188 @@
+package rt
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/descriptor"
+	"github.com/wucongyou/go-jvm/loader"
+)
+
+// Java errors of linking and resolution, besides the ones of loader. Linking
+// and resolution fail with a *loader.Error of such a kind.
+const (
+	NoSuchFieldError   = "java/lang/NoSuchFieldError"
+	NoSuchMethodError  = "java/lang/NoSuchMethodError"
+	IllegalAccessError = "java/lang/IllegalAccessError"
+)
+
+// Linker links the classes of a loader (JVMS 5.4) and caches them by name.
+// Bytecode is not verified. A Linker is safe for concurrent use.
+type Linker struct {
+	loader  *loader.Loader
+	mu      sync.Mutex
+	classes map[string]*Class
+}
+
+// NewLinker returns a linker of the classes loaded by l.
+func NewLinker(l *loader.Loader) *Linker {
+	return &Linker{loader: l, classes: make(map[string]*Class)}
+}
+
+// Loader returns the loader of the linker.
+func (m *Linker) Loader() *loader.Loader {
+	return m.loader
+}
+
+// Class returns the linked class of the binary name in internal form, e.g.
+// java/lang/String, loading it and its superclasses and superinterfaces if
+// necessary.
+func (m *Linker) Class(name string) (res *Class, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.class(name)
+}
+
+func (m *Linker) class(name string) (res *Class, err error) {
+	if res = m.classes[name]; res != nil {
+		return
+	}
+	if len(name) > 0 && name[0] == '[' {
+		return m.arrayClass(name)
+	}
+	var lc *loader.Class
+	if lc, err = m.loader.Load(name); err != nil {
+		return
+	}
+	return m.link(lc)
+}
+
+// arrayClass creates the array class of the descriptor name (JVMS 5.3.3),
+// a subclass of java/lang/Object implementing Cloneable and Serializable
+// with nothing to initialize, m.mu is held.
+func (m *Linker) arrayClass(name string) (res *Class, err error) {
+	var t *descriptor.Type
+	if t, err = descriptor.ParseField(name); err != nil {
+		return nil, &loader.Error{Kind: loader.NoClassDefFoundError, Class: name, Err: err}
+	}
+	res = &Class{Name: name, AccessFlags: class.AccPublic | class.AccFinal | class.AccAbstract, linker: m}
+	if e := t.Elem(); e.IsReference() {
+		c := e.ClassName
+		if e.IsArray() {
+			c = e.String()
+		}
+		if res.Component, err = m.class(c); err != nil {
+			return nil, err
+		}
+	}
+	if res.Super, err = m.class("java/lang/Object"); err != nil {
+		return nil, err
+	}
+	for _, in := range []string{"java/lang/Cloneable", "java/io/Serializable"} {
+		var c *Class
+		if c, err = m.class(in); err != nil {
+			return nil, err
+		}
+		res.Interfaces = append(res.Interfaces, c)
+	}
+	res.init.state = _initialized
+	m.classes[name] = res
+	return
+}
+
+// link links lc after its superclass and superinterfaces, the loader
+// already rejected circular ones, m.mu is held.
+func (m *Linker) link(lc *loader.Class) (res *Class, err error) {
+	cf := lc.File
+	res = &Class{
+		Name:        lc.Name,
+		AccessFlags: class.ClassFlags(cf.AccessFlags),
+		File:        cf,
+		linker:      m,
+		fields:      make(map[string]*Field),
+		methods:     make(map[string]*Method),
+	}
+	if lc.Super != nil {
+		if res.Super, err = m.class(lc.Super.Name); err != nil {
+			return nil, err
+		}
+		res.InstanceSlots = res.Super.InstanceSlots
+	}
+	res.Interfaces = make([]*Class, len(lc.Interfaces))
+	for i, in := range lc.Interfaces {
+		if res.Interfaces[i], err = m.class(in.Name); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := cf.Attribute("SourceFile").(*class.SourceFileAttribute); ok {
+		res.SourceFile, _ = s.ParseSourceFileFromPool(cf.CpInfo)
+	}
+	cp := cf.ConstantPool()
+	res.ConstantPool = newConstantPool(res, cp)
+	fail := func(err error) (*Class, error) {
+		return nil, &loader.Error{Kind: loader.ClassFormatError, Class: res.Name, Err: err}
+	}
+
+	for _, fi := range cf.Fields {
+		f := &Field{AccessFlags: class.FieldFlags(fi.AccessFlags)}
+		f.member = member{Class: res, flags: f.AccessFlags}
+		if f.Name, err = cp.Utf8(fi.NameIndex); err != nil {
+			return fail(err)
+		}
+		if f.Descriptor, err = cp.Utf8(fi.DescriptorIndex); err != nil {
+			return fail(err)
+		}
+		if f.Type, err = descriptor.ParseField(f.Descriptor); err != nil {
+			return fail(err)
+		}
+		if v, ok := fi.Attribute("ConstantValue").(*class.ConstantValueAttribute); ok {
+			f.ConstantValueIndex = v.ConstantValueIndex
+		}
+		// long and double take two slots
+		if f.IsStatic() {
+			f.Slot = res.StaticSlots
+			res.StaticSlots += f.Type.Slots()
+		} else {
+			f.Slot = res.InstanceSlots
+			res.InstanceSlots += f.Type.Slots()
+		}
+		res.Fields = append(res.Fields, f)
+		res.fields[f.Name+":"+f.Descriptor] = f
+	}
+
+	for _, mi := range cf.Methods {
+		f := &Method{AccessFlags: class.MethodFlags(mi.AccessFlags)}
+		f.member = member{Class: res, flags: f.AccessFlags}
+		if f.Name, err = cp.Utf8(mi.NameIndex); err != nil {
+			return fail(err)
+		}
+		if f.Descriptor, err = cp.Utf8(mi.DescriptorIndex); err != nil {
+			return fail(err)
+		}
+		if f.Type, err = descriptor.ParseMethod(f.Descriptor); err != nil {
+			return fail(err)
+		}
+		f.ArgSlots = f.Type.ArgSlots()
+		if !f.IsStatic() {
+			f.ArgSlots++
+		}
+		if c := mi.Code; c != nil {
+			f.MaxStack, f.MaxLocals = int(c.MaxStack), int(c.MaxLocals)
+			f.Code, f.ExceptionTable = c.Code, c.ExceptionTable
+			if l, ok := c.Attribute("LineNumberTable").(*class.LineNumberTableAttribute); ok {
+				f.LineNumbers = l.LineNumberTable
+			}
+		} else if !f.IsAbstract() && !f.IsNative() {
+			return fail(fmt.Errorf("method %s%s has no Code", f.Name, f.Descriptor))
+		}
+		res.Methods = append(res.Methods, f)
+		res.methods[f.Name+":"+f.Descriptor] = f
+	}
+
+	// preparation: static variables get their default values, zero for
+	// every type
+	res.StaticVars = make(Slots, res.StaticSlots)
+	m.classes[res.Name] = res
+	return
+}
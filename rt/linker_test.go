@@ -0,0 +1,149 @@
+package rt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wucongyou/go-jvm/class"
+	"github.com/wucongyou/go-jvm/internal/classtest"
+	"github.com/wucongyou/go-jvm/loader"
+)
+
+func TestLink(t *testing.T) {
+	api := classtest.New("p/Api", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract)
+	api.Field(class.AccPublic|class.AccStatic|class.AccFinal, "ID", "I", api.Integer(7))
+	api.Method(class.AccPublic|class.AccAbstract, "run", "()V", 0, 0, nil)
+	api.Method(class.AccPublic, "name", "()Ljava/lang/String;", 1, 1, []byte{0x01, 0xb0})
+
+	base := classtest.New("p/Base", "java/lang/Object", class.AccPublic|class.AccSuper)
+	base.Field(class.AccProtected, "a", "I", 0)
+	base.Field(class.AccPrivate, "b", "J", 0)
+	base.Method(class.AccPublic, "<init>", "()V", 1, 1, []byte{0x2a, 0xb7, 0, byte(base.MethodRef("java/lang/Object", "<init>", "()V")), 0xb1})
+
+	main := classtest.New("p/Main", "p/Base", class.AccPublic|class.AccSuper, "p/Api")
+	main.Field(class.AccStatic, "s", "D", 0)
+	main.Field(class.AccStatic, "t", "Ljava/lang/String;", 0)
+	main.Field(0, "c", "Z", 0)
+	main.Method(class.AccPublic|class.AccStatic, "f", "(IJLjava/lang/String;D)V", 0, 6, []byte{0xb1})
+	main.Method(class.AccPublic, "run", "()V", 0, 1, []byte{0xb1})
+	refs := []uint16{
+		main.FieldRef("p/Main", "a", "I"),
+		main.FieldRef("p/Main", "ID", "I"),
+		main.MethodRef("p/Main", "name", "()Ljava/lang/String;"),
+		main.InterfaceMethodRef("p/Api", "hashCode", "()I"),
+		main.FieldRef("p/Main", "b", "J"),
+		main.FieldRef("p/Main", "missing", "I"),
+		main.MethodRef("p/Api", "run", "()V"),
+		main.String("hi"),
+		main.Long(-1),
+	}
+	object := classtest.Object()
+	object.Method(class.AccPublic|class.AccNative, "hashCode", "()I", 0, 0, nil)
+
+	l := NewLinker(loader.New(loader.Classpath{loader.MemoryEntry(classtest.Classes(object, api, base, main))}))
+	c, err := l.Class("p/Main")
+	if err != nil {
+		t.Errorf("failed to link, error(%v)", err)
+		t.FailNow()
+	}
+	if c.Super.Name != "p/Base" || c.Super.Super.Name != "java/lang/Object" || len(c.Interfaces) != 1 || !c.Implements(c.Interfaces[0]) {
+		t.Errorf("unexpected class %+v", c)
+	}
+	// a int, b long, c boolean after the fields of Base
+	if c.InstanceSlots != 4 || c.Field("c", "Z").Slot != 3 || c.Super.Field("b", "J").Slot != 1 {
+		t.Errorf("unexpected instance layout, slots(%d)", c.InstanceSlots)
+	}
+	if c.StaticSlots != 3 || len(c.StaticVars) != 3 || c.Field("t", "Ljava/lang/String;").Slot != 2 {
+		t.Errorf("unexpected static layout, slots(%d)", c.StaticSlots)
+	}
+	if c.StaticVars.Double(0) != 0 || c.StaticVars.Ref(2) != nil {
+		t.Errorf("expected default static values")
+	}
+	if f := c.Method("f", "(IJLjava/lang/String;D)V"); f == nil || f.ArgSlots != 6 || f.MaxLocals != 6 {
+		t.Errorf("unexpected method %+v", f)
+	}
+	if f := c.Method("run", "()V"); f == nil || f.ArgSlots != 1 {
+		t.Errorf("unexpected method %+v", f)
+	}
+	if c2, _ := l.Class("p/Main"); c2 != c {
+		t.Errorf("expected cached class")
+	}
+
+	cp := c.ConstantPool
+	if f, err := cp.Field(refs[0]); err != nil || f.Class != c.Super {
+		t.Errorf("failed to resolve inherited field, error(%v)", err)
+	}
+	if f, err := cp.Field(refs[1]); err != nil || f.Class.Name != "p/Api" || f.ConstantValueIndex == 0 {
+		t.Errorf("failed to resolve interface field, error(%v)", err)
+	}
+	if f, err := cp.Method(refs[2]); err != nil || f.Class.Name != "p/Api" {
+		t.Errorf("failed to resolve default method, error(%v)", err)
+	}
+	if f, err := cp.InterfaceMethod(refs[3]); err != nil || f.Class.Name != "java/lang/Object" {
+		t.Errorf("failed to resolve Object method, error(%v)", err)
+	}
+	f1, _ := cp.Field(refs[0])
+	if f2, _ := cp.Field(refs[0]); f1 != f2 {
+		t.Errorf("expected cached resolution")
+	}
+	for _, e := range []struct {
+		f    func() error
+		kind string
+	}{
+		{func() error { _, err := cp.Field(refs[4]); return err }, IllegalAccessError},
+		{func() error { _, err := cp.Field(refs[5]); return err }, NoSuchFieldError},
+		{func() error { _, err := cp.Method(refs[6]); return err }, loader.IncompatibleClassChangeError},
+	} {
+		err := e.f()
+		var re *loader.Error
+		if !errors.As(err, &re) || re.Kind != e.kind {
+			t.Errorf("expected %s, got %v", e.kind, err)
+		}
+	}
+	if v, err := cp.Constant(refs[7]); err != nil || v != "hi" {
+		t.Errorf("unexpected constant %v, error(%v)", v, err)
+	}
+	if v, err := cp.Constant(refs[8]); err != nil || v != int64(-1) {
+		t.Errorf("unexpected constant %v, error(%v)", v, err)
+	}
+}
+
+func TestArrayClass(t *testing.T) {
+	e := loader.MemoryEntry(classtest.Classes(classtest.Object(),
+		classtest.New("java/lang/Cloneable", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract),
+		classtest.New("java/io/Serializable", "java/lang/Object", class.AccPublic|class.AccInterface|class.AccAbstract),
+		classtest.New("p/A", "java/lang/Object", class.AccPublic|class.AccSuper),
+	))
+	l := NewLinker(loader.New(loader.Classpath{e}))
+	as, err := l.Class("[[Lp/A;")
+	if err != nil {
+		t.Errorf("failed to link, error(%v)", err)
+		t.FailNow()
+	}
+	if !as.IsArray() || as.Component.Name != "[Lp/A;" || as.Component.Component.Name != "p/A" || as.Super.Name != "java/lang/Object" {
+		t.Errorf("unexpected array class %+v", as)
+	}
+	if c, _ := as.Component.ArrayClass(); c != as {
+		t.Errorf("expected the array class of the component, got %v", c)
+	}
+	ints, _ := l.Class("[I")
+	objects, _ := l.Class("[Ljava/lang/Object;")
+	for _, e := range []struct {
+		from, to *Class
+		ok       bool
+	}{
+		{as, objects, true},
+		{as, as.Interfaces[0], true},
+		{as.Component, objects, true},
+		{ints, objects, false},
+		{ints, objects.Component, true},
+		{objects, as, false},
+	} {
+		if e.from.IsAssignableTo(e.to) != e.ok {
+			t.Errorf("%s assignable to %s: expected %v", e.from.Name, e.to.Name, e.ok)
+		}
+	}
+	if a := NewArray(ints, 3); a.ArrayLength() != 3 || len(a.Array.([]int32)) != 3 {
+		t.Errorf("unexpected array %+v", a)
+	}
+}
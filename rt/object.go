@@ -0,0 +1,72 @@
+package rt
+
+import (
+	"fmt"
+)
+
+// Object instance of a class or array on the heap. The fields of an
+// instance are laid out by the instance slots of its class and superclasses,
+// the elements of an array are held by Array.
+type Object struct {
+	Class  *Class
+	Fields Slots
+	// Array elements of an array: []int8 for byte and boolean, []uint16,
+	// []int16, []int32, []int64, []float32, []float64 for the other
+	// primitive types, []*Object for references.
+	Array interface{}
+}
+
+// NewObject returns an instance of c with its fields set to their default
+// values.
+func NewObject(c *Class) *Object {
+	return &Object{Class: c, Fields: make(Slots, c.InstanceSlots)}
+}
+
+// NewArray returns an array of the array class c with n elements set to
+// their default values, n must not be negative.
+func NewArray(c *Class, n int) *Object {
+	var a interface{}
+	switch c.Name[1] {
+	case 'Z', 'B':
+		a = make([]int8, n)
+	case 'C':
+		a = make([]uint16, n)
+	case 'S':
+		a = make([]int16, n)
+	case 'I':
+		a = make([]int32, n)
+	case 'J':
+		a = make([]int64, n)
+	case 'F':
+		a = make([]float32, n)
+	case 'D':
+		a = make([]float64, n)
+	default:
+		a = make([]*Object, n)
+	}
+	return &Object{Class: c, Array: a}
+}
+
+// ArrayLength returns the number of elements of an array, it panics if m
+// isn't one.
+func (m *Object) ArrayLength() int {
+	switch a := m.Array.(type) {
+	case []int8:
+		return len(a)
+	case []uint16:
+		return len(a)
+	case []int16:
+		return len(a)
+	case []int32:
+		return len(a)
+	case []int64:
+		return len(a)
+	case []float32:
+		return len(a)
+	case []float64:
+		return len(a)
+	case []*Object:
+		return len(a)
+	}
+	panic(fmt.Sprintf("rt: %s is not an array", m.Class.Name))
+}
@@ -0,0 +1,59 @@
+// Package rt holds the run-time data of the JVM: classes linked from loaded
+// class files, their run-time constant pools and the slots values are kept
+// in (JVMS 2.5, 5.4).
+package rt
+
+import (
+	"math"
+)
+
+// Slot local variable, operand stack or variable slot (JVMS 2.6.1). A long
+// or double takes two slots, the first holds the value. Num holds an int
+// sign-extended, a float or double as its bits.
+type Slot struct {
+	Num int64
+	Ref *Object
+}
+
+// Slots consecutive slots, e.g. the static variables of a class.
+type Slots []Slot
+
+func (m Slots) SetInt(i int, v int32) {
+	m[i] = Slot{Num: int64(v)}
+}
+
+func (m Slots) Int(i int) int32 {
+	return int32(m[i].Num)
+}
+
+func (m Slots) SetFloat(i int, v float32) {
+	m[i] = Slot{Num: int64(math.Float32bits(v))}
+}
+
+func (m Slots) Float(i int) float32 {
+	return math.Float32frombits(uint32(m[i].Num))
+}
+
+func (m Slots) SetLong(i int, v int64) {
+	m[i], m[i+1] = Slot{Num: v}, Slot{}
+}
+
+func (m Slots) Long(i int) int64 {
+	return m[i].Num
+}
+
+func (m Slots) SetDouble(i int, v float64) {
+	m.SetLong(i, int64(math.Float64bits(v)))
+}
+
+func (m Slots) Double(i int) float64 {
+	return math.Float64frombits(uint64(m[i].Num))
+}
+
+func (m Slots) SetRef(i int, v *Object) {
+	m[i] = Slot{Ref: v}
+}
+
+func (m Slots) Ref(i int) *Object {
+	return m[i].Ref
+}
@@ -0,0 +1,606 @@
+// Package signature parses generic class, method and field signatures
+// (JVMS 4.7.9.1).
+package signature
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wucongyou/go-jvm/descriptor"
+)
+
+// Error signature syntax error.
+type Error struct {
+	Signature string
+	Offset    int
+	Msg       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("signature: invalid %q at offset %d: %s", e.Signature, e.Offset, e.Msg)
+}
+
+// JavaType JavaTypeSignature: a base type, a class type, a type variable or an
+// array type.
+type JavaType interface {
+	// String returns the signature.
+	String() string
+	// Java returns the type in Java source syntax.
+	Java() string
+	write(b *strings.Builder)
+}
+
+// BaseType BaseType, or void as a method result.
+type BaseType struct {
+	Kind descriptor.Kind
+}
+
+func (t *BaseType) String() string {
+	return string(t.Kind)
+}
+
+func (t *BaseType) Java() string {
+	return t.Kind.String()
+}
+
+func (t *BaseType) write(b *strings.Builder) {
+	b.WriteByte(byte(t.Kind))
+}
+
+// ClassType ClassTypeSignature, e.g. Ljava/util/Map<TK;TV;>.Entry<TK;TV;>;
+type ClassType struct {
+	// Package package specifier in internal form with the trailing slash,
+	// e.g. java/util/, empty for the unnamed package.
+	Package string
+	// Classes the outermost class followed by the inner classes.
+	Classes []*SimpleClassType
+}
+
+// Name returns the binary class name in internal form, e.g. java/util/Map$Entry.
+func (t *ClassType) Name() string {
+	ns := make([]string, len(t.Classes))
+	for i, c := range t.Classes {
+		ns[i] = c.Name
+	}
+	return t.Package + strings.Join(ns, "$")
+}
+
+func (t *ClassType) String() string {
+	b := new(strings.Builder)
+	t.write(b)
+	return b.String()
+}
+
+func (t *ClassType) Java() string {
+	b := new(strings.Builder)
+	b.WriteString(strings.Replace(t.Package, "/", ".", -1))
+	for i, c := range t.Classes {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(c.Name)
+		if len(c.TypeArguments) > 0 {
+			as := make([]string, len(c.TypeArguments))
+			for j, a := range c.TypeArguments {
+				as[j] = a.Java()
+			}
+			b.WriteString("<" + strings.Join(as, ", ") + ">")
+		}
+	}
+	return b.String()
+}
+
+func (t *ClassType) write(b *strings.Builder) {
+	b.WriteByte('L')
+	b.WriteString(t.Package)
+	for i, c := range t.Classes {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(c.Name)
+		if len(c.TypeArguments) > 0 {
+			b.WriteByte('<')
+			for _, a := range c.TypeArguments {
+				a.write(b)
+			}
+			b.WriteByte('>')
+		}
+	}
+	b.WriteByte(';')
+}
+
+// SimpleClassType SimpleClassTypeSignature.
+type SimpleClassType struct {
+	Name          string
+	TypeArguments []*TypeArgument
+}
+
+// Wildcard wildcard indicator of a type argument.
+type Wildcard byte
+
+const (
+	// None an exact type argument.
+	None Wildcard = 0
+	// Any unbounded wildcard: ?.
+	Any Wildcard = '*'
+	// Extends upper bounded wildcard: ? extends T.
+	Extends Wildcard = '+'
+	// Super lower bounded wildcard: ? super T.
+	Super Wildcard = '-'
+)
+
+// TypeArgument TypeArgument, Type is nil for Any.
+type TypeArgument struct {
+	Wildcard Wildcard
+	Type     JavaType
+}
+
+func (a *TypeArgument) Java() string {
+	switch a.Wildcard {
+	case Any:
+		return "?"
+	case Extends:
+		return "? extends " + a.Type.Java()
+	case Super:
+		return "? super " + a.Type.Java()
+	}
+	return a.Type.Java()
+}
+
+func (a *TypeArgument) write(b *strings.Builder) {
+	if a.Wildcard != None {
+		b.WriteByte(byte(a.Wildcard))
+	}
+	if a.Type != nil {
+		a.Type.write(b)
+	}
+}
+
+// TypeVariable TypeVariableSignature, e.g. TT;
+type TypeVariable struct {
+	Name string
+}
+
+func (t *TypeVariable) String() string {
+	return "T" + t.Name + ";"
+}
+
+func (t *TypeVariable) Java() string {
+	return t.Name
+}
+
+func (t *TypeVariable) write(b *strings.Builder) {
+	b.WriteString(t.String())
+}
+
+// ArrayType ArrayTypeSignature.
+type ArrayType struct {
+	Elem JavaType
+}
+
+func (t *ArrayType) String() string {
+	return "[" + t.Elem.String()
+}
+
+func (t *ArrayType) Java() string {
+	return t.Elem.Java() + "[]"
+}
+
+func (t *ArrayType) write(b *strings.Builder) {
+	b.WriteByte('[')
+	t.Elem.write(b)
+}
+
+// TypeParameter TypeParameter, ClassBound is nil if the class bound is empty.
+type TypeParameter struct {
+	Name            string
+	ClassBound      JavaType
+	InterfaceBounds []JavaType
+}
+
+func (p *TypeParameter) Java() string {
+	bs := make([]string, 0, len(p.InterfaceBounds)+1)
+	if p.ClassBound != nil {
+		bs = append(bs, p.ClassBound.Java())
+	}
+	for _, t := range p.InterfaceBounds {
+		bs = append(bs, t.Java())
+	}
+	if len(bs) == 0 {
+		return p.Name
+	}
+	return p.Name + " extends " + strings.Join(bs, " & ")
+}
+
+func (p *TypeParameter) write(b *strings.Builder) {
+	b.WriteString(p.Name)
+	b.WriteByte(':')
+	if p.ClassBound != nil {
+		p.ClassBound.write(b)
+	}
+	for _, t := range p.InterfaceBounds {
+		b.WriteByte(':')
+		t.write(b)
+	}
+}
+
+func writeTypeParameters(b *strings.Builder, ps []*TypeParameter) {
+	if len(ps) == 0 {
+		return
+	}
+	b.WriteByte('<')
+	for _, p := range ps {
+		p.write(b)
+	}
+	b.WriteByte('>')
+}
+
+func javaTypeParameters(ps []*TypeParameter) string {
+	if len(ps) == 0 {
+		return ""
+	}
+	ns := make([]string, len(ps))
+	for i, p := range ps {
+		ns[i] = p.Java()
+	}
+	return "<" + strings.Join(ns, ", ") + ">"
+}
+
+// Class ClassSignature.
+type Class struct {
+	TypeParameters []*TypeParameter
+	Super          *ClassType
+	Interfaces     []*ClassType
+}
+
+func (c *Class) String() string {
+	b := new(strings.Builder)
+	writeTypeParameters(b, c.TypeParameters)
+	c.Super.write(b)
+	for _, t := range c.Interfaces {
+		t.write(b)
+	}
+	return b.String()
+}
+
+// Java returns the signature in Java source syntax following the class name,
+// e.g. <T> extends java.lang.Object implements java.lang.Comparable<T>.
+func (c *Class) Java() string {
+	b := new(strings.Builder)
+	b.WriteString(javaTypeParameters(c.TypeParameters))
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString("extends " + c.Super.Java())
+	if len(c.Interfaces) > 0 {
+		ns := make([]string, len(c.Interfaces))
+		for i, t := range c.Interfaces {
+			ns[i] = t.Java()
+		}
+		b.WriteString(" implements " + strings.Join(ns, ", "))
+	}
+	return b.String()
+}
+
+// Method MethodSignature, Return is a *BaseType of descriptor.Void for void.
+type Method struct {
+	TypeParameters []*TypeParameter
+	Params         []JavaType
+	Return         JavaType
+	Throws         []JavaType
+}
+
+func (m *Method) String() string {
+	b := new(strings.Builder)
+	writeTypeParameters(b, m.TypeParameters)
+	b.WriteByte('(')
+	for _, p := range m.Params {
+		p.write(b)
+	}
+	b.WriteByte(')')
+	m.Return.write(b)
+	for _, t := range m.Throws {
+		b.WriteByte('^')
+		t.write(b)
+	}
+	return b.String()
+}
+
+// Java returns the signature in Java source syntax with the given method
+// name, e.g. <T> T max(java.util.List<? extends T>) throws E.
+func (m *Method) Java(name string) string {
+	b := new(strings.Builder)
+	if tp := javaTypeParameters(m.TypeParameters); tp != "" {
+		b.WriteString(tp + " ")
+	}
+	ps := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		ps[i] = p.Java()
+	}
+	b.WriteString(fmt.Sprintf("%s %s(%s)", m.Return.Java(), name, strings.Join(ps, ", ")))
+	if len(m.Throws) > 0 {
+		ts := make([]string, len(m.Throws))
+		for i, t := range m.Throws {
+			ts[i] = t.Java()
+		}
+		b.WriteString(" throws " + strings.Join(ts, ", "))
+	}
+	return b.String()
+}
+
+// ParseClass parses a class signature.
+func ParseClass(s string) (res *Class, err error) {
+	p := &parser{s: s}
+	res = new(Class)
+	if res.TypeParameters, err = p.typeParameters(); err != nil {
+		return nil, err
+	}
+	if res.Super, err = p.classType(); err != nil {
+		return nil, err
+	}
+	res.Interfaces = make([]*ClassType, 0)
+	for !p.eof() {
+		var t *ClassType
+		if t, err = p.classType(); err != nil {
+			return nil, err
+		}
+		res.Interfaces = append(res.Interfaces, t)
+	}
+	return
+}
+
+// ParseMethod parses a method signature.
+func ParseMethod(s string) (res *Method, err error) {
+	p := &parser{s: s}
+	res = new(Method)
+	if res.TypeParameters, err = p.typeParameters(); err != nil {
+		return nil, err
+	}
+	if err = p.expect('('); err != nil {
+		return nil, err
+	}
+	res.Params = make([]JavaType, 0)
+	for !p.eof() && p.peek() != ')' {
+		var t JavaType
+		if t, err = p.javaType(); err != nil {
+			return nil, err
+		}
+		res.Params = append(res.Params, t)
+	}
+	if err = p.expect(')'); err != nil {
+		return nil, err
+	}
+	if !p.eof() && p.peek() == byte(descriptor.Void) {
+		p.off++
+		res.Return = &BaseType{Kind: descriptor.Void}
+	} else if res.Return, err = p.javaType(); err != nil {
+		return nil, err
+	}
+	res.Throws = make([]JavaType, 0)
+	for !p.eof() {
+		if err = p.expect('^'); err != nil {
+			return nil, err
+		}
+		var t JavaType
+		switch {
+		case p.eof():
+			return nil, p.error("unexpected end of signature")
+		case p.peek() == 'L':
+			t, err = p.classType()
+		case p.peek() == 'T':
+			t, err = p.typeVariable()
+		default:
+			return nil, p.error("expected class type or type variable")
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Throws = append(res.Throws, t)
+	}
+	return
+}
+
+// ParseField parses a field signature, which is a reference type signature.
+func ParseField(s string) (res JavaType, err error) {
+	p := &parser{s: s}
+	if res, err = p.referenceType(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.error("unexpected trailing characters")
+	}
+	return
+}
+
+type parser struct {
+	s   string
+	off int
+}
+
+func (p *parser) error(msg string) error {
+	return &Error{Signature: p.s, Offset: p.off, Msg: msg}
+}
+
+func (p *parser) eof() bool {
+	return p.off >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	return p.s[p.off]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.eof() || p.peek() != c {
+		return p.error(fmt.Sprintf("expected %q", c))
+	}
+	p.off++
+	return nil
+}
+
+// identifier reads an identifier up to one of the characters . ; [ / < > :
+func (p *parser) identifier() (string, error) {
+	s := p.off
+	for !p.eof() && strings.IndexByte(".;[/<>:", p.peek()) < 0 {
+		p.off++
+	}
+	if s == p.off {
+		return "", p.error("expected identifier")
+	}
+	return p.s[s:p.off], nil
+}
+
+func (p *parser) typeParameters() (res []*TypeParameter, err error) {
+	res = make([]*TypeParameter, 0)
+	if p.eof() || p.peek() != '<' {
+		return
+	}
+	p.off++
+	for !p.eof() && p.peek() != '>' {
+		tp := &TypeParameter{InterfaceBounds: make([]JavaType, 0)}
+		if tp.Name, err = p.identifier(); err != nil {
+			return
+		}
+		if err = p.expect(':'); err != nil {
+			return
+		}
+		if !p.eof() && p.peek() != ':' && p.peek() != '>' {
+			if tp.ClassBound, err = p.referenceType(); err != nil {
+				return
+			}
+		}
+		for !p.eof() && p.peek() == ':' {
+			p.off++
+			var t JavaType
+			if t, err = p.referenceType(); err != nil {
+				return
+			}
+			tp.InterfaceBounds = append(tp.InterfaceBounds, t)
+		}
+		res = append(res, tp)
+	}
+	if err = p.expect('>'); err != nil {
+		return
+	}
+	if len(res) == 0 {
+		err = p.error("empty type parameters")
+	}
+	return
+}
+
+func (p *parser) javaType() (JavaType, error) {
+	if p.eof() {
+		return nil, p.error("unexpected end of signature")
+	}
+	switch k := descriptor.Kind(p.peek()); k {
+	case descriptor.Byte, descriptor.Char, descriptor.Double, descriptor.Float,
+		descriptor.Int, descriptor.Long, descriptor.Short, descriptor.Boolean:
+		p.off++
+		return &BaseType{Kind: k}, nil
+	}
+	return p.referenceType()
+}
+
+func (p *parser) referenceType() (JavaType, error) {
+	if p.eof() {
+		return nil, p.error("unexpected end of signature")
+	}
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		return p.typeVariable()
+	case '[':
+		p.off++
+		t, err := p.javaType()
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayType{Elem: t}, nil
+	}
+	return nil, p.error(fmt.Sprintf("unexpected character %q", p.peek()))
+}
+
+func (p *parser) typeVariable() (res *TypeVariable, err error) {
+	if err = p.expect('T'); err != nil {
+		return
+	}
+	res = new(TypeVariable)
+	if res.Name, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(';'); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (p *parser) classType() (res *ClassType, err error) {
+	if err = p.expect('L'); err != nil {
+		return
+	}
+	res = &ClassType{Classes: make([]*SimpleClassType, 0)}
+	start := p.off
+	var id string
+	// the package specifier ends with the last slash before the first
+	// simple class type signature
+	for {
+		if id, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if p.eof() || p.peek() != '/' {
+			break
+		}
+		p.off++
+	}
+	res.Package = p.s[start : p.off-len(id)]
+	for {
+		c := &SimpleClassType{Name: id, TypeArguments: make([]*TypeArgument, 0)}
+		if c.TypeArguments, err = p.typeArguments(); err != nil {
+			return nil, err
+		}
+		res.Classes = append(res.Classes, c)
+		if p.eof() || p.peek() != '.' {
+			break
+		}
+		p.off++
+		if id, err = p.identifier(); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.expect(';'); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (p *parser) typeArguments() (res []*TypeArgument, err error) {
+	res = make([]*TypeArgument, 0)
+	if p.eof() || p.peek() != '<' {
+		return
+	}
+	p.off++
+	for !p.eof() && p.peek() != '>' {
+		a := new(TypeArgument)
+		switch w := Wildcard(p.peek()); w {
+		case Any:
+			p.off++
+			a.Wildcard = w
+			res = append(res, a)
+			continue
+		case Extends, Super:
+			p.off++
+			a.Wildcard = w
+		}
+		if a.Type, err = p.referenceType(); err != nil {
+			return
+		}
+		res = append(res, a)
+	}
+	if err = p.expect('>'); err != nil {
+		return
+	}
+	if len(res) == 0 {
+		err = p.error("empty type arguments")
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package signature
+
+import (
+	"testing"
+)
+
+func TestParseClass(t *testing.T) {
+	s := "<K:Ljava/lang/Object;V::Ljava/lang/Comparable<-TV;>;>Ljava/util/AbstractMap<TK;TV;>;Ljava/io/Serializable;"
+	c, err := ParseClass(s)
+	if err != nil {
+		t.Errorf("failed to parse, error(%v)", err)
+		t.FailNow()
+	}
+	if c.String() != s {
+		t.Errorf("unexpected signature %s", c.String())
+	}
+	exp := "<K extends java.lang.Object, V extends java.lang.Comparable<? super V>> extends java.util.AbstractMap<K, V> implements java.io.Serializable"
+	if c.Java() != exp {
+		t.Errorf("unexpected java %s", c.Java())
+	}
+	if c.TypeParameters[1].ClassBound != nil || len(c.TypeParameters[1].InterfaceBounds) != 1 {
+		t.Errorf("unexpected bounds %+v", c.TypeParameters[1])
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	s := "<T:Ljava/lang/Object;E:Ljava/lang/Exception;>(Ljava/util/Map<TT;*>.Entry<+TT;[I>;[[TT;J)TT;^TE;^Ljava/io/IOException;"
+	m, err := ParseMethod(s)
+	if err != nil {
+		t.Errorf("failed to parse, error(%v)", err)
+		t.FailNow()
+	}
+	if m.String() != s {
+		t.Errorf("unexpected signature %s", m.String())
+	}
+	exp := "<T extends java.lang.Object, E extends java.lang.Exception> T f(java.util.Map<T, ?>.Entry<? extends T, int[]>, T[][], long) throws E, java.io.IOException"
+	if m.Java("f") != exp {
+		t.Errorf("unexpected java %s", m.Java("f"))
+	}
+	ct := m.Params[0].(*ClassType)
+	if ct.Name() != "java/util/Map$Entry" || ct.Package != "java/util/" {
+		t.Errorf("unexpected class type %s %s", ct.Name(), ct.Package)
+	}
+}
+
+func TestParseField(t *testing.T) {
+	for _, s := range []string{"TT;", "[TT;", "Ljava/util/List<Ljava/lang/String;>;", "LFoo;"} {
+		f, err := ParseField(s)
+		if err != nil {
+			t.Errorf("failed to parse %s, error(%v)", s, err)
+			continue
+		}
+		if f.String() != s {
+			t.Errorf("unexpected signature %s, expected %s", f.String(), s)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{"", "I", "T;", "Ljava/util/List<>;", "Ljava/util/List", "TT;I", "L/a;"} {
+		if _, err := ParseField(s); err == nil {
+			t.Errorf("expected error for field %q", s)
+		}
+	}
+	for _, s := range []string{"", "()", "<>()V", "()V^I", "(V)V", "()VI"} {
+		if _, err := ParseMethod(s); err == nil {
+			t.Errorf("expected error for method %q", s)
+		}
+	}
+	for _, s := range []string{"", "<T>Ljava/lang/Object;", "Ljava/lang/Object;I"} {
+		if _, err := ParseClass(s); err == nil {
+			t.Errorf("expected error for class %q", s)
+		}
+	}
+}